@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+	"github.com/pevin/pevin-golang-training-beginner/signature"
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+)
+
+// APIKeyMiddleware authenticates requests by the API key in their
+// Authorization header and adds the merchant of the key to the request
+// context, where the usecases scope payment codes by it.
+//
+// Requests carrying signature headers are also verified with the signing
+// secret of the key, and keys that require signatures are refused without
+// them.
+type APIKeyMiddleware struct {
+	Usecase    usecase.IAPIKeyUseCase
+	Signatures signature.Verifier
+}
+
+// Wrap requires the read scope for GET and HEAD requests and the write scope
+// for every other method.
+func (m APIKeyMiddleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope := model.API_KEY_SCOPE_WRITE
+		if r.Method == "GET" || r.Method == "HEAD" {
+			scope = model.API_KEY_SCOPE_READ
+		}
+		m.authenticate(w, r, scope, next)
+	}
+}
+
+// WrapAdmin requires the admin scope.
+func (m APIKeyMiddleware) WrapAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m.authenticate(w, r, model.API_KEY_SCOPE_ADMIN, next)
+	}
+}
+
+func (m APIKeyMiddleware) authenticate(w http.ResponseWriter, r *http.Request, scope string, next http.HandlerFunc) {
+	key, ok := bearerToken(r)
+	if !ok {
+		unauthorizedHandler(w, "header 'Authorization' must be 'Bearer <API key>'")
+		return
+	}
+
+	apiKey, err := m.Usecase.Authenticate(r.Context(), key)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	if (apiKey.RequireSignature || signature.Signed(r)) && !m.verifySignature(w, r, apiKey) {
+		return
+	}
+
+	if !apiKey.HasScope(scope) {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("API key lacks the '%s' scope", scope))
+		return
+	}
+
+	next(w, r.WithContext(usecase.WithMerchantID(r.Context(), apiKey.MerchantId)))
+}
+
+// verifySignature checks the signature of r and answers 401 when it is not
+// valid. The body is read to be hashed and then put back.
+func (m APIKeyMiddleware) verifySignature(w http.ResponseWriter, r *http.Request, apiKey model.APIKey) (ok bool) {
+	if apiKey.SigningSecret == "" {
+		unauthorizedHandler(w, "API key has no signing secret, rotate it to get one")
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		errorHandler(w, r, readBodyError(err))
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	err = m.Signatures.Verify(r, body, apiKey.Id, apiKey.SigningSecret, time.Now())
+	switch {
+	case errors.Is(err, signature.ErrMissing):
+		unauthorizedHandler(w, fmt.Sprintf("API key requires signed requests, headers '%s', '%s' and '%s' are required", signature.HEADER_SIGNATURE, signature.HEADER_TIMESTAMP, signature.HEADER_NONCE))
+	case errors.Is(err, signature.ErrMalformed):
+		unauthorizedHandler(w, fmt.Sprintf("header '%s' must be Unix seconds and header '%s' at most %d characters", signature.HEADER_TIMESTAMP, signature.HEADER_NONCE, signature.MAX_NONCE_LENGTH))
+	case errors.Is(err, signature.ErrExpired):
+		unauthorizedHandler(w, fmt.Sprintf("header '%s' must be within %s of the server time", signature.HEADER_TIMESTAMP, m.Signatures.Window))
+	case err != nil:
+		unauthorizedHandler(w, err.Error())
+	default:
+		ok = true
+	}
+	return
+}
+
+// bearerToken returns the token of an Authorization header using the Bearer
+// scheme.
+func bearerToken(r *http.Request) (token string, ok bool) {
+	const scheme = "bearer "
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return
+	}
+	token = strings.TrimSpace(header[len(scheme):])
+	return token, token != ""
+}
+
+func unauthorizedHandler(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	writeError(w, http.StatusUnauthorized, message)
+}
+
+type APIKeyHandler struct {
+	Usecase usecase.IAPIKeyUseCase
+}
+
+// routeHandler routes /api-keys and /api-keys/{id}. A key is revoked with
+// DELETE /api-keys/{id} and rotated with POST /api-keys/{id}:rotate.
+func (h *APIKeyHandler) routeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api-keys" {
+		switch r.Method {
+		case "POST":
+			h.createHandler(w, r)
+			return
+		case "GET":
+			h.listHandler(w, r)
+			return
+		}
+		notFoundHandler(w, r)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api-keys/")
+	switch {
+	case r.Method == "POST" && strings.HasSuffix(id, ":rotate"):
+		h.rotateHandler(w, r, strings.TrimSuffix(id, ":rotate"))
+	case r.Method == "DELETE" && id != "" && !strings.Contains(id, ":"):
+		h.revokeHandler(w, r, id)
+	default:
+		notFoundHandler(w, r)
+	}
+}
+
+func (h *APIKeyHandler) createHandler(w http.ResponseWriter, r *http.Request) {
+	var apiKey model.APIKey
+	err := decodeJSON(r, &apiKey)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	details, err := validatePayload(apiKey)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+	if len(details) > 0 {
+		validationErrorHandler(w, r, validationError(details))
+		return
+	}
+
+	err = h.Usecase.Create(r.Context(), &apiKey)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	writeAPIKey(w, http.StatusCreated, apiKey)
+}
+
+func (h *APIKeyHandler) listHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := h.Usecase.List(r.Context())
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(list)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+func (h *APIKeyHandler) rotateHandler(w http.ResponseWriter, r *http.Request, id string) {
+	apiKey, err := h.Usecase.Rotate(r.Context(), id)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	writeAPIKey(w, http.StatusOK, apiKey)
+}
+
+func (h *APIKeyHandler) revokeHandler(w http.ResponseWriter, r *http.Request, id string) {
+	apiKey, err := h.Usecase.Revoke(r.Context(), id)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	writeAPIKey(w, http.StatusOK, apiKey)
+}
+
+// writeAPIKey answers with apiKey. Responses holding the key or the signing
+// secret must not be cached.
+func writeAPIKey(w http.ResponseWriter, status int, apiKey model.APIKey) {
+	resp, _ := json.Marshal(apiKey)
+
+	w.Header().Set("Content-Type", "application/json")
+	if apiKey.Key != "" || apiKey.SigningSecret != "" {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.WriteHeader(status)
+	w.Write(resp)
+}
+
+// runAPIKey is the apikey subcommand. It creates, rotates and revokes the API
+// keys of a merchant and prints the key as JSON; the key and signing secret
+// of created and rotated keys are only shown this once. It returns the exit
+// code.
+func runAPIKey(ctx context.Context, cfg config.Config, args []string) int {
+	usage := func(flags *flag.FlagSet) {
+		fmt.Fprintln(flags.Output(), "usage: apikey create -merchant id -name name [-scopes read,write,admin] [-require-signature]")
+		fmt.Fprintln(flags.Output(), "       apikey rotate -merchant id key-id")
+		fmt.Fprintln(flags.Output(), "       apikey revoke -merchant id key-id")
+		flags.PrintDefaults()
+	}
+
+	flags := flag.NewFlagSet("apikey", flag.ContinueOnError)
+	merchantID := flags.String("merchant", "", "merchant id the key belongs to")
+	name := flags.String("name", "", "name of a new key")
+	scopes := flags.String("scopes", model.API_KEY_SCOPE_READ+","+model.API_KEY_SCOPE_WRITE, "comma separated scopes of a new key")
+	requireSignature := flags.Bool("require-signature", false, "refuse unsigned requests made with a new key")
+	flags.Usage = func() { usage(flags) }
+
+	if len(args) == 0 {
+		usage(flags)
+		return 2
+	}
+	command := args[0]
+	if err := flags.Parse(args[1:]); err != nil || *merchantID == "" {
+		usage(flags)
+		return 2
+	}
+
+	apiKey := model.APIKey{Name: *name, Scopes: strings.Split(*scopes, ","), RequireSignature: *requireSignature}
+	switch {
+	case command == "create" && flags.NArg() == 0:
+		details, err := validatePayload(apiKey)
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		if len(details) > 0 {
+			log.Print(validationError(details).Message)
+			return 2
+		}
+	case (command == "rotate" || command == "revoke") && flags.NArg() == 1:
+	default:
+		usage(flags)
+		return 2
+	}
+
+	app, err := NewApp(ctx, cfg)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	defer func() {
+		if err := app.Shutdown(context.Background()); err != nil {
+			log.Printf("shutdown app: %v", err)
+		}
+	}()
+
+	ctx = usecase.WithMerchantID(ctx, *merchantID)
+	switch command {
+	case "create":
+		err = app.APIKeyHandler.Usecase.Create(ctx, &apiKey)
+	case "rotate":
+		apiKey, err = app.APIKeyHandler.Usecase.Rotate(ctx, flags.Arg(0))
+	case "revoke":
+		apiKey, err = app.APIKeyHandler.Usecase.Revoke(ctx, flags.Arg(0))
+	}
+	switch {
+	case errors.Is(err, repository.ErrInvalidInput):
+		log.Printf("merchant %s does not exist", *merchantID)
+		return 1
+	case errors.Is(err, repository.ErrNotFound):
+		log.Printf("merchant %s has no API key %s, or it is revoked", *merchantID, flags.Arg(0))
+		return 1
+	case err != nil:
+		log.Print(err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(apiKey); err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	return 0
+}
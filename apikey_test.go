@@ -0,0 +1,379 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mock_usecase "github.com/pevin/pevin-golang-training-beginner/mock/usecase"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+	"github.com/pevin/pevin-golang-training-beginner/signature"
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+)
+
+func TestAPIKeyMiddleware_Wrap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	readKey := model.APIKey{Id: "test-id", MerchantId: "test-merchant", Scopes: []string{model.API_KEY_SCOPE_READ}}
+	writeKey := readKey
+	writeKey.Scopes = []string{model.API_KEY_SCOPE_WRITE}
+
+	newRequest := func(method string, authorization string) *http.Request {
+		req, err := http.NewRequest(method, "/payment-codes", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return req
+	}
+	authenticate := func(key string, apiKey model.APIKey, err error) func() usecase.IAPIKeyUseCase {
+		return func() usecase.IAPIKeyUseCase {
+			uc := mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+			uc.
+				EXPECT().
+				Authenticate(gomock.Any(), key).
+				Return(apiKey, err)
+			return uc
+		}
+	}
+
+	tests := []struct {
+		name         string
+		usecase      func() usecase.IAPIKeyUseCase
+		admin        bool
+		r            *http.Request
+		wantStatus   int
+		wantMessage  string
+		wantMerchant string
+	}{
+		{
+			name:         "read-key-reads",
+			usecase:      authenticate("pk_test", readKey, nil),
+			r:            newRequest("GET", "Bearer pk_test"),
+			wantStatus:   http.StatusOK,
+			wantMerchant: "test-merchant",
+		},
+		{
+			name:         "write-key-writes",
+			usecase:      authenticate("pk_test", writeKey, nil),
+			r:            newRequest("POST", "bearer pk_test"),
+			wantStatus:   http.StatusOK,
+			wantMerchant: "test-merchant",
+		},
+		{
+			name:        "read-key-cannot-write",
+			usecase:     authenticate("pk_test", readKey, nil),
+			r:           newRequest("PATCH", "Bearer pk_test"),
+			wantStatus:  http.StatusForbidden,
+			wantMessage: "API key lacks the 'write' scope",
+		},
+		{
+			name:        "write-key-is-not-admin",
+			usecase:     authenticate("pk_test", writeKey, nil),
+			admin:       true,
+			r:           newRequest("GET", "Bearer pk_test"),
+			wantStatus:  http.StatusForbidden,
+			wantMessage: "API key lacks the 'admin' scope",
+		},
+		{
+			name: "missing-key",
+			usecase: func() usecase.IAPIKeyUseCase {
+				return mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+			},
+			r:           newRequest("GET", ""),
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "header 'Authorization' must be 'Bearer <API key>'",
+		},
+		{
+			name: "other-scheme",
+			usecase: func() usecase.IAPIKeyUseCase {
+				return mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+			},
+			r:           newRequest("GET", "Basic dXNlcjpwYXNz"),
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "header 'Authorization' must be 'Bearer <API key>'",
+		},
+		{
+			name:        "invalid-key",
+			usecase:     authenticate("pk_test", model.APIKey{}, usecase.ErrInvalidAPIKey),
+			r:           newRequest("GET", "Bearer pk_test"),
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: usecase.ErrInvalidAPIKey.Error(),
+		},
+		{
+			name:        "error-from-usecase",
+			usecase:     authenticate("pk_test", model.APIKey{}, fmt.Errorf("%w: mock", repository.ErrUnavailable)),
+			r:           newRequest("GET", "Bearer pk_test"),
+			wantStatus:  http.StatusServiceUnavailable,
+			wantMessage: repository.ErrUnavailable.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMerchant string
+			next := func(w http.ResponseWriter, r *http.Request) {
+				gotMerchant = usecase.MerchantIDFromContext(r.Context())
+			}
+
+			m := APIKeyMiddleware{Usecase: tt.usecase()}
+			handler := m.Wrap(next)
+			if tt.admin {
+				handler = m.WrapAdmin(next)
+			}
+
+			rec := httptest.NewRecorder()
+			handler(rec, tt.r)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("APIKeyMiddleware.Wrap() status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotMerchant != tt.wantMerchant {
+				t.Errorf("APIKeyMiddleware.Wrap() merchant = %q, want %q", gotMerchant, tt.wantMerchant)
+			}
+			if tt.wantMessage != "" {
+				var got model.Error
+				json.Unmarshal(rec.Body.Bytes(), &got)
+				if got.Message != tt.wantMessage {
+					t.Errorf("APIKeyMiddleware.Wrap() message = %q, want %q", got.Message, tt.wantMessage)
+				}
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("APIKeyMiddleware.Wrap() WWW-Authenticate = %q, want Bearer", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAPIKeyMiddleware_signatures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	apiKey := model.APIKey{Id: "test-id", MerchantId: "test-merchant", SigningSecret: "ss_test", Scopes: []string{model.API_KEY_SCOPE_WRITE}}
+	required := apiKey
+	required.RequireSignature = true
+	withoutSecret := apiKey
+	withoutSecret.SigningSecret = ""
+
+	body := `{"name":"test"}`
+	newRequest := func(secret string, nonce string) *http.Request {
+		req, err := http.NewRequest("POST", "/payment-codes", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer pk_test")
+		if secret != "" {
+			if err := signature.Sign(req, secret, time.Now(), nonce); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return req
+	}
+
+	tests := []struct {
+		name        string
+		apiKey      model.APIKey
+		r           *http.Request
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:       "unsigned-optional",
+			apiKey:     apiKey,
+			r:          newRequest("", ""),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "signed",
+			apiKey:     required,
+			r:          newRequest("ss_test", "test-nonce"),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "unsigned-required",
+			apiKey:      required,
+			r:           newRequest("", ""),
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "API key requires signed requests, headers 'X-Signature', 'X-Signature-Timestamp' and 'X-Signature-Nonce' are required",
+		},
+		{
+			name:        "signed-with-other-secret",
+			apiKey:      apiKey,
+			r:           newRequest("ss_other", "test-nonce"),
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: signature.ErrMismatch.Error(),
+		},
+		{
+			name:        "signed-without-secret",
+			apiKey:      withoutSecret,
+			r:           newRequest("ss_test", "test-nonce"),
+			wantStatus:  http.StatusUnauthorized,
+			wantMessage: "API key has no signing secret, rotate it to get one",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+			uc.
+				EXPECT().
+				Authenticate(gomock.Any(), "pk_test").
+				Return(tt.apiKey, nil)
+
+			var gotBody []byte
+			m := APIKeyMiddleware{
+				Usecase:    uc,
+				Signatures: signature.Verifier{Window: time.Minute, Nonces: signature.NewNonceCache()},
+			}
+			handler := m.Wrap(func(w http.ResponseWriter, r *http.Request) {
+				gotBody, _ = ioutil.ReadAll(r.Body)
+			})
+
+			rec := httptest.NewRecorder()
+			handler(rec, tt.r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("APIKeyMiddleware.Wrap() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusOK && string(gotBody) != body {
+				t.Errorf("APIKeyMiddleware.Wrap() passed body %q, want %q", gotBody, body)
+			}
+			if tt.wantMessage != "" {
+				var got model.Error
+				json.Unmarshal(rec.Body.Bytes(), &got)
+				if got.Message != tt.wantMessage {
+					t.Errorf("APIKeyMiddleware.Wrap() message = %q, want %q", got.Message, tt.wantMessage)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_routeHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	apiKey := model.APIKey{Id: "test-id", MerchantId: "test-merchant", Name: "test name", Prefix: "0123456789abcdef", Scopes: []string{model.API_KEY_SCOPE_READ}}
+	withKey := apiKey
+	withKey.Key = "pk_0123456789abcdef_secret"
+
+	newRequest := func(method string, path string, body string) *http.Request {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	tests := []struct {
+		name       string
+		usecase    func() usecase.IAPIKeyUseCase
+		r          *http.Request
+		wantStatus int
+		wantBody   interface{}
+	}{
+		{
+			name: "create",
+			usecase: func() usecase.IAPIKeyUseCase {
+				uc := mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+				uc.
+					EXPECT().
+					Create(gomock.Any(), &model.APIKey{Name: "test name", Scopes: []string{model.API_KEY_SCOPE_READ}}).
+					DoAndReturn(func(_ interface{}, k *model.APIKey) error {
+						*k = withKey
+						return nil
+					})
+				return uc
+			},
+			r:          newRequest("POST", "/api-keys", `{"name":"test name","scopes":["read"]}`),
+			wantStatus: http.StatusCreated,
+			wantBody:   withKey,
+		},
+		{
+			name: "create-with-unknown-scope",
+			usecase: func() usecase.IAPIKeyUseCase {
+				return mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+			},
+			r:          newRequest("POST", "/api-keys", `{"name":"test name","scopes":["root"]}`),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "list",
+			usecase: func() usecase.IAPIKeyUseCase {
+				uc := mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+				uc.
+					EXPECT().
+					List(gomock.Any()).
+					Return(model.APIKeyList{Data: []model.APIKey{apiKey}}, nil)
+				return uc
+			},
+			r:          newRequest("GET", "/api-keys", ""),
+			wantStatus: http.StatusOK,
+			wantBody:   model.APIKeyList{Data: []model.APIKey{apiKey}},
+		},
+		{
+			name: "rotate",
+			usecase: func() usecase.IAPIKeyUseCase {
+				uc := mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+				uc.
+					EXPECT().
+					Rotate(gomock.Any(), "test-id").
+					Return(withKey, nil)
+				return uc
+			},
+			r:          newRequest("POST", "/api-keys/test-id:rotate", ""),
+			wantStatus: http.StatusOK,
+			wantBody:   withKey,
+		},
+		{
+			name: "revoke-unknown",
+			usecase: func() usecase.IAPIKeyUseCase {
+				uc := mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+				uc.
+					EXPECT().
+					Revoke(gomock.Any(), "unknown-id").
+					Return(model.APIKey{}, fmt.Errorf("%w: mock", repository.ErrNotFound))
+				return uc
+			},
+			r:          newRequest("DELETE", "/api-keys/unknown-id", ""),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "unknown-route",
+			usecase: func() usecase.IAPIKeyUseCase {
+				return mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+			},
+			r:          newRequest("PUT", "/api-keys/test-id", ""),
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &APIKeyHandler{Usecase: tt.usecase()}
+			rec := httptest.NewRecorder()
+			h.routeHandler(rec, tt.r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("APIKeyHandler.routeHandler() status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantBody != nil {
+				want, _ := json.Marshal(tt.wantBody)
+				if got := rec.Body.String(); got != string(want) {
+					t.Errorf("APIKeyHandler.routeHandler() body = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/generator"
+	"github.com/pevin/pevin-golang-training-beginner/producer"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+	"github.com/pevin/pevin-golang-training-beginner/signature"
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+	"github.com/pevin/pevin-golang-training-beginner/worker"
+)
+
+type backgroundWorker interface {
+	Run(ctx context.Context)
+}
+
+// App holds the dependencies shared by every request. It is built once in
+// main and must be shut down to stop the workers and release the connection
+// pool.
+type App struct {
+	DB                 *sql.DB
+	Producer           producer.IPaymentCodeMessageProducer
+	PaymentCodeHandler *PaymentCodeHandler
+	PaymentHandler     *PaymentHandler
+	APIKeyHandler      *APIKeyHandler
+	WebhookHandler     *WebhookHandler
+	Merchants          usecase.IMerchantUseCase
+	Auth               APIKeyMiddleware
+	Idempotency        IdempotencyMiddleware
+	// MaxBodyBytes and MaxUploadBytes cap the request bodies, the latter
+	// for CSV imports. ImportTimeout and ExportTimeout extend the server
+	// timeouts for CSV imports and exports.
+	MaxBodyBytes       int64
+	MaxUploadBytes     int64
+	ImportTimeout      time.Duration
+	ExportTimeout      time.Duration
+	ExpirationWorker   worker.ExpirationWorker
+	OutboxRelay        worker.OutboxRelay
+	IdempotencyCleanup worker.IdempotencyCleanupWorker
+	WebhookDispatcher  worker.WebhookDispatcher
+
+	workers     sync.WaitGroup
+	stopWorkers context.CancelFunc
+}
+
+func NewApp(ctx context.Context, cfg config.Config) (app *App, err error) {
+	db, err := openDB(ctx, cfg.DB)
+	if err != nil {
+		return
+	}
+
+	transactor := repository.Transactor{Db: db}
+	outboxRepo := repository.OutboxRepository{Db: db, Config: cfg.DB}
+	webhookRepo := repository.WebhookRepository{Db: db, Config: cfg.DB}
+	pcRepo := repository.PaymentCodeRepository{Db: db, Config: cfg.DB}
+	pcProducer := producer.PaymentCodeMessageProducer{Config: cfg.Producer, Outbox: outboxRepo, Webhooks: webhookRepo}
+	pcUsecase := usecase.PaymentCodeUseCase{
+		Repo:             pcRepo,
+		Producer:         pcProducer,
+		Transactor:       transactor,
+		ExpirationPolicy: cfg.Expiration,
+		Generator:        generator.Generator{Format: cfg.Generator.Format()},
+		GenerateAttempts: cfg.Generator.MaxAttempts,
+	}
+	paymentUsecase := usecase.PaymentUseCase{
+		PaymentCodeRepo: pcRepo,
+		Repo:            repository.PaymentRepository{Db: db, Config: cfg.DB},
+		Producer:        pcProducer,
+		Transactor:      transactor,
+		CodeFormat:      cfg.Generator.Format(),
+	}
+	merchantUsecase := usecase.MerchantUseCase{
+		Repo: repository.MerchantRepository{Db: db, Config: cfg.DB},
+	}
+	apiKeyUsecase := usecase.APIKeyUseCase{
+		Repo: repository.APIKeyRepository{Db: db, Config: cfg.DB},
+	}
+	auth := APIKeyMiddleware{
+		Usecase: apiKeyUsecase,
+		Signatures: signature.Verifier{
+			Window: cfg.Server.SignatureWindow.Duration(),
+			Nonces: signature.NewNonceCache(),
+		},
+	}
+	idempotencyUsecase := usecase.IdempotencyUseCase{
+		Repo:        repository.IdempotencyKeyRepository{Db: db, Config: cfg.DB},
+		TTL:         cfg.Idempotency.TTL.Duration(),
+		LockTimeout: cfg.Idempotency.LockTimeout.Duration(),
+	}
+
+	app = &App{
+		DB:                 db,
+		Producer:           pcProducer,
+		PaymentCodeHandler: &PaymentCodeHandler{Usecase: pcUsecase},
+		PaymentHandler:     &PaymentHandler{Usecase: paymentUsecase},
+		APIKeyHandler:      &APIKeyHandler{Usecase: apiKeyUsecase},
+		WebhookHandler:     &WebhookHandler{Usecase: usecase.WebhookUseCase{Repo: webhookRepo}},
+		Merchants:          merchantUsecase,
+		Auth:               auth,
+		Idempotency:        IdempotencyMiddleware{Usecase: idempotencyUsecase},
+		MaxBodyBytes:       int64(cfg.Server.MaxBodyBytes),
+		MaxUploadBytes:     int64(cfg.Server.MaxUploadBytes),
+		ImportTimeout:      cfg.Server.ImportTimeout.Duration(),
+		ExportTimeout:      cfg.Server.ExportTimeout.Duration(),
+		ExpirationWorker: worker.ExpirationWorker{
+			Usecase:   pcUsecase,
+			Interval:  cfg.ExpirationWorker.Interval.Duration(),
+			BatchSize: cfg.ExpirationWorker.BatchSize,
+		},
+		OutboxRelay: worker.OutboxRelay{
+			Outbox:     outboxRepo,
+			Publisher:  newPublisher(cfg.Producer),
+			Interval:   cfg.Producer.RelayInterval.Duration(),
+			BatchSize:  cfg.Producer.BatchSize,
+			MinBackoff: cfg.Producer.MinBackoff.Duration(),
+			MaxBackoff: cfg.Producer.MaxBackoff.Duration(),
+			Lease:      cfg.Producer.RelayLease.Duration(),
+		},
+		IdempotencyCleanup: worker.IdempotencyCleanupWorker{
+			Usecase:   idempotencyUsecase,
+			Interval:  cfg.Idempotency.CleanupInterval.Duration(),
+			BatchSize: cfg.Idempotency.CleanupBatchSize,
+		},
+		WebhookDispatcher: worker.WebhookDispatcher{
+			Repo:        webhookRepo,
+			Client:      worker.NewWebhookClient(cfg.Webhook.Timeout.Duration()),
+			Interval:    cfg.Webhook.Interval.Duration(),
+			BatchSize:   cfg.Webhook.BatchSize,
+			MaxAttempts: cfg.Webhook.MaxAttempts,
+			MinBackoff:  cfg.Webhook.MinBackoff.Duration(),
+			MaxBackoff:  cfg.Webhook.MaxBackoff.Duration(),
+			Lease:       cfg.Webhook.Lease.Duration(),
+		},
+	}
+
+	return
+}
+
+func newPublisher(cfg config.Producer) producer.Publisher {
+	switch cfg.Publisher {
+	case config.PUBLISHER_HTTP:
+		return producer.HTTPPublisher{
+			URL:    cfg.URL,
+			Client: &http.Client{Timeout: cfg.Timeout.Duration()},
+		}
+	default:
+		return producer.LogPublisher{}
+	}
+}
+
+func (a *App) Routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", healthHandler)
+	mux.HandleFunc("/hello-world", helloWorldHandler)
+
+	mux.HandleFunc("/payment-codes", limitBody(a.MaxBodyBytes, a.Auth.Wrap(a.Idempotency.Wrap(a.PaymentCodeHandler.routeHandler))))
+	mux.HandleFunc("/payment-codes:batch", limitBody(a.MaxBodyBytes, a.Auth.Wrap(a.Idempotency.Wrap(a.PaymentCodeHandler.actionRouteHandler))))
+	mux.HandleFunc("/payment-codes:export", extendDeadlines(0, a.ExportTimeout, limitBody(a.MaxBodyBytes, a.Auth.Wrap(a.Idempotency.Wrap(a.PaymentCodeHandler.actionRouteHandler)))))
+	mux.HandleFunc("/payment-codes:import", extendDeadlines(a.ImportTimeout, a.ImportTimeout, limitBody(a.MaxUploadBytes, a.Auth.Wrap(a.PaymentCodeHandler.actionRouteHandler))))
+	mux.HandleFunc("/payment-codes/", limitBody(a.MaxBodyBytes, a.Auth.Wrap(a.routePaymentCodes)))
+
+	mux.HandleFunc("/api-keys", limitBody(a.MaxBodyBytes, a.Auth.WrapAdmin(a.APIKeyHandler.routeHandler)))
+	mux.HandleFunc("/api-keys/", limitBody(a.MaxBodyBytes, a.Auth.WrapAdmin(a.APIKeyHandler.routeHandler)))
+
+	mux.HandleFunc("/webhook-endpoints", limitBody(a.MaxBodyBytes, a.Auth.WrapAdmin(a.WebhookHandler.endpointRouteHandler)))
+	mux.HandleFunc("/webhook-endpoints/", limitBody(a.MaxBodyBytes, a.Auth.WrapAdmin(a.WebhookHandler.endpointRouteHandler)))
+	mux.HandleFunc("/webhook-deliveries", limitBody(a.MaxBodyBytes, a.Auth.Wrap(a.WebhookHandler.deliveryRouteHandler)))
+	mux.HandleFunc("/webhook-deliveries/", limitBody(a.MaxBodyBytes, a.Auth.Wrap(a.WebhookHandler.deliveryRouteHandler)))
+
+	mux.HandleFunc("/", notFoundHandler)
+
+	return mux
+}
+
+// routePaymentCodes sends /payment-codes/{payment_code}/payments to the
+// payment handler and everything else under /payment-codes/ to the payment
+// code handler.
+func (a *App) routePaymentCodes(w http.ResponseWriter, r *http.Request) {
+	if _, ok := parsePaymentsPath(r.URL.Path); ok {
+		a.PaymentHandler.routeHandler(w, r)
+		return
+	}
+	a.PaymentCodeHandler.routeHandler(w, r)
+}
+
+func (a *App) NewServer(cfg config.Server) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           a.Routes(),
+		ReadTimeout:       cfg.ReadTimeout.Duration(),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout.Duration(),
+		WriteTimeout:      cfg.WriteTimeout.Duration(),
+		IdleTimeout:       cfg.IdleTimeout.Duration(),
+		ConnContext:       withConn,
+	}
+}
+
+type connKey struct{}
+
+// withConn keeps the connection of a request in its context, so
+// extendDeadlines can reach it. Set it as the ConnContext of the server.
+func withConn(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, c)
+}
+
+// extendDeadlines moves the read and write deadlines of the connection, set
+// by the ReadTimeout and WriteTimeout of the server when the request arrived,
+// to read and write from now. A zero duration leaves that deadline alone.
+// The server must keep the connection in the context with withConn, and
+// serve HTTP/1 only, where a connection carries one request at a time.
+func extendDeadlines(read time.Duration, write time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := r.Context().Value(connKey{}).(net.Conn); ok {
+			now := time.Now()
+			if read > 0 {
+				c.SetReadDeadline(now.Add(read))
+			}
+			if write > 0 {
+				c.SetWriteDeadline(now.Add(write))
+			}
+		}
+		next(w, r)
+	}
+}
+
+// StartWorkers runs the background workers until ctx is cancelled or the app
+// is shut down.
+func (a *App) StartWorkers(ctx context.Context) {
+	ctx, a.stopWorkers = context.WithCancel(ctx)
+
+	workers := []backgroundWorker{
+		a.ExpirationWorker,
+		a.OutboxRelay,
+		a.IdempotencyCleanup,
+		a.WebhookDispatcher,
+	}
+
+	for _, w := range workers {
+		a.workers.Add(1)
+		go func(w backgroundWorker) {
+			defer a.workers.Done()
+			w.Run(ctx)
+		}(w)
+	}
+}
+
+// Shutdown stops the workers, waits for them to finish, then closes the
+// producer and the connection pool. The producer and the pool are closed
+// even when ctx expires before the workers are done.
+func (a *App) Shutdown(ctx context.Context) (err error) {
+	if a.stopWorkers != nil {
+		a.stopWorkers()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		a.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("wait for workers: %w", ctx.Err())
+	}
+
+	if closeErr := a.Producer.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("close producer: %w", closeErr)
+	}
+
+	if closeErr := a.DB.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("close database: %w", closeErr)
+	}
+
+	return
+}
+
+// openDB opens the connection pool and waits for the database to accept
+// connections, retrying with exponential backoff.
+func openDB(ctx context.Context, cfg config.DB) (db *sql.DB, err error) {
+	db, err = sql.Open("postgres", cfg.DataSourceName())
+	if err != nil {
+		return
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime.Duration())
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime.Duration())
+
+	backoff := cfg.ConnectBackoff.Duration()
+	for attempt := 0; ; attempt++ {
+		err = db.PingContext(ctx)
+		if err == nil {
+			return
+		}
+
+		if attempt >= cfg.ConnectRetries {
+			break
+		}
+
+		log.Printf("database is not ready (attempt %d/%d): %v, retrying in %s", attempt+1, cfg.ConnectRetries+1, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(backoff):
+			backoff *= 2
+			continue
+		}
+		break
+	}
+
+	db.Close()
+	db = nil
+	err = fmt.Errorf("connect to database: %w", err)
+
+	return
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	mock_producer "github.com/pevin/pevin-golang-training-beginner/mock/producer"
+	mock_repository "github.com/pevin/pevin-golang-training-beginner/mock/repository"
+	mock_usecase "github.com/pevin/pevin-golang-training-beginner/mock/usecase"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/worker"
+
+	"github.com/golang/mock/gomock"
+)
+
+func Test_openDB(t *testing.T) {
+	unreachable := config.DB{
+		Host:           "127.0.0.1",
+		Port:           1,
+		User:           "postgres",
+		Password:       "postgres",
+		Name:           "traingolang",
+		SSLMode:        "disable",
+		ConnectRetries: 2,
+		ConnectBackoff: config.Duration(time.Millisecond),
+	}
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		config config.DB
+	}{
+		{
+			name:   "unreachable-after-retries",
+			ctx:    context.Background(),
+			config: unreachable,
+		},
+		{
+			name:   "cancelled-while-waiting",
+			ctx:    cancelledCtx,
+			config: unreachable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := openDB(tt.ctx, tt.config)
+			if err == nil {
+				t.Errorf("openDB() error = nil, want error")
+			}
+			if db != nil {
+				t.Errorf("openDB() = %v, want nil", db)
+			}
+		})
+	}
+}
+
+func TestApp_Shutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockErr := errors.New("Mock Error")
+
+	tests := []struct {
+		name     string
+		closeErr error
+		wantErr  bool
+	}{
+		{
+			name: "shutdown-success",
+		},
+		{
+			name:     "with-error-in-producer",
+			closeErr: mockErr,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sql.Open("postgres", "host=127.0.0.1 port=1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+			uc.EXPECT().ExpireDue(gomock.Any(), gomock.Any()).Return(0, nil).AnyTimes()
+
+			idempotency := mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+			idempotency.EXPECT().DeleteExpired(gomock.Any(), gomock.Any()).Return(0, nil).AnyTimes()
+
+			outbox := mock_repository.NewMockIOutboxRepository(ctrl)
+			outbox.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+			webhookRepo := mock_repository.NewMockIWebhookRepository(ctrl)
+			webhookRepo.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+			pcProducer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+			pcProducer.EXPECT().Close().Return(tt.closeErr)
+
+			app := &App{
+				DB:                 db,
+				Producer:           pcProducer,
+				ExpirationWorker:   worker.ExpirationWorker{Usecase: uc, Interval: time.Millisecond},
+				OutboxRelay:        worker.OutboxRelay{Outbox: outbox, Interval: time.Millisecond},
+				IdempotencyCleanup: worker.IdempotencyCleanupWorker{Usecase: idempotency, Interval: time.Millisecond},
+				WebhookDispatcher:  worker.WebhookDispatcher{Repo: webhookRepo, Interval: time.Millisecond},
+			}
+			app.StartWorkers(context.Background())
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			if err := app.Shutdown(ctx); (err != nil) != tt.wantErr {
+				t.Errorf("App.Shutdown() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApp_exportTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name          string
+		exportTimeout time.Duration
+		wantComplete  bool
+	}{
+		{
+			name:          "export-outlives-write-timeout",
+			exportTimeout: 5 * time.Second,
+			wantComplete:  true,
+		},
+		{
+			name:         "export-cut-by-write-timeout",
+			wantComplete: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKeys := mock_usecase.NewMockIAPIKeyUseCase(ctrl)
+			apiKeys.
+				EXPECT().
+				Authenticate(gomock.Any(), "test-key").
+				Return(model.APIKey{MerchantId: "test-merchant", Scopes: []string{model.API_KEY_SCOPE_READ}}, nil)
+
+			// the second page is only ready after the write timeout of the
+			// server has passed
+			uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+			gomock.InOrder(
+				uc.
+					EXPECT().
+					List(gomock.Any(), gomock.Any()).
+					Return(model.PaymentCodeList{Data: []model.PaymentCode{{Id: "test-id-1"}}, NextCursor: "test-cursor"}, nil),
+				uc.
+					EXPECT().
+					List(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, filter model.PaymentCodeFilter) (model.PaymentCodeList, error) {
+						time.Sleep(300 * time.Millisecond)
+						return model.PaymentCodeList{Data: []model.PaymentCode{{Id: "test-id-2"}}}, nil
+					}).
+					MaxTimes(1),
+			)
+
+			app := &App{
+				PaymentCodeHandler: &PaymentCodeHandler{Usecase: uc},
+				Auth:               APIKeyMiddleware{Usecase: apiKeys},
+				ExportTimeout:      tt.exportTimeout,
+			}
+			server := httptest.NewUnstartedServer(nil)
+			server.Config = app.NewServer(config.Server{
+				ReadTimeout:  config.Duration(time.Second),
+				WriteTimeout: config.Duration(100 * time.Millisecond),
+			})
+			server.Start()
+			defer server.Close()
+
+			req, err := http.NewRequest("GET", server.URL+"/payment-codes:export?format=jsonl", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer test-key")
+
+			resp, err := server.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+
+			complete := err == nil && strings.Count(string(body), "\n") == 2
+			if complete != tt.wantComplete {
+				t.Errorf("export complete = %v, want %v: %s (%v)", complete, tt.wantComplete, body, err)
+			}
+		})
+	}
+}
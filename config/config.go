@@ -0,0 +1,497 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/generator"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the service. It is loaded once at startup by
+// Load and then passed down to the components that need it.
+type Config struct {
+	Server           Server           `json:"server" yaml:"server"`
+	DB               DB               `json:"db" yaml:"db"`
+	Producer         Producer         `json:"producer" yaml:"producer"`
+	ExpirationWorker ExpirationWorker `json:"expiration_worker" yaml:"expiration_worker"`
+	Expiration       ExpirationPolicy `json:"expiration" yaml:"expiration"`
+	Generator        Generator        `json:"generator" yaml:"generator"`
+	Idempotency      Idempotency      `json:"idempotency" yaml:"idempotency"`
+	Webhook          Webhook          `json:"webhook" yaml:"webhook"`
+}
+
+type Server struct {
+	Addr              string   `json:"addr" yaml:"addr"`
+	ReadTimeout       Duration `json:"read_timeout" yaml:"read_timeout"`
+	ReadHeaderTimeout Duration `json:"read_header_timeout" yaml:"read_header_timeout"`
+	WriteTimeout      Duration `json:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout" yaml:"idle_timeout"`
+	ShutdownTimeout   Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	// MaxBodyBytes caps JSON request bodies. MaxUploadBytes caps the CSV
+	// files sent to the import endpoint.
+	MaxBodyBytes   int `json:"max_body_bytes" yaml:"max_body_bytes"`
+	MaxUploadBytes int `json:"max_upload_bytes" yaml:"max_upload_bytes"`
+	// SignatureWindow is how far the timestamp of a signed request may be
+	// from the server time, either way.
+	SignatureWindow Duration `json:"signature_window" yaml:"signature_window"`
+	// ImportTimeout and ExportTimeout replace ReadTimeout and WriteTimeout
+	// for CSV imports and for exports, which upload or stream far more data
+	// than other requests.
+	ImportTimeout Duration `json:"import_timeout" yaml:"import_timeout"`
+	ExportTimeout Duration `json:"export_timeout" yaml:"export_timeout"`
+}
+
+type DB struct {
+	// DSN overrides every connection setting below when it is set.
+	DSN             string   `json:"dsn" yaml:"dsn"`
+	Host            string   `json:"host" yaml:"host"`
+	Port            int      `json:"port" yaml:"port"`
+	User            string   `json:"user" yaml:"user"`
+	Password        string   `json:"password" yaml:"password"`
+	Name            string   `json:"name" yaml:"name"`
+	SSLMode         string   `json:"sslmode" yaml:"sslmode"`
+	MaxOpenConns    int      `json:"max_open_conns" yaml:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns" yaml:"max_idle_conns"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `json:"conn_max_idle_time" yaml:"conn_max_idle_time"`
+	ConnectRetries  int      `json:"connect_retries" yaml:"connect_retries"`
+	ConnectBackoff  Duration `json:"connect_backoff" yaml:"connect_backoff"`
+	// QueryTimeout bounds every repository query. Zero means no timeout.
+	QueryTimeout Duration `json:"query_timeout" yaml:"query_timeout"`
+}
+
+type Producer struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Topic   string `json:"topic" yaml:"topic"`
+	// Publisher is where the outbox relay delivers messages: "log" or "http".
+	Publisher     string   `json:"publisher" yaml:"publisher"`
+	URL           string   `json:"url" yaml:"url"`
+	Timeout       Duration `json:"timeout" yaml:"timeout"`
+	RelayInterval Duration `json:"relay_interval" yaml:"relay_interval"`
+	BatchSize     int      `json:"batch_size" yaml:"batch_size"`
+	MinBackoff    Duration `json:"min_backoff" yaml:"min_backoff"`
+	MaxBackoff    Duration `json:"max_backoff" yaml:"max_backoff"`
+	// RelayLease is how long a batch is leased to one relay. It must cover
+	// Timeout for every message of the batch.
+	RelayLease Duration `json:"relay_lease" yaml:"relay_lease"`
+}
+
+type ExpirationWorker struct {
+	Interval  Duration `json:"interval" yaml:"interval"`
+	BatchSize int      `json:"batch_size" yaml:"batch_size"`
+}
+
+// ExpirationRule bounds the expiration date of new payment codes. DefaultTTL
+// is used when the create request sets neither an expiration date nor a TTL.
+type ExpirationRule struct {
+	DefaultTTL Duration `json:"default_ttl" yaml:"default_ttl"`
+	MaxTTL     Duration `json:"max_ttl" yaml:"max_ttl"`
+}
+
+// ExpirationPolicy is the service-wide expiration rule with optional
+// overrides keyed by merchant id.
+type ExpirationPolicy struct {
+	ExpirationRule `yaml:",inline"`
+	Merchants      map[string]ExpirationRule `json:"merchants" yaml:"merchants"`
+}
+
+// For returns the rule of the given merchant. Settings the merchant does not
+// override fall back to the service-wide rule.
+func (p ExpirationPolicy) For(merchantID string) (rule ExpirationRule) {
+	rule = p.ExpirationRule
+
+	override, ok := p.Merchants[merchantID]
+	if !ok {
+		return
+	}
+	if override.DefaultTTL != 0 {
+		rule.DefaultTTL = override.DefaultTTL
+	}
+	if override.MaxTTL != 0 {
+		rule.MaxTTL = override.MaxTTL
+	}
+	return
+}
+
+// Generator is the format of the payment codes generated for create requests
+// without a payment_code. MaxAttempts bounds the retries on collisions.
+type Generator struct {
+	Prefix      string `json:"prefix" yaml:"prefix"`
+	Length      int    `json:"length" yaml:"length"`
+	Alphabet    string `json:"alphabet" yaml:"alphabet"`
+	CheckDigit  string `json:"check_digit" yaml:"check_digit"`
+	MaxAttempts int    `json:"max_attempts" yaml:"max_attempts"`
+}
+
+func (g Generator) Format() generator.Format {
+	return generator.Format{
+		Prefix:     g.Prefix,
+		Length:     g.Length,
+		Alphabet:   g.Alphabet,
+		CheckDigit: g.CheckDigit,
+	}
+}
+
+// Idempotency controls the Idempotency-Key header. A key is remembered for
+// TTL; a first request that has not finished within LockTimeout is assumed
+// lost and its key can be used again. Expired keys are deleted every
+// CleanupInterval.
+type Idempotency struct {
+	TTL              Duration `json:"ttl" yaml:"ttl"`
+	LockTimeout      Duration `json:"lock_timeout" yaml:"lock_timeout"`
+	CleanupInterval  Duration `json:"cleanup_interval" yaml:"cleanup_interval"`
+	CleanupBatchSize int      `json:"cleanup_batch_size" yaml:"cleanup_batch_size"`
+}
+
+// Webhook controls the delivery of webhooks to the endpoints of merchants.
+// Failed deliveries are retried with a backoff growing from MinBackoff to
+// MaxBackoff, and moved to the dead letter state after MaxAttempts attempts.
+// Timeout bounds every attempt. A batch is leased to one dispatcher for Lease,
+// which must cover Timeout for every delivery of the batch.
+type Webhook struct {
+	Interval    Duration `json:"interval" yaml:"interval"`
+	BatchSize   int      `json:"batch_size" yaml:"batch_size"`
+	Timeout     Duration `json:"timeout" yaml:"timeout"`
+	MaxAttempts int      `json:"max_attempts" yaml:"max_attempts"`
+	MinBackoff  Duration `json:"min_backoff" yaml:"min_backoff"`
+	MaxBackoff  Duration `json:"max_backoff" yaml:"max_backoff"`
+	Lease       Duration `json:"lease" yaml:"lease"`
+}
+
+const (
+	PUBLISHER_LOG  = "log"
+	PUBLISHER_HTTP = "http"
+)
+
+var (
+	sslModes   = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	publishers = []string{PUBLISHER_LOG, PUBLISHER_HTTP}
+)
+
+// Default returns the settings used when neither the config file nor the
+// environment sets a value.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:              ":8080",
+			ReadTimeout:       Duration(10 * time.Second),
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			WriteTimeout:      Duration(10 * time.Second),
+			IdleTimeout:       Duration(60 * time.Second),
+			ShutdownTimeout:   Duration(30 * time.Second),
+			MaxBodyBytes:      1 << 20,
+			MaxUploadBytes:    32 << 20,
+			SignatureWindow:   Duration(5 * time.Minute),
+			ImportTimeout:     Duration(5 * time.Minute),
+			ExportTimeout:     Duration(30 * time.Minute),
+		},
+		DB: DB{
+			Host:            "localhost",
+			Port:            5432,
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+			ConnMaxIdleTime: Duration(5 * time.Minute),
+			ConnectRetries:  5,
+			ConnectBackoff:  Duration(time.Second),
+			QueryTimeout:    Duration(5 * time.Second),
+		},
+		Producer: Producer{
+			Enabled:       true,
+			Topic:         "payment-codes",
+			Publisher:     PUBLISHER_LOG,
+			Timeout:       Duration(5 * time.Second),
+			RelayInterval: Duration(time.Second),
+			BatchSize:     100,
+			MinBackoff:    Duration(time.Second),
+			MaxBackoff:    Duration(10 * time.Minute),
+			RelayLease:    Duration(10 * time.Minute),
+		},
+		ExpirationWorker: ExpirationWorker{
+			Interval:  Duration(time.Minute),
+			BatchSize: 100,
+		},
+		// payment codes used to always expire after 50 years; shorter
+		// rules are opted in to through the config
+		Expiration: ExpirationPolicy{
+			ExpirationRule: ExpirationRule{
+				DefaultTTL: Duration(50 * 8766 * time.Hour),
+				MaxTTL:     Duration(50 * 8766 * time.Hour),
+			},
+		},
+		Generator: Generator{
+			Length:      12,
+			Alphabet:    generator.ALPHABET_NUMERIC,
+			CheckDigit:  generator.CHECK_DIGIT_LUHN,
+			MaxAttempts: 5,
+		},
+		Idempotency: Idempotency{
+			TTL:              Duration(24 * time.Hour),
+			LockTimeout:      Duration(time.Minute),
+			CleanupInterval:  Duration(time.Hour),
+			CleanupBatchSize: 1000,
+		},
+		Webhook: Webhook{
+			Interval:    Duration(time.Second),
+			BatchSize:   20,
+			Timeout:     Duration(10 * time.Second),
+			MaxAttempts: 10,
+			MinBackoff:  Duration(30 * time.Second),
+			MaxBackoff:  Duration(2 * time.Hour),
+			Lease:       Duration(5 * time.Minute),
+		},
+	}
+}
+
+// Load builds the config from the defaults, then the optional JSON or YAML
+// file at path, then the environment, and validates the result.
+func Load(path string) (config Config, err error) {
+	config = Default()
+
+	if path != "" {
+		err = config.loadFile(path)
+		if err != nil {
+			return
+		}
+	}
+
+	err = config.loadEnv(os.LookupEnv)
+	if err != nil {
+		return
+	}
+
+	err = config.Validate()
+
+	return
+}
+
+func (c *Config) loadFile(path string) (err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, c)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, c)
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return
+}
+
+func (c *Config) loadEnv(lookup func(string) (string, bool)) (err error) {
+	env := envLoader{lookup: lookup}
+
+	env.string("SERVER_ADDR", &c.Server.Addr)
+	env.duration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("SERVER_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout)
+	env.duration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	env.duration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	env.int("SERVER_MAX_BODY_BYTES", &c.Server.MaxBodyBytes)
+	env.int("SERVER_MAX_UPLOAD_BYTES", &c.Server.MaxUploadBytes)
+	env.duration("SERVER_SIGNATURE_WINDOW", &c.Server.SignatureWindow)
+	env.duration("SERVER_IMPORT_TIMEOUT", &c.Server.ImportTimeout)
+	env.duration("SERVER_EXPORT_TIMEOUT", &c.Server.ExportTimeout)
+
+	env.string("DB_DSN", &c.DB.DSN)
+	env.string("DB_HOST", &c.DB.Host)
+	env.int("DB_PORT", &c.DB.Port)
+	env.string("DB_USER", &c.DB.User)
+	env.string("DB_PASS", &c.DB.Password)
+	env.string("DB_NAME", &c.DB.Name)
+	env.string("DB_SSLMODE", &c.DB.SSLMode)
+	env.int("DB_MAX_OPEN_CONNS", &c.DB.MaxOpenConns)
+	env.int("DB_MAX_IDLE_CONNS", &c.DB.MaxIdleConns)
+	env.duration("DB_CONN_MAX_LIFETIME", &c.DB.ConnMaxLifetime)
+	env.duration("DB_CONN_MAX_IDLE_TIME", &c.DB.ConnMaxIdleTime)
+	env.int("DB_CONNECT_RETRIES", &c.DB.ConnectRetries)
+	env.duration("DB_CONNECT_BACKOFF", &c.DB.ConnectBackoff)
+	env.duration("DB_QUERY_TIMEOUT", &c.DB.QueryTimeout)
+
+	env.bool("PRODUCER_ENABLED", &c.Producer.Enabled)
+	env.string("PRODUCER_TOPIC", &c.Producer.Topic)
+	env.string("PRODUCER_PUBLISHER", &c.Producer.Publisher)
+	env.string("PRODUCER_URL", &c.Producer.URL)
+	env.duration("PRODUCER_TIMEOUT", &c.Producer.Timeout)
+	env.duration("PRODUCER_RELAY_INTERVAL", &c.Producer.RelayInterval)
+	env.int("PRODUCER_BATCH_SIZE", &c.Producer.BatchSize)
+	env.duration("PRODUCER_MIN_BACKOFF", &c.Producer.MinBackoff)
+	env.duration("PRODUCER_MAX_BACKOFF", &c.Producer.MaxBackoff)
+	env.duration("PRODUCER_RELAY_LEASE", &c.Producer.RelayLease)
+
+	env.duration("EXPIRATION_WORKER_INTERVAL", &c.ExpirationWorker.Interval)
+	env.int("EXPIRATION_WORKER_BATCH_SIZE", &c.ExpirationWorker.BatchSize)
+
+	env.duration("EXPIRATION_DEFAULT_TTL", &c.Expiration.DefaultTTL)
+	env.duration("EXPIRATION_MAX_TTL", &c.Expiration.MaxTTL)
+
+	env.string("GENERATOR_PREFIX", &c.Generator.Prefix)
+	env.int("GENERATOR_LENGTH", &c.Generator.Length)
+	env.string("GENERATOR_ALPHABET", &c.Generator.Alphabet)
+	env.string("GENERATOR_CHECK_DIGIT", &c.Generator.CheckDigit)
+	env.int("GENERATOR_MAX_ATTEMPTS", &c.Generator.MaxAttempts)
+
+	env.duration("IDEMPOTENCY_TTL", &c.Idempotency.TTL)
+	env.duration("IDEMPOTENCY_LOCK_TIMEOUT", &c.Idempotency.LockTimeout)
+	env.duration("IDEMPOTENCY_CLEANUP_INTERVAL", &c.Idempotency.CleanupInterval)
+	env.int("IDEMPOTENCY_CLEANUP_BATCH_SIZE", &c.Idempotency.CleanupBatchSize)
+
+	env.duration("WEBHOOK_INTERVAL", &c.Webhook.Interval)
+	env.int("WEBHOOK_BATCH_SIZE", &c.Webhook.BatchSize)
+	env.duration("WEBHOOK_TIMEOUT", &c.Webhook.Timeout)
+	env.int("WEBHOOK_MAX_ATTEMPTS", &c.Webhook.MaxAttempts)
+	env.duration("WEBHOOK_MIN_BACKOFF", &c.Webhook.MinBackoff)
+	env.duration("WEBHOOK_MAX_BACKOFF", &c.Webhook.MaxBackoff)
+	env.duration("WEBHOOK_LEASE", &c.Webhook.Lease)
+
+	return env.err()
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.MaxBodyBytes > 0, "server.max_body_bytes must be positive")
+	check(c.Server.MaxUploadBytes > 0, "server.max_upload_bytes must be positive")
+	check(c.Server.SignatureWindow > 0, "server.signature_window must be positive")
+	check(c.Server.ImportTimeout > 0, "server.import_timeout must be positive")
+	check(c.Server.ExportTimeout > 0, "server.export_timeout must be positive")
+
+	if c.DB.DSN == "" {
+		check(c.DB.Host != "", "db.host is required")
+		check(c.DB.Port > 0 && c.DB.Port <= 65535, "db.port must be between 1 and 65535")
+		check(c.DB.User != "", "db.user is required")
+		check(c.DB.Name != "", "db.name is required")
+		check(contains(sslModes, c.DB.SSLMode), "db.sslmode must be one of %s", strings.Join(sslModes, ", "))
+	}
+	check(c.DB.MaxOpenConns >= 0, "db.max_open_conns must not be negative")
+	check(c.DB.MaxIdleConns >= 0, "db.max_idle_conns must not be negative")
+	check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns, "db.max_idle_conns must not exceed db.max_open_conns")
+	check(c.DB.ConnMaxLifetime >= 0, "db.conn_max_lifetime must not be negative")
+	check(c.DB.ConnMaxIdleTime >= 0, "db.conn_max_idle_time must not be negative")
+	check(c.DB.ConnectRetries >= 0, "db.connect_retries must not be negative")
+	check(c.DB.ConnectBackoff > 0, "db.connect_backoff must be positive")
+	check(c.DB.QueryTimeout >= 0, "db.query_timeout must not be negative")
+
+	check(!c.Producer.Enabled || c.Producer.Topic != "", "producer.topic is required when the producer is enabled")
+	check(contains(publishers, c.Producer.Publisher), "producer.publisher must be one of %s", strings.Join(publishers, ", "))
+	check(c.Producer.Publisher != PUBLISHER_HTTP || c.Producer.URL != "", "producer.url is required for the http publisher")
+	check(c.Producer.Timeout > 0, "producer.timeout must be positive")
+	check(c.Producer.RelayInterval > 0, "producer.relay_interval must be positive")
+	check(c.Producer.BatchSize > 0, "producer.batch_size must be positive")
+	check(c.Producer.MinBackoff > 0, "producer.min_backoff must be positive")
+	check(c.Producer.MaxBackoff >= c.Producer.MinBackoff, "producer.max_backoff must not be less than producer.min_backoff")
+	check(c.Producer.RelayLease >= Duration(c.Producer.BatchSize)*c.Producer.Timeout, "producer.relay_lease must cover producer.timeout for every message of a batch")
+
+	check(c.ExpirationWorker.Interval > 0, "expiration_worker.interval must be positive")
+	check(c.ExpirationWorker.BatchSize > 0, "expiration_worker.batch_size must be positive")
+
+	check(c.Expiration.DefaultTTL > 0, "expiration.default_ttl must be positive")
+	check(c.Expiration.MaxTTL >= c.Expiration.DefaultTTL, "expiration.max_ttl must not be less than expiration.default_ttl")
+	merchantIDs := make([]string, 0, len(c.Expiration.Merchants))
+	for merchantID := range c.Expiration.Merchants {
+		merchantIDs = append(merchantIDs, merchantID)
+	}
+	sort.Strings(merchantIDs)
+	for _, merchantID := range merchantIDs {
+		rule := c.Expiration.For(merchantID)
+		check(rule.DefaultTTL > 0 && rule.MaxTTL >= rule.DefaultTTL, "expiration.merchants.%s: default_ttl must be positive and not exceed max_ttl", merchantID)
+	}
+
+	if err := c.Generator.Format().Validate(); err != nil {
+		problems = append(problems, "generator."+err.Error())
+	}
+	check(c.Generator.MaxAttempts > 0, "generator.max_attempts must be positive")
+
+	check(c.Idempotency.TTL > 0, "idempotency.ttl must be positive")
+	check(c.Idempotency.LockTimeout > 0, "idempotency.lock_timeout must be positive")
+	check(c.Idempotency.LockTimeout <= c.Idempotency.TTL, "idempotency.lock_timeout must not exceed idempotency.ttl")
+	check(c.Idempotency.CleanupInterval > 0, "idempotency.cleanup_interval must be positive")
+	check(c.Idempotency.CleanupBatchSize > 0, "idempotency.cleanup_batch_size must be positive")
+
+	check(c.Webhook.Interval > 0, "webhook.interval must be positive")
+	check(c.Webhook.BatchSize > 0, "webhook.batch_size must be positive")
+	check(c.Webhook.Timeout > 0, "webhook.timeout must be positive")
+	check(c.Webhook.MaxAttempts > 0, "webhook.max_attempts must be positive")
+	check(c.Webhook.MinBackoff > 0, "webhook.min_backoff must be positive")
+	check(c.Webhook.MaxBackoff >= c.Webhook.MinBackoff, "webhook.max_backoff must not be less than webhook.min_backoff")
+	check(c.Webhook.Lease >= Duration(c.Webhook.BatchSize)*c.Webhook.Timeout, "webhook.lease must cover webhook.timeout for every delivery of a batch")
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// DataSourceName returns the lib/pq connection string for the database.
+func (c DB) DataSourceName() string {
+	if c.DSN != "" {
+		return c.DSN
+	}
+
+	params := []struct{ key, value string }{
+		{"host", c.Host},
+		{"port", strconv.Itoa(c.Port)},
+		{"user", c.User},
+		{"password", c.Password},
+		{"dbname", c.Name},
+		{"sslmode", c.SSLMode},
+	}
+
+	var parts []string
+	for _, param := range params {
+		if param.value == "" {
+			continue
+		}
+		parts = append(parts, param.key+"="+quoteDSNValue(param.value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteDSNValue quotes a key/value connection string value when it is empty
+// or contains spaces, quotes or backslashes.
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
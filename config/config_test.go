@@ -0,0 +1,329 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfig() Config {
+	config := Default()
+	config.DB.User = "postgres"
+	config.DB.Name = "traingolang"
+	return config
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	jsonFile := writeFile("config.json", `{
+		"server": {"addr": ":9090", "write_timeout": "20s"},
+		"db": {"user": "postgres", "name": "traingolang", "sslmode": "require"}
+	}`)
+	yamlFile := writeFile("config.yaml", `
+server:
+  addr: ":9091"
+db:
+  user: postgres
+  name: traingolang
+  max_open_conns: 10
+  max_idle_conns: 5
+expiration_worker:
+  interval: 30s
+expiration:
+  default_ttl: 1h
+  merchants:
+    test-merchant:
+      max_ttl: 48h
+`)
+	invalidFile := writeFile("config.toml", `addr = ":9092"`)
+
+	tests := []struct {
+		name    string
+		path    string
+		env     map[string]string
+		check   func(t *testing.T, config Config)
+		wantErr string
+	}{
+		{
+			name: "json-file",
+			path: jsonFile,
+			check: func(t *testing.T, config Config) {
+				if config.Server.Addr != ":9090" {
+					t.Errorf("Server.Addr = %v, want :9090", config.Server.Addr)
+				}
+				if config.Server.WriteTimeout.Duration() != 20*time.Second {
+					t.Errorf("Server.WriteTimeout = %v, want 20s", config.Server.WriteTimeout)
+				}
+				if config.DB.SSLMode != "require" {
+					t.Errorf("DB.SSLMode = %v, want require", config.DB.SSLMode)
+				}
+				// codes without an expiration keep expiring after 50 years
+				if config.Expiration.DefaultTTL.Duration() != 50*8766*time.Hour {
+					t.Errorf("Expiration.DefaultTTL = %v, want 50 years", config.Expiration.DefaultTTL)
+				}
+			},
+		},
+		{
+			name: "yaml-file",
+			path: yamlFile,
+			check: func(t *testing.T, config Config) {
+				if config.Server.Addr != ":9091" {
+					t.Errorf("Server.Addr = %v, want :9091", config.Server.Addr)
+				}
+				if config.DB.MaxOpenConns != 10 || config.DB.MaxIdleConns != 5 {
+					t.Errorf("DB pool = %v/%v, want 10/5", config.DB.MaxOpenConns, config.DB.MaxIdleConns)
+				}
+				if config.ExpirationWorker.Interval.Duration() != 30*time.Second {
+					t.Errorf("ExpirationWorker.Interval = %v, want 30s", config.ExpirationWorker.Interval)
+				}
+				if rule := config.Expiration.For("test-merchant"); rule.DefaultTTL.Duration() != time.Hour || rule.MaxTTL.Duration() != 48*time.Hour {
+					t.Errorf("Expiration.For(test-merchant) = %v, want 1h/48h", rule)
+				}
+			},
+		},
+		{
+			name: "env-overrides-file",
+			path: yamlFile,
+			env: map[string]string{
+				"SERVER_ADDR": ":9999",
+				"DB_PORT":     "6543",
+				"DB_DSN":      "postgres://localhost/traingolang",
+			},
+			check: func(t *testing.T, config Config) {
+				if config.Server.Addr != ":9999" {
+					t.Errorf("Server.Addr = %v, want :9999", config.Server.Addr)
+				}
+				if config.DB.Port != 6543 {
+					t.Errorf("DB.Port = %v, want 6543", config.DB.Port)
+				}
+				if config.DB.DataSourceName() != "postgres://localhost/traingolang" {
+					t.Errorf("DB.DataSourceName() = %v, want the DSN override", config.DB.DataSourceName())
+				}
+			},
+		},
+		{
+			name:    "unsupported-file-format",
+			path:    invalidFile,
+			wantErr: "unsupported format",
+		},
+		{
+			name:    "missing-file",
+			path:    filepath.Join(dir, "missing.json"),
+			wantErr: "read config file",
+		},
+		{
+			name:    "invalid-env",
+			env:     map[string]string{"DB_PORT": "five", "SERVER_READ_TIMEOUT": "10"},
+			wantErr: "SERVER_READ_TIMEOUT must be a duration such as 30s or 5m; DB_PORT must be an integer",
+		},
+		{
+			name:    "missing-required",
+			wantErr: "db.user is required; db.name is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				os.Setenv(key, value)
+			}
+			defer func() {
+				for key := range tt.env {
+					os.Unsetenv(key)
+				}
+			}()
+
+			config, err := Load(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			tt.check(t, config)
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(config *Config)
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			modify: func(config *Config) {},
+		},
+		{
+			name:    "invalid-sslmode",
+			modify:  func(config *Config) { config.DB.SSLMode = "on" },
+			wantErr: "db.sslmode must be one of",
+		},
+		{
+			name: "dsn-skips-connection-settings",
+			modify: func(config *Config) {
+				config.DB = Default().DB
+				config.DB.DSN = "postgres://localhost/traingolang"
+			},
+		},
+		{
+			name:    "idle-exceeds-open",
+			modify:  func(config *Config) { config.DB.MaxOpenConns, config.DB.MaxIdleConns = 5, 10 },
+			wantErr: "db.max_idle_conns must not exceed db.max_open_conns",
+		},
+		{
+			name: "every-problem-reported",
+			modify: func(config *Config) {
+				config.Server.Addr = ""
+				config.ExpirationWorker.BatchSize = 0
+			},
+			wantErr: "server.addr is required; expiration_worker.batch_size must be positive",
+		},
+		{
+			name:    "zero-max-body-bytes",
+			modify:  func(config *Config) { config.Server.MaxBodyBytes = 0 },
+			wantErr: "server.max_body_bytes must be positive",
+		},
+		{
+			name:    "zero-signature-window",
+			modify:  func(config *Config) { config.Server.SignatureWindow = 0 },
+			wantErr: "server.signature_window must be positive",
+		},
+		{
+			name:    "zero-export-timeout",
+			modify:  func(config *Config) { config.Server.ExportTimeout = 0 },
+			wantErr: "server.export_timeout must be positive",
+		},
+		{
+			name:    "invalid-generator",
+			modify:  func(config *Config) { config.Generator.Alphabet = "hex" },
+			wantErr: "generator.alphabet must be one of numeric, alphanumeric",
+		},
+		{
+			name: "merchant-max-ttl-below-default",
+			modify: func(config *Config) {
+				config.Expiration.Merchants = map[string]ExpirationRule{
+					"test-merchant": {MaxTTL: Duration(time.Hour)},
+				}
+			},
+			wantErr: "expiration.merchants.test-merchant: default_ttl must be positive and not exceed max_ttl",
+		},
+		{
+			name:    "idempotency-lock-exceeds-ttl",
+			modify:  func(config *Config) { config.Idempotency.LockTimeout = Duration(48 * time.Hour) },
+			wantErr: "idempotency.lock_timeout must not exceed idempotency.ttl",
+		},
+		{
+			name:    "producer-relay-lease-below-batch-timeout",
+			modify:  func(config *Config) { config.Producer.RelayLease = Duration(time.Minute) },
+			wantErr: "producer.relay_lease must cover producer.timeout for every message of a batch",
+		},
+		{
+			name:    "webhook-max-backoff-below-min",
+			modify:  func(config *Config) { config.Webhook.MaxBackoff = Duration(time.Second) },
+			wantErr: "webhook.max_backoff must not be less than webhook.min_backoff",
+		},
+		{
+			name:    "webhook-lease-below-batch-timeout",
+			modify:  func(config *Config) { config.Webhook.Lease = Duration(time.Minute) },
+			wantErr: "webhook.lease must cover webhook.timeout for every delivery of a batch",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validConfig()
+			tt.modify(&config)
+
+			err := config.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Config.Validate() error = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Config.Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpirationPolicy_For(t *testing.T) {
+	policy := ExpirationPolicy{
+		ExpirationRule: ExpirationRule{DefaultTTL: Duration(time.Hour), MaxTTL: Duration(24 * time.Hour)},
+		Merchants: map[string]ExpirationRule{
+			"test-merchant": {MaxTTL: Duration(48 * time.Hour)},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		merchantID string
+		want       ExpirationRule
+	}{
+		{
+			name:       "service-wide",
+			merchantID: "other-merchant",
+			want:       ExpirationRule{DefaultTTL: Duration(time.Hour), MaxTTL: Duration(24 * time.Hour)},
+		},
+		{
+			name:       "merchant-override",
+			merchantID: "test-merchant",
+			want:       ExpirationRule{DefaultTTL: Duration(time.Hour), MaxTTL: Duration(48 * time.Hour)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.For(tt.merchantID); got != tt.want {
+				t.Errorf("ExpirationPolicy.For() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDB_DataSourceName(t *testing.T) {
+	tests := []struct {
+		name string
+		db   DB
+		want string
+	}{
+		{
+			name: "key-value",
+			db:   DB{Host: "localhost", Port: 5432, User: "postgres", Password: "postgres", Name: "traingolang", SSLMode: "disable"},
+			want: "host=localhost port=5432 user=postgres password=postgres dbname=traingolang sslmode=disable",
+		},
+		{
+			name: "quoted-password",
+			db:   DB{Host: "localhost", Port: 5432, User: "postgres", Password: `it's a secret`, Name: "traingolang", SSLMode: "require"},
+			want: `host=localhost port=5432 user=postgres password='it\'s a secret' dbname=traingolang sslmode=require`,
+		},
+		{
+			name: "dsn-override",
+			db:   DB{DSN: "postgres://postgres@localhost/traingolang", Host: "ignored"},
+			want: "postgres://postgres@localhost/traingolang",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.db.DataSourceName(); got != tt.want {
+				t.Errorf("DB.DataSourceName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envLoader overrides config values with the environment variables that are
+// set, remembering every variable it could not parse.
+type envLoader struct {
+	lookup   func(string) (string, bool)
+	problems []string
+}
+
+func (e *envLoader) string(key string, dst *string) {
+	if value, ok := e.lookup(key); ok {
+		*dst = value
+	}
+}
+
+func (e *envLoader) int(key string, dst *int) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s must be an integer", key))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envLoader) bool(key string, dst *bool) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s must be a boolean", key))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envLoader) duration(key string, dst *Duration) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s must be a duration such as 30s or 5m", key))
+		return
+	}
+	*dst = Duration(parsed)
+}
+
+func (e *envLoader) err() error {
+	if len(e.problems) > 0 {
+		return errors.New("invalid environment: " + strings.Join(e.problems, "; "))
+	}
+	return nil
+}
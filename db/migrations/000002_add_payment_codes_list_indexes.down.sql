@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS payment_codes_name_trgm_idx;
+DROP INDEX IF EXISTS payment_codes_payment_code_prefix_idx;
+DROP INDEX IF EXISTS payment_codes_status_created_at_id_idx;
+DROP INDEX IF EXISTS payment_codes_created_at_id_idx;
@@ -0,0 +1,6 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS payment_codes_created_at_id_idx ON payment_codes (created_at, id);
+CREATE INDEX IF NOT EXISTS payment_codes_status_created_at_id_idx ON payment_codes (status, created_at, id);
+CREATE INDEX IF NOT EXISTS payment_codes_payment_code_prefix_idx ON payment_codes (payment_code varchar_pattern_ops);
+CREATE INDEX IF NOT EXISTS payment_codes_name_trgm_idx ON payment_codes USING gin (name gin_trgm_ops);
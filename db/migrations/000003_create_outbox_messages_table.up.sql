@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS outbox_messages(
+  id VARCHAR (255) PRIMARY KEY,
+  topic VARCHAR (255) NOT NULL,
+  message_key VARCHAR (255) NOT NULL,
+  payload JSONB NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT,
+  next_attempt_at timestamptz NOT NULL,
+  published_at timestamptz,
+  created_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS outbox_messages_pending_idx ON outbox_messages (next_attempt_at) WHERE published_at IS NULL;
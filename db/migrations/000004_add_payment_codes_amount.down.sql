@@ -0,0 +1,7 @@
+ALTER TABLE payment_codes
+  DROP CONSTRAINT IF EXISTS payment_codes_amount_check,
+  DROP COLUMN IF EXISTS amount_type,
+  DROP COLUMN IF EXISTS amount,
+  DROP COLUMN IF EXISTS min_amount,
+  DROP COLUMN IF EXISTS max_amount,
+  DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,20 @@
+ALTER TABLE payment_codes
+  ADD COLUMN amount_type VARCHAR (16) NOT NULL DEFAULT 'OPEN',
+  ADD COLUMN amount BIGINT,
+  ADD COLUMN min_amount BIGINT,
+  ADD COLUMN max_amount BIGINT,
+  ADD COLUMN currency VARCHAR (3) NOT NULL DEFAULT 'IDR';
+
+-- the defaults only backfill existing rows, new rows must set both explicitly
+ALTER TABLE payment_codes
+  ALTER COLUMN amount_type DROP DEFAULT,
+  ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE payment_codes
+  ADD CONSTRAINT payment_codes_amount_check CHECK (
+    (amount_type = 'FIXED' AND amount IS NOT NULL AND amount > 0 AND min_amount IS NULL AND max_amount IS NULL)
+    OR (amount_type = 'OPEN' AND amount IS NULL
+      AND (min_amount IS NULL OR min_amount > 0)
+      AND (max_amount IS NULL OR max_amount > 0)
+      AND (min_amount IS NULL OR max_amount IS NULL OR min_amount <= max_amount))
+  );
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS payments(
+  id VARCHAR (255) PRIMARY KEY,
+  payment_code_id VARCHAR (255) NOT NULL REFERENCES payment_codes (id),
+  amount BIGINT NOT NULL CHECK (amount > 0),
+  currency VARCHAR (3) NOT NULL,
+  created_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS payments_payment_code_id_created_at_idx ON payments (payment_code_id, created_at);
@@ -0,0 +1,7 @@
+ALTER TABLE payment_codes
+  DROP CONSTRAINT IF EXISTS payment_codes_usage_check,
+  DROP COLUMN IF EXISTS usage_type,
+  DROP COLUMN IF EXISTS max_usage,
+  DROP COLUMN IF EXISTS max_total_amount,
+  DROP COLUMN IF EXISTS usage_count,
+  DROP COLUMN IF EXISTS total_paid;
@@ -0,0 +1,17 @@
+-- existing codes keep accepting payments without limits
+ALTER TABLE payment_codes
+  ADD COLUMN usage_type VARCHAR (16) NOT NULL DEFAULT 'MULTI',
+  ADD COLUMN max_usage INT,
+  ADD COLUMN max_total_amount BIGINT,
+  ADD COLUMN usage_count INT NOT NULL DEFAULT 0,
+  ADD COLUMN total_paid BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE payment_codes
+  ALTER COLUMN usage_type DROP DEFAULT;
+
+ALTER TABLE payment_codes
+  ADD CONSTRAINT payment_codes_usage_check CHECK (
+    ((usage_type = 'SINGLE' AND max_usage IS NULL AND max_total_amount IS NULL) OR usage_type = 'MULTI')
+    AND (max_usage IS NULL OR (max_usage > 0 AND usage_count <= max_usage))
+    AND (max_total_amount IS NULL OR (max_total_amount > 0 AND total_paid <= max_total_amount))
+  );
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS payment_codes_active_expiration_date_idx;
+
+ALTER TABLE payment_codes
+  ALTER COLUMN expiration_date TYPE VARCHAR (255)
+  USING to_char(expiration_date AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS.US"Z"');
@@ -0,0 +1,18 @@
+-- expiration_date was written by lib/pq as the text form of a time.Time, which
+-- casts cleanly. Rows that do not parse get the 50 year expiration that was
+-- applied to every code before the expiration policy existed.
+CREATE FUNCTION pg_temp.to_timestamptz_or_null(value TEXT) RETURNS timestamptz AS $$
+BEGIN
+  RETURN value::timestamptz;
+EXCEPTION WHEN others THEN
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE payment_codes
+  ALTER COLUMN expiration_date TYPE timestamptz
+  USING COALESCE(pg_temp.to_timestamptz_or_null(expiration_date), created_at + INTERVAL '50 years');
+
+DROP FUNCTION pg_temp.to_timestamptz_or_null(TEXT);
+
+CREATE INDEX IF NOT EXISTS payment_codes_active_expiration_date_idx ON payment_codes (expiration_date) WHERE status = 'ACTIVE';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS payment_codes_payment_code_key;
@@ -0,0 +1,24 @@
+-- payment codes are handed to customers, so duplicates are not renamed here:
+-- the migration stops until they are resolved by hand, e.g. by expiring or
+-- reissuing all but one of the codes listed by
+--   SELECT payment_code, array_agg(id) FROM payment_codes GROUP BY payment_code HAVING count(*) > 1;
+DO $$
+DECLARE
+  duplicates TEXT;
+BEGIN
+  SELECT string_agg(payment_code, ', ') INTO duplicates
+  FROM (
+    SELECT payment_code FROM payment_codes
+    GROUP BY payment_code
+    HAVING count(*) > 1
+    ORDER BY payment_code
+    LIMIT 20
+  ) duplicated;
+
+  IF duplicates IS NOT NULL THEN
+    RAISE EXCEPTION 'payment_codes holds duplicate payment_code values (first 20: %); resolve them before making payment_code unique', duplicates;
+  END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS payment_codes_payment_code_key ON payment_codes (payment_code);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys(
+  client_id VARCHAR (255) NOT NULL,
+  idempotency_key VARCHAR (255) NOT NULL,
+  request_hash VARCHAR (64) NOT NULL,
+  status_code INT,
+  response_body BYTEA,
+  locked_until timestamptz NOT NULL,
+  created_at timestamptz NOT NULL,
+  expires_at timestamptz NOT NULL,
+  PRIMARY KEY (client_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS payment_codes_merchant_id_payment_code_prefix_idx;
+DROP INDEX IF EXISTS payment_codes_merchant_id_status_created_at_id_idx;
+DROP INDEX IF EXISTS payment_codes_merchant_id_created_at_id_idx;
+CREATE INDEX IF NOT EXISTS payment_codes_created_at_id_idx ON payment_codes (created_at, id);
+CREATE INDEX IF NOT EXISTS payment_codes_status_created_at_id_idx ON payment_codes (status, created_at, id);
+CREATE INDEX IF NOT EXISTS payment_codes_payment_code_prefix_idx ON payment_codes (payment_code varchar_pattern_ops);
+
+-- fails when two merchants use the same payment_code
+DROP INDEX IF EXISTS payment_codes_merchant_id_payment_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS payment_codes_payment_code_key ON payment_codes (payment_code);
+
+ALTER TABLE payment_codes DROP COLUMN IF EXISTS merchant_id;
+DROP TABLE IF EXISTS merchants;
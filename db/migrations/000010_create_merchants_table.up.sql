@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS merchants(
+  id VARCHAR (255) PRIMARY KEY,
+  name VARCHAR (255) NOT NULL,
+  created_at timestamptz NOT NULL,
+  updated_at timestamptz NOT NULL
+);
+
+-- payment codes created before merchants existed belong to the default merchant
+INSERT INTO merchants (id, name, created_at, updated_at) VALUES ('default', 'Default', now(), now())
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE payment_codes ADD COLUMN IF NOT EXISTS merchant_id VARCHAR (255) NOT NULL DEFAULT 'default' REFERENCES merchants (id);
+ALTER TABLE payment_codes ALTER COLUMN merchant_id DROP DEFAULT;
+
+-- payment_code is unique per merchant, and every lookup and listing is scoped
+-- by merchant
+DROP INDEX IF EXISTS payment_codes_payment_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS payment_codes_merchant_id_payment_code_key ON payment_codes (merchant_id, payment_code);
+
+DROP INDEX IF EXISTS payment_codes_created_at_id_idx;
+DROP INDEX IF EXISTS payment_codes_status_created_at_id_idx;
+DROP INDEX IF EXISTS payment_codes_payment_code_prefix_idx;
+CREATE INDEX IF NOT EXISTS payment_codes_merchant_id_created_at_id_idx ON payment_codes (merchant_id, created_at, id);
+CREATE INDEX IF NOT EXISTS payment_codes_merchant_id_status_created_at_id_idx ON payment_codes (merchant_id, status, created_at, id);
+CREATE INDEX IF NOT EXISTS payment_codes_merchant_id_payment_code_prefix_idx ON payment_codes (merchant_id, payment_code varchar_pattern_ops);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS api_keys(
+  id VARCHAR (255) PRIMARY KEY,
+  merchant_id VARCHAR (255) NOT NULL REFERENCES merchants (id),
+  name VARCHAR (255) NOT NULL,
+  prefix VARCHAR (32) NOT NULL,
+  key_hash VARCHAR (64) NOT NULL,
+  scopes TEXT[] NOT NULL,
+  created_at timestamptz NOT NULL,
+  updated_at timestamptz NOT NULL,
+  last_used_at timestamptz,
+  revoked_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_prefix_key ON api_keys (prefix);
+CREATE INDEX IF NOT EXISTS api_keys_merchant_id_created_at_idx ON api_keys (merchant_id, created_at);
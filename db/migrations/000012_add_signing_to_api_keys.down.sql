@@ -0,0 +1,3 @@
+ALTER TABLE api_keys
+  DROP COLUMN IF EXISTS signing_secret,
+  DROP COLUMN IF EXISTS require_signature;
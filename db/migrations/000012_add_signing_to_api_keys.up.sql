@@ -0,0 +1,4 @@
+-- existing keys have no signing secret until they are rotated
+ALTER TABLE api_keys
+  ADD COLUMN signing_secret VARCHAR (64),
+  ADD COLUMN require_signature BOOLEAN NOT NULL DEFAULT FALSE;
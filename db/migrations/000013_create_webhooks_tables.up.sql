@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS webhook_endpoints(
+  id VARCHAR (255) PRIMARY KEY,
+  merchant_id VARCHAR (255) NOT NULL REFERENCES merchants (id),
+  url VARCHAR (2048) NOT NULL,
+  event_types TEXT[] NOT NULL,
+  signing_secret VARCHAR (64) NOT NULL,
+  created_at timestamptz NOT NULL,
+  updated_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_endpoints_merchant_id_created_at_idx ON webhook_endpoints (merchant_id, created_at);
+
+-- deliveries go with their endpoint when it is deleted
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+  id VARCHAR (255) PRIMARY KEY,
+  merchant_id VARCHAR (255) NOT NULL REFERENCES merchants (id),
+  endpoint_id VARCHAR (255) NOT NULL REFERENCES webhook_endpoints (id) ON DELETE CASCADE,
+  event_id VARCHAR (255) NOT NULL,
+  event_type VARCHAR (255) NOT NULL,
+  payload JSONB NOT NULL,
+  status VARCHAR (16) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  last_status_code INT,
+  last_error TEXT,
+  next_attempt_at timestamptz NOT NULL,
+  delivered_at timestamptz,
+  created_at timestamptz NOT NULL,
+  updated_at timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_endpoint_id_event_id_key ON webhook_deliveries (endpoint_id, event_id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_merchant_id_created_at_idx ON webhook_deliveries (merchant_id, created_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS payment_codes_unexpired_expiration_date_idx;
+CREATE INDEX IF NOT EXISTS payment_codes_active_expiration_date_idx ON payment_codes (expiration_date) WHERE status = 'ACTIVE';
@@ -0,0 +1,4 @@
+-- INACTIVE codes expire as well, so the expiration worker looks up both
+-- statuses by expiration_date.
+DROP INDEX IF EXISTS payment_codes_active_expiration_date_idx;
+CREATE INDEX IF NOT EXISTS payment_codes_unexpired_expiration_date_idx ON payment_codes (expiration_date) WHERE status IN ('ACTIVE', 'INACTIVE');
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS payment_codes_merchant_id_expiration_date_idx;
@@ -0,0 +1,3 @@
+-- backs the expiration_from and expiration_to filters of the list endpoint,
+-- which needed expiration_date to be a timestamptz first (000007).
+CREATE INDEX IF NOT EXISTS payment_codes_merchant_id_expiration_date_idx ON payment_codes (merchant_id, expiration_date);
@@ -0,0 +1,2 @@
+-- responses stored before this column existed were all JSON
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS content_type VARCHAR (255);
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// bodyError is a request body the handlers cannot read. errorHandler answers
+// it with its status and message.
+type bodyError struct {
+	status  int
+	message string
+}
+
+func (e *bodyError) Error() string {
+	return e.message
+}
+
+// limitBody caps the request body of next at limit bytes. Reading past the
+// limit fails, and decodeJSON reports it as 413.
+func limitBody(limit int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if limit > 0 && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		next(w, r)
+	}
+}
+
+// isBodyTooLarge reports whether err comes from reading past the limit set by
+// limitBody. http.MaxBytesReader only describes it in its message.
+func isBodyTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "http: request body too large")
+}
+
+// checkContentType returns a 415 bodyError unless the request body is one of
+// mediaTypes.
+func checkContentType(r *http.Request, mediaTypes ...string) (err error) {
+	mediaType, _, parseErr := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if parseErr == nil {
+		for _, t := range mediaTypes {
+			if mediaType == t {
+				return
+			}
+		}
+	}
+
+	return &bodyError{
+		status:  http.StatusUnsupportedMediaType,
+		message: fmt.Sprintf("header 'Content-Type' must be %s", strings.Join(mediaTypes, " or ")),
+	}
+}
+
+// readBodyError turns an error from reading the request body into a
+// bodyError.
+func readBodyError(err error) error {
+	if isBodyTooLarge(err) {
+		return &bodyError{status: http.StatusRequestEntityTooLarge, message: "request body is too large"}
+	}
+	return &bodyError{status: http.StatusBadRequest, message: "invalid request body"}
+}
+
+// decodeJSON decodes the application/json body of r into dst. Unknown fields
+// and anything after the JSON value are rejected.
+func decodeJSON(r *http.Request, dst interface{}) (err error) {
+	err = checkContentType(r, "application/json")
+	if err != nil {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(dst)
+	if err != nil {
+		return jsonBodyError(err)
+	}
+
+	if _, tokenErr := decoder.Token(); tokenErr != io.EOF {
+		if isBodyTooLarge(tokenErr) {
+			return readBodyError(tokenErr)
+		}
+		return &bodyError{status: http.StatusBadRequest, message: "request body must hold a single JSON value"}
+	}
+
+	return
+}
+
+// jsonBodyError turns an error of decoding a JSON request body into a
+// bodyError that tells the client what is wrong with it.
+func jsonBodyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var timeErr *time.ParseError
+	switch {
+	case isBodyTooLarge(err):
+		return readBodyError(err)
+	case err == io.EOF:
+		return &bodyError{status: http.StatusBadRequest, message: "request body is empty"}
+	case errors.As(err, &syntaxErr), err == io.ErrUnexpectedEOF:
+		return &bodyError{status: http.StatusBadRequest, message: "request body is not valid JSON"}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return &bodyError{status: http.StatusBadRequest, message: fmt.Sprintf("field '%s' must be a %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind()))}
+	case errors.As(err, &timeErr):
+		return &bodyError{status: http.StatusBadRequest, message: "timestamps must be RFC 3339, such as 2006-01-02T15:04:05Z"}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &bodyError{status: http.StatusBadRequest, message: fmt.Sprintf("field '%s' is not allowed", field)}
+	default:
+		return &bodyError{status: http.StatusBadRequest, message: "invalid request body"}
+	}
+}
+
+// jsonTypeName names a Go kind the way a JSON client knows it.
+func jsonTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		return kind.String()
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+)
+
+func Test_decodeJSON(t *testing.T) {
+	newRequest := func(contentType string, body string, limit int64) *http.Request {
+		req, err := http.NewRequest("POST", "/payment-codes", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		if limit > 0 {
+			req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, limit)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name        string
+		r           *http.Request
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name: "valid",
+			r:    newRequest("application/json; charset=utf-8", `{"name":"test-name","amount":150000}`, 0),
+		},
+		{
+			name:        "wrong-content-type",
+			r:           newRequest("text/plain", `{"name":"test-name"}`, 0),
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantMessage: "header 'Content-Type' must be application/json",
+		},
+		{
+			name:        "missing-content-type",
+			r:           newRequest("", `{"name":"test-name"}`, 0),
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantMessage: "header 'Content-Type' must be application/json",
+		},
+		{
+			name:        "empty",
+			r:           newRequest("application/json", ``, 0),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "request body is empty",
+		},
+		{
+			name:        "malformed",
+			r:           newRequest("application/json", `{"name":"test-name",}`, 0),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "request body is not valid JSON",
+		},
+		{
+			name:        "truncated",
+			r:           newRequest("application/json", `{"name":"test-`, 0),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "request body is not valid JSON",
+		},
+		{
+			name:        "wrong-type",
+			r:           newRequest("application/json", `{"amount":"150000"}`, 0),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "field 'amount' must be a number",
+		},
+		{
+			name:        "invalid-timestamp",
+			r:           newRequest("application/json", `{"expiration_date":"tomorrow"}`, 0),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "timestamps must be RFC 3339, such as 2006-01-02T15:04:05Z",
+		},
+		{
+			name:        "unknown-field",
+			r:           newRequest("application/json", `{"name":"test-name","nmae":"typo"}`, 0),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "field 'nmae' is not allowed",
+		},
+		{
+			name:        "trailing-data",
+			r:           newRequest("application/json", `{"name":"test-name"}{"name":"other"}`, 0),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "request body must hold a single JSON value",
+		},
+		{
+			name:        "too-large",
+			r:           newRequest("application/json", `{"name":"`+strings.Repeat("a", 64)+`"}`, 32),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantMessage: "request body is too large",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paymentCode model.PaymentCode
+			err := decodeJSON(tt.r, &paymentCode)
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("decodeJSON() error = %v", err)
+				}
+				if paymentCode.Name != "test-name" || paymentCode.Amount != 150000 {
+					t.Errorf("decodeJSON() decoded %+v", paymentCode)
+				}
+				return
+			}
+
+			bodyErr, ok := err.(*bodyError)
+			if !ok {
+				t.Fatalf("decodeJSON() error = %v, want a *bodyError", err)
+			}
+			if bodyErr.status != tt.wantStatus || bodyErr.message != tt.wantMessage {
+				t.Errorf("decodeJSON() = %d %q, want %d %q", bodyErr.status, bodyErr.message, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+const (
+	ALPHABET_NUMERIC      = "numeric"
+	ALPHABET_ALPHANUMERIC = "alphanumeric"
+)
+
+const (
+	CHECK_DIGIT_NONE  = "none"
+	CHECK_DIGIT_LUHN  = "luhn"
+	CHECK_DIGIT_MOD97 = "mod97"
+)
+
+var alphabets = map[string]string{
+	ALPHABET_NUMERIC:      "0123456789",
+	ALPHABET_ALPHANUMERIC: "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+}
+
+var checkDigitLengths = map[string]int{
+	CHECK_DIGIT_NONE:  0,
+	CHECK_DIGIT_LUHN:  1,
+	CHECK_DIGIT_MOD97: 2,
+}
+
+// Format describes a generated payment code: Prefix, followed by random
+// characters of Alphabet, followed by the check digits of those characters.
+// Length counts every character, including the prefix and the check digits.
+//
+// The Luhn check digit is computed with the Luhn mod N algorithm over the
+// alphabet, which is the usual Luhn algorithm for numeric codes. The mod97
+// check digits follow ISO 7064 MOD 97-10, as used by IBAN.
+type Format struct {
+	Prefix     string
+	Length     int
+	Alphabet   string
+	CheckDigit string
+}
+
+// Validate reports why the format cannot generate codes, if it cannot.
+func (f Format) Validate() (err error) {
+	if _, ok := alphabets[f.Alphabet]; !ok {
+		return fmt.Errorf("alphabet must be one of %s, %s", ALPHABET_NUMERIC, ALPHABET_ALPHANUMERIC)
+	}
+
+	if _, ok := checkDigitLengths[f.CheckDigit]; !ok {
+		return fmt.Errorf("check_digit must be one of %s, %s, %s", CHECK_DIGIT_NONE, CHECK_DIGIT_LUHN, CHECK_DIGIT_MOD97)
+	}
+
+	if f.bodyLength() < 4 {
+		return errors.New("length must leave at least 4 random characters after the prefix and check digits")
+	}
+
+	return
+}
+
+// Matches reports whether code has the prefix, length and alphabet of the
+// format. It does not look at the check digits.
+func (f Format) Matches(code string) bool {
+	if len(code) != f.Length || !strings.HasPrefix(code, f.Prefix) {
+		return false
+	}
+
+	chars := alphabets[f.Alphabet]
+	for i := len(f.Prefix); i < len(code); i++ {
+		if strings.IndexByte(chars, code[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateCheckDigit reports whether code matches the format and ends with
+// the check digits of its random characters. Every matching code is valid
+// when the format has no check digit.
+func (f Format) ValidateCheckDigit(code string) bool {
+	if !f.Matches(code) {
+		return false
+	}
+
+	checkStart := len(code) - checkDigitLengths[f.CheckDigit]
+	return f.checkDigits(code[len(f.Prefix):checkStart]) == code[checkStart:]
+}
+
+func (f Format) bodyLength() int {
+	return f.Length - len(f.Prefix) - checkDigitLengths[f.CheckDigit]
+}
+
+func (f Format) checkDigits(body string) string {
+	chars := alphabets[f.Alphabet]
+	switch f.CheckDigit {
+	case CHECK_DIGIT_LUHN:
+		return string(luhn(body, chars))
+	case CHECK_DIGIT_MOD97:
+		return mod97(body, chars)
+	default:
+		return ""
+	}
+}
+
+// luhn returns the Luhn mod N check character of body, where N is the size
+// of chars.
+func luhn(body string, chars string) byte {
+	n := len(chars)
+	factor := 2
+	sum := 0
+	for i := len(body) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(chars, body[i])
+		addend = addend/n + addend%n
+		sum += addend
+		factor = 3 - factor
+	}
+	return chars[(n-sum%n)%n]
+}
+
+// mod97 returns the two ISO 7064 MOD 97-10 check digits of body. Letters
+// count as the numbers 10 to 35.
+func mod97(body string, chars string) string {
+	remainder := 0
+	for i := 0; i < len(body); i++ {
+		value := strings.IndexByte(chars, body[i])
+		if value >= 10 {
+			remainder = remainder * 10 % 97
+		}
+		remainder = (remainder*10 + value) % 97
+	}
+	remainder = remainder * 100 % 97
+	return fmt.Sprintf("%02d", 98-remainder)
+}
+
+// Generator produces random payment codes in Format.
+type Generator struct {
+	Format Format
+	// Rand is the source of randomness. crypto/rand is used when it is nil.
+	Rand io.Reader
+}
+
+func (g Generator) Generate() (code string, err error) {
+	err = g.Format.Validate()
+	if err != nil {
+		return
+	}
+
+	random := g.Rand
+	if random == nil {
+		random = rand.Reader
+	}
+
+	chars := alphabets[g.Format.Alphabet]
+	max := big.NewInt(int64(len(chars)))
+
+	body := make([]byte, g.Format.bodyLength())
+	for i := range body {
+		var index *big.Int
+		index, err = rand.Int(random, max)
+		if err != nil {
+			return
+		}
+		body[i] = chars[index.Int64()]
+	}
+
+	code = g.Format.Prefix + string(body) + g.Format.checkDigits(string(body))
+
+	return
+}
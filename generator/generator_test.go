@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"testing"
+)
+
+func TestFormat_ValidateCheckDigit(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		code   string
+		want   bool
+	}{
+		{
+			name:   "luhn-valid",
+			format: Format{Length: 11, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_LUHN},
+			code:   "79927398713",
+			want:   true,
+		},
+		{
+			name:   "luhn-mistyped",
+			format: Format{Length: 11, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_LUHN},
+			code:   "79927398723",
+		},
+		{
+			name:   "luhn-swapped-digits",
+			format: Format{Length: 11, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_LUHN},
+			code:   "97927398713",
+		},
+		{
+			name:   "mod97-valid",
+			format: Format{Length: 22, Alphabet: ALPHABET_ALPHANUMERIC, CheckDigit: CHECK_DIGIT_MOD97},
+			code:   "WEST12345698765432GB82",
+			want:   true,
+		},
+		{
+			name:   "mod97-mistyped",
+			format: Format{Length: 22, Alphabet: ALPHABET_ALPHANUMERIC, CheckDigit: CHECK_DIGIT_MOD97},
+			code:   "WEST12345698765433GB82",
+		},
+		{
+			name:   "prefix-is-not-checked",
+			format: Format{Prefix: "PC", Length: 13, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_LUHN},
+			code:   "PC79927398713",
+			want:   true,
+		},
+		{
+			name:   "other-prefix",
+			format: Format{Prefix: "PC", Length: 13, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_LUHN},
+			code:   "XX79927398713",
+		},
+		{
+			name:   "wrong-length",
+			format: Format{Length: 12, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_LUHN},
+			code:   "79927398713",
+		},
+		{
+			name:   "outside-alphabet",
+			format: Format{Length: 11, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_LUHN},
+			code:   "7992739871A",
+		},
+		{
+			name:   "no-check-digit",
+			format: Format{Length: 11, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_NONE},
+			code:   "79927398712",
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.ValidateCheckDigit(tt.code); got != tt.want {
+				t.Errorf("Format.ValidateCheckDigit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		wantErr bool
+	}{
+		{
+			name:   "numeric-luhn",
+			format: Format{Prefix: "88", Length: 16, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_LUHN},
+		},
+		{
+			name:   "alphanumeric-luhn",
+			format: Format{Prefix: "PC", Length: 12, Alphabet: ALPHABET_ALPHANUMERIC, CheckDigit: CHECK_DIGIT_LUHN},
+		},
+		{
+			name:   "alphanumeric-mod97",
+			format: Format{Length: 12, Alphabet: ALPHABET_ALPHANUMERIC, CheckDigit: CHECK_DIGIT_MOD97},
+		},
+		{
+			name:   "no-check-digit",
+			format: Format{Length: 8, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_NONE},
+		},
+		{
+			name:    "unknown-alphabet",
+			format:  Format{Length: 8, Alphabet: "hex", CheckDigit: CHECK_DIGIT_NONE},
+			wantErr: true,
+		},
+		{
+			name:    "too-short",
+			format:  Format{Prefix: "PC", Length: 6, Alphabet: ALPHABET_NUMERIC, CheckDigit: CHECK_DIGIT_MOD97},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := Generator{Format: tt.format}
+			for i := 0; i < 100; i++ {
+				code, err := g.Generate()
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Generator.Generate() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if err != nil {
+					return
+				}
+				if !tt.format.ValidateCheckDigit(code) {
+					t.Fatalf("Generator.Generate() = %v, which does not pass its own check", code)
+				}
+			}
+		})
+	}
+}
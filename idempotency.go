@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyMiddleware makes POST requests sent with an Idempotency-Key header
+// safe to retry: the first response is stored and returned again to retries
+// of the same request. Keys are scoped to the merchant of the request, so the
+// middleware must run after APIKeyMiddleware.
+type IdempotencyMiddleware struct {
+	Usecase usecase.IIdempotencyUseCase
+}
+
+func (m IdempotencyMiddleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if r.Method != "POST" || key == "" || m.Usecase == nil {
+			next(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			badRequestHandler(w, r, "header 'Idempotency-Key' must be at most 255 characters")
+			return
+		}
+
+		clientId := usecase.MerchantIDFromContext(r.Context())
+		if clientId == "" {
+			errorHandler(w, r, usecase.ErrMerchantRequired)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			errorHandler(w, r, readBodyError(err))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		stored, replay, err := m.Usecase.Begin(r.Context(), clientId, key, requestHash(r, body))
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+
+		if replay {
+			contentType := stored.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.ResponseBody)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		// the request context may already be cancelled by a client that gave
+		// up, but its retry still needs the key to be completed or released
+		ctx := context.Background()
+		if rec.status >= http.StatusInternalServerError {
+			err = m.Usecase.Release(ctx, clientId, key, stored.LockedUntil)
+		} else {
+			err = m.Usecase.Complete(ctx, clientId, key, stored.LockedUntil, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes())
+		}
+		if err != nil {
+			log.Printf("%s %s: store idempotency key: %v", r.Method, r.URL.Path, err)
+		}
+	}
+}
+
+// requestHash fingerprints everything that changes the outcome of a request,
+// so a key cannot be reused for a different request.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{r.Method, r.URL.Path, r.URL.RawQuery, usecase.MerchantIDFromContext(r.Context())} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes a response through while keeping a copy of its
+// status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mock_http "github.com/pevin/pevin-golang-training-beginner/mock/net/http"
+	mock_usecase "github.com/pevin/pevin-golang-training-beginner/mock/usecase"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+)
+
+func TestIdempotencyMiddleware_Wrap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	body := `{"name":"test-name"}`
+	created := []byte(`{"id":"test-id"}`)
+	reservation := model.IdempotencyKey{ClientId: "test-merchant", Key: "test-key", LockedUntil: time.Now().UTC()}
+
+	newRequest := func(key string, merchantId string) *http.Request {
+		req, err := http.NewRequest("POST", "/payment-codes", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		if merchantId != "" {
+			req = req.WithContext(usecase.WithMerchantID(req.Context(), merchantId))
+		}
+		return req
+	}
+
+	tests := []struct {
+		name       string
+		usecase    func() usecase.IIdempotencyUseCase
+		w          func() http.ResponseWriter
+		r          *http.Request
+		nextStatus int
+		wantNext   bool
+	}{
+		{
+			name: "without-key",
+			usecase: func() usecase.IIdempotencyUseCase {
+				return mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+			},
+			w: func() http.ResponseWriter {
+				rw := mock_http.NewMockResponseWriter(ctrl)
+				rw.EXPECT().WriteHeader(http.StatusCreated)
+				rw.EXPECT().Write(created).Return(0, nil)
+				return rw
+			},
+			r:          newRequest("", ""),
+			nextStatus: http.StatusCreated,
+			wantNext:   true,
+		},
+		{
+			name: "without-merchant",
+			usecase: func() usecase.IIdempotencyUseCase {
+				return mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+			},
+			w: func() http.ResponseWriter {
+				rw := mock_http.NewMockResponseWriter(ctrl)
+				rw.EXPECT().Header().Return(http.Header{})
+				rw.EXPECT().WriteHeader(http.StatusUnauthorized)
+				rw.EXPECT().Write(gomock.Any()).Return(0, nil)
+				return rw
+			},
+			r: newRequest("test-key", ""),
+		},
+		{
+			name: "first-request-completed",
+			usecase: func() usecase.IIdempotencyUseCase {
+				uc := mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+				uc.
+					EXPECT().
+					Begin(gomock.Any(), "test-merchant", "test-key", gomock.Any()).
+					Return(reservation, false, nil)
+				uc.
+					EXPECT().
+					Complete(gomock.Any(), "test-merchant", "test-key", reservation.LockedUntil, http.StatusCreated, "application/json", created).
+					Return(nil)
+				return uc
+			},
+			w: func() http.ResponseWriter {
+				rw := mock_http.NewMockResponseWriter(ctrl)
+				rw.EXPECT().Header().Return(http.Header{"Content-Type": {"application/json"}})
+				rw.EXPECT().WriteHeader(http.StatusCreated)
+				rw.EXPECT().Write(created).Return(0, nil)
+				return rw
+			},
+			r:          newRequest("test-key", "test-merchant"),
+			nextStatus: http.StatusCreated,
+			wantNext:   true,
+		},
+		{
+			name: "first-request-failed-releases-key",
+			usecase: func() usecase.IIdempotencyUseCase {
+				uc := mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+				uc.
+					EXPECT().
+					Begin(gomock.Any(), "test-merchant", "test-key", gomock.Any()).
+					Return(reservation, false, nil)
+				uc.
+					EXPECT().
+					Release(gomock.Any(), "test-merchant", "test-key", reservation.LockedUntil).
+					Return(nil)
+				return uc
+			},
+			w: func() http.ResponseWriter {
+				rw := mock_http.NewMockResponseWriter(ctrl)
+				rw.EXPECT().WriteHeader(http.StatusServiceUnavailable)
+				rw.EXPECT().Write(created).Return(0, nil)
+				return rw
+			},
+			r:          newRequest("test-key", "test-merchant"),
+			nextStatus: http.StatusServiceUnavailable,
+			wantNext:   true,
+		},
+		{
+			name: "retry-replayed",
+			usecase: func() usecase.IIdempotencyUseCase {
+				uc := mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+				uc.
+					EXPECT().
+					Begin(gomock.Any(), "test-merchant", "test-key", gomock.Any()).
+					Return(model.IdempotencyKey{StatusCode: http.StatusCreated, ContentType: "application/x-ndjson", ResponseBody: created}, true, nil)
+				return uc
+			},
+			w: func() http.ResponseWriter {
+				header := http.Header{}
+				rw := mock_http.NewMockResponseWriter(ctrl)
+				rw.EXPECT().Header().Return(header).Times(2)
+				rw.EXPECT().WriteHeader(http.StatusCreated).Do(func(int) {
+					if got := header.Get("Content-Type"); got != "application/x-ndjson" {
+						t.Errorf("replayed Content-Type = %v, want application/x-ndjson", got)
+					}
+				})
+				rw.EXPECT().Write(created).Return(0, nil)
+				return rw
+			},
+			r: newRequest("test-key", "test-merchant"),
+		},
+		{
+			name: "retry-while-in-progress",
+			usecase: func() usecase.IIdempotencyUseCase {
+				uc := mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+				uc.
+					EXPECT().
+					Begin(gomock.Any(), "test-merchant", "test-key", gomock.Any()).
+					Return(model.IdempotencyKey{}, false, usecase.ErrIdempotencyKeyInUse)
+				return uc
+			},
+			w: func() http.ResponseWriter {
+				rw := mock_http.NewMockResponseWriter(ctrl)
+				rw.EXPECT().Header().Return(http.Header{})
+				rw.EXPECT().WriteHeader(http.StatusConflict)
+				rw.EXPECT().Write(gomock.Any()).Return(0, nil)
+				return rw
+			},
+			r: newRequest("test-key", "test-merchant"),
+		},
+		{
+			name: "key-reused-for-different-request",
+			usecase: func() usecase.IIdempotencyUseCase {
+				uc := mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+				uc.
+					EXPECT().
+					Begin(gomock.Any(), "test-merchant", "test-key", gomock.Any()).
+					Return(model.IdempotencyKey{}, false, usecase.ErrIdempotencyKeyMismatch)
+				return uc
+			},
+			w: func() http.ResponseWriter {
+				rw := mock_http.NewMockResponseWriter(ctrl)
+				rw.EXPECT().Header().Return(http.Header{})
+				rw.EXPECT().WriteHeader(http.StatusUnprocessableEntity)
+				rw.EXPECT().Write(gomock.Any()).Return(0, nil)
+				return rw
+			},
+			r: newRequest("test-key", "test-merchant"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			next := func(w http.ResponseWriter, r *http.Request) {
+				called = true
+
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil || string(b) != body {
+					t.Errorf("next handler body = %q, %v, want %q", b, err, body)
+				}
+
+				w.WriteHeader(tt.nextStatus)
+				w.Write(created)
+			}
+
+			m := IdempotencyMiddleware{Usecase: tt.usecase()}
+			m.Wrap(next)(tt.w(), tt.r)
+
+			if called != tt.wantNext {
+				t.Errorf("IdempotencyMiddleware.Wrap() called next = %v, want %v", called, tt.wantNext)
+			}
+		})
+	}
+}
+
+func Test_requestHash(t *testing.T) {
+	newRequest := func(merchantId string, query string) *http.Request {
+		req, err := http.NewRequest("POST", "/payment-codes:batch"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req.WithContext(usecase.WithMerchantID(req.Context(), merchantId))
+	}
+
+	hash := requestHash(newRequest("test-merchant", "?mode=atomic"), []byte(`{"name":"test-name"}`))
+	if got := requestHash(newRequest("test-merchant", "?mode=atomic"), []byte(`{"name":"test-name"}`)); got != hash {
+		t.Errorf("requestHash() = %v, want %v for the same request", got, hash)
+	}
+	if got := requestHash(newRequest("test-merchant", "?mode=atomic"), []byte(`{"name":"other-name"}`)); got == hash {
+		t.Errorf("requestHash() = %v for a different body", got)
+	}
+	if got := requestHash(newRequest("other-merchant", "?mode=atomic"), []byte(`{"name":"test-name"}`)); got == hash {
+		t.Errorf("requestHash() = %v for a different merchant", got)
+	}
+	if got := requestHash(newRequest("test-merchant", "?mode=partial"), []byte(`{"name":"test-name"}`)); got == hash {
+		t.Errorf("requestHash() = %v for a different query", got)
+	}
+}
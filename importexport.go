@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/paymentcodecsv"
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+)
+
+const (
+	EXPORT_FORMAT_CSV   = "csv"
+	EXPORT_FORMAT_JSONL = "jsonl"
+)
+
+// importChunkSize is how many rows are created per transaction. Rows of
+// committed chunks stay created when a later chunk fails.
+const importChunkSize = 500
+
+// importPaymentCodesHandler creates the payment codes of a CSV upload. Rows
+// are read and created in chunks, and every row that is not created is
+// reported with its row number. The upload is capped at server.max_upload_bytes
+// and must be read and processed within server.import_timeout. Uploads are
+// streamed rather than buffered, so Idempotency-Key is not honored here.
+func (p *PaymentCodeHandler) importPaymentCodesHandler(w http.ResponseWriter, r *http.Request) {
+	err := checkContentType(r, "text/csv")
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	reader, err := paymentcodecsv.NewReader(r.Body)
+	if isBodyTooLarge(err) {
+		errorHandler(w, r, readBodyError(err))
+		return
+	}
+	if err != nil {
+		badRequestHandler(w, r, err.Error())
+		return
+	}
+
+	result, err := p.importPaymentCodes(r.Context(), r.Method+" "+r.URL.Path, reader)
+	if isBodyTooLarge(err) {
+		err = readBodyError(err)
+	}
+	if err != nil {
+		log.Printf("%s %s: import stopped after creating %d payment codes", r.Method, r.URL.Path, result.Created)
+		errorHandler(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// importPaymentCodes validates every row of reader like a create request and
+// creates the valid ones in chunks. err is set when the import stopped; the
+// chunks before it are created. Unexpected row errors are logged under source.
+func (p *PaymentCodeHandler) importPaymentCodes(ctx context.Context, source string, reader *paymentcodecsv.Reader) (result model.PaymentCodeImport, err error) {
+	result.Errors = []model.PaymentCodeImportError{}
+
+	var chunk []*model.PaymentCode
+	var rows []int
+	flush := func() (err error) {
+		if len(chunk) == 0 {
+			return
+		}
+
+		errs, err := p.Usecase.CreateBatch(ctx, chunk, false)
+		if err != nil {
+			return
+		}
+
+		for i := range chunk {
+			if errs[i] == nil {
+				result.Created++
+				continue
+			}
+			status, message := batchItemError(source, errs[i])
+			result.Errors = append(result.Errors, model.PaymentCodeImportError{Row: rows[i], Status: status, Error: message})
+		}
+
+		chunk, rows = nil, nil
+		return
+	}
+
+	for {
+		paymentCode, row, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+
+		var rowErr *paymentcodecsv.RowError
+		if errors.As(readErr, &rowErr) {
+			result.Errors = append(result.Errors, model.PaymentCodeImportError{Row: row, Status: http.StatusBadRequest, Error: rowErr.Err.Error()})
+			continue
+		}
+		if readErr != nil {
+			err = readErr
+			break
+		}
+
+		validateError, validateErr := p.validate(paymentCode)
+		if validateErr != nil {
+			err = validateErr
+			break
+		}
+		if validateError.Message != "" {
+			result.Errors = append(result.Errors, model.PaymentCodeImportError{Row: row, Status: http.StatusBadRequest, Error: validateError.Message, Details: validateError.Details})
+			continue
+		}
+
+		chunk = append(chunk, &paymentCode)
+		rows = append(rows, row)
+		if len(chunk) == importChunkSize {
+			err = flush()
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	if err == nil {
+		err = flush()
+	}
+	result.Failed = len(result.Errors)
+
+	return
+}
+
+// exportPaymentCodesHandler streams the payment codes matching the list
+// filters as CSV, or as JSON lines with ?format=jsonl. It reads them page by
+// page, so the export is never held in memory. An error after the first page
+// can only cut the response short, and is logged. The whole export must be
+// written within server.export_timeout.
+func (p *PaymentCodeHandler) exportPaymentCodesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parsePaymentCodeFilter(r.URL.Query())
+	if err != nil {
+		badRequestHandler(w, r, err.Error())
+		return
+	}
+	filter.Limit = usecase.MaxListLimit
+
+	format := r.URL.Query().Get("format")
+	var contentType string
+	switch format {
+	case "", EXPORT_FORMAT_CSV:
+		format, contentType = EXPORT_FORMAT_CSV, "text/csv"
+	case EXPORT_FORMAT_JSONL:
+		contentType = "application/x-ndjson"
+	default:
+		badRequestHandler(w, r, fmt.Sprintf("query 'format' must be one of %s, %s", EXPORT_FORMAT_CSV, EXPORT_FORMAT_JSONL))
+		return
+	}
+
+	list, err := p.Usecase.List(r.Context(), filter)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payment-codes.%s"`, format))
+	w.WriteHeader(http.StatusOK)
+
+	var write func(paymentCode model.PaymentCode) error
+	var flush func() error
+	if format == EXPORT_FORMAT_CSV {
+		csvWriter := paymentcodecsv.NewWriter(w)
+		write, flush = csvWriter.Write, csvWriter.Flush
+		err = csvWriter.WriteHeader()
+	} else {
+		encoder := json.NewEncoder(w)
+		write = func(paymentCode model.PaymentCode) error { return encoder.Encode(paymentCode) }
+		flush = func() error { return nil }
+	}
+
+	for err == nil {
+		for _, paymentCode := range list.Data {
+			err = write(paymentCode)
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = flush()
+		}
+		if flusher, ok := w.(http.Flusher); ok && err == nil {
+			flusher.Flush()
+		}
+
+		if err != nil || list.NextCursor == "" {
+			break
+		}
+
+		filter.Cursor = list.NextCursor
+		list, err = p.Usecase.List(r.Context(), filter)
+	}
+
+	if err != nil {
+		log.Printf("%s %s: export stopped: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+// runImport is the import subcommand: it creates the payment codes of a CSV
+// file, or of stdin when the file is -, and prints the rows that were not
+// created. It returns the exit code.
+func runImport(ctx context.Context, cfg config.Config, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	merchantID := flags.String("merchant", "", "merchant id the payment codes are created for")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: import -merchant id file.csv")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *merchantID == "" {
+		flags.Usage()
+		return 2
+	}
+
+	input := os.Stdin
+	if name := flags.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+	}
+
+	reader, err := paymentcodecsv.NewReader(input)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	app, err := NewApp(ctx, cfg)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	defer func() {
+		if err := app.Shutdown(context.Background()); err != nil {
+			log.Printf("shutdown app: %v", err)
+		}
+	}()
+
+	_, err = app.Merchants.Get(ctx, *merchantID)
+	if err != nil {
+		log.Printf("merchant %s: %v", *merchantID, err)
+		return 1
+	}
+
+	result, err := app.PaymentCodeHandler.importPaymentCodes(usecase.WithMerchantID(ctx, *merchantID), "import", reader)
+	for _, rowErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "row %d: %s\n", rowErr.Row, rowErr.Error)
+	}
+	fmt.Printf("created %d payment codes, %d rows failed\n", result.Created, result.Failed)
+
+	if err != nil {
+		log.Printf("import stopped: %v", err)
+		return 1
+	}
+	if result.Failed > 0 {
+		return 1
+	}
+	return 0
+}
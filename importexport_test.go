@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mock_http "github.com/pevin/pevin-golang-training-beginner/mock/net/http"
+	mock_usecase "github.com/pevin/pevin-golang-training-beginner/mock/usecase"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+)
+
+func TestPaymentCodeHandler_importPaymentCodesHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	header := "payment_code,name,amount_type,amount,currency,usage_type\n"
+	valid := "test-payment-code-%d,test-name,FIXED,150000,IDR,SINGLE\n"
+
+	newRequest := func(body string) *http.Request {
+		req, err := http.NewRequest("POST", "/payment-codes:import", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "text/csv")
+		return req
+	}
+
+	tests := []struct {
+		name       string
+		usecase    func() usecase.IPaymentCodeUseCase
+		body       string
+		wantStatus int
+		want       model.PaymentCodeImport
+	}{
+		{
+			name: "import-with-row-errors",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+				uc.
+					EXPECT().
+					CreateBatch(gomock.Any(), gomock.Len(2), false).
+					Return([]error{nil, usecase.ErrDuplicatePaymentCode}, nil)
+				return uc
+			},
+			body: header +
+				strings.Replace(valid, "%d", "1", 1) +
+				"test-payment-code-2,test-name,FIXED,abc,IDR,SINGLE\n" +
+				"test-payment-code-3,,FIXED,150000,IDR,SINGLE\n" +
+				strings.Replace(valid, "%d", "4", 1),
+			wantStatus: http.StatusOK,
+			want: model.PaymentCodeImport{
+				Created: 1,
+				Failed:  3,
+				Errors: []model.PaymentCodeImportError{
+					{Row: 2, Status: http.StatusBadRequest, Error: "column 'amount' must be an integer"},
+					{Row: 3, Status: http.StatusBadRequest, Error: "field 'name' is required", Details: []model.ErrorDetail{{Field: "name", Rule: "required", Message: "field 'name' is required"}}},
+					{Row: 4, Status: http.StatusConflict, Error: usecase.ErrDuplicatePaymentCode.Error()},
+				},
+			},
+		},
+		{
+			name: "import-in-chunks",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+				uc.
+					EXPECT().
+					CreateBatch(gomock.Any(), gomock.Len(importChunkSize), false).
+					Return(make([]error, importChunkSize), nil)
+				uc.
+					EXPECT().
+					CreateBatch(gomock.Any(), gomock.Len(1), false).
+					Return(make([]error, 1), nil)
+				return uc
+			},
+			body:       header + strings.Repeat(strings.Replace(valid, "%d", "1", 1), importChunkSize+1),
+			wantStatus: http.StatusOK,
+			want: model.PaymentCodeImport{
+				Created: importChunkSize + 1,
+				Errors:  []model.PaymentCodeImportError{},
+			},
+		},
+		{
+			name: "invalid-header",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				return mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+			},
+			body:       "payment_code,color\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "with-error-in-usecase",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+				uc.
+					EXPECT().
+					CreateBatch(gomock.Any(), gomock.Any(), false).
+					Return(nil, errors.New("Mock Error"))
+				return uc
+			},
+			body:       header + strings.Replace(valid, "%d", "1", 1),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PaymentCodeHandler{
+				Usecase: tt.usecase(),
+			}
+			rw := mock_http.NewMockResponseWriter(ctrl)
+			rw.EXPECT().Header().Return(http.Header{}).AnyTimes()
+			if tt.wantStatus != http.StatusOK {
+				rw.EXPECT().WriteHeader(tt.wantStatus)
+			}
+			rw.EXPECT().Write(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+				if tt.wantStatus != http.StatusOK {
+					return len(b), nil
+				}
+				var got model.PaymentCodeImport
+				if err := json.Unmarshal(b, &got); err != nil {
+					t.Fatal(err)
+				}
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				if !bytes.Equal(gotJSON, wantJSON) {
+					t.Errorf("PaymentCodeHandler.importPaymentCodesHandler() = %s, want %s", gotJSON, wantJSON)
+				}
+				return len(b), nil
+			})
+
+			p.actionRouteHandler(rw, newRequest(tt.body))
+		})
+	}
+}
+
+func TestPaymentCodeHandler_exportPaymentCodesHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	first := model.PaymentCode{Id: "test-id-1", PaymentCode: "test-payment-code-1", Name: "test-name", Status: model.PAYMENT_CODE_STATUS_ACTIVE}
+	second := model.PaymentCode{Id: "test-id-2", PaymentCode: "test-payment-code-2", Name: "test-name", Status: model.PAYMENT_CODE_STATUS_ACTIVE}
+
+	twoPages := func() usecase.IPaymentCodeUseCase {
+		uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+		gomock.InOrder(
+			uc.
+				EXPECT().
+				List(gomock.Any(), model.PaymentCodeFilter{Status: model.PAYMENT_CODE_STATUS_ACTIVE, Limit: usecase.MaxListLimit}).
+				Return(model.PaymentCodeList{Data: []model.PaymentCode{first}, NextCursor: "test-cursor"}, nil),
+			uc.
+				EXPECT().
+				List(gomock.Any(), model.PaymentCodeFilter{Status: model.PAYMENT_CODE_STATUS_ACTIVE, Limit: usecase.MaxListLimit, Cursor: "test-cursor"}).
+				Return(model.PaymentCodeList{Data: []model.PaymentCode{second}}, nil),
+		)
+		return uc
+	}
+
+	tests := []struct {
+		name       string
+		usecase    func() usecase.IPaymentCodeUseCase
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "export-csv",
+			usecase:    twoPages,
+			query:      "?status=ACTIVE",
+			wantStatus: http.StatusOK,
+			wantBody: "id,payment_code,name,status,amount_type,amount,min_amount,max_amount,currency,usage_type,max_usage,max_total_amount,usage_count,total_paid,expiration_date,created_at,updated_at\n" +
+				"test-id-1,test-payment-code-1,test-name,ACTIVE,,,,,,,,,0,0,,,\n" +
+				"test-id-2,test-payment-code-2,test-name,ACTIVE,,,,,,,,,0,0,,,\n",
+		},
+		{
+			name:       "export-jsonl",
+			usecase:    twoPages,
+			query:      "?status=ACTIVE&format=jsonl",
+			wantStatus: http.StatusOK,
+			wantBody: func() string {
+				firstJSON, _ := json.Marshal(first)
+				secondJSON, _ := json.Marshal(second)
+				return string(firstJSON) + "\n" + string(secondJSON) + "\n"
+			}(),
+		},
+		{
+			name: "invalid-format",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				return mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+			},
+			query:      "?format=xlsx",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid-status",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				return mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+			},
+			query:      "?status=bogus",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "with-error-in-usecase",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+				uc.
+					EXPECT().
+					List(gomock.Any(), gomock.Any()).
+					Return(model.PaymentCodeList{}, errors.New("Mock Error"))
+				return uc
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PaymentCodeHandler{
+				Usecase: tt.usecase(),
+			}
+
+			var body bytes.Buffer
+			rw := mock_http.NewMockResponseWriter(ctrl)
+			rw.EXPECT().Header().Return(http.Header{}).AnyTimes()
+			rw.EXPECT().WriteHeader(tt.wantStatus)
+			rw.EXPECT().Write(gomock.Any()).DoAndReturn(body.Write).AnyTimes()
+
+			req, err := http.NewRequest("GET", "/payment-codes:export"+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.actionRouteHandler(rw, req)
+
+			if tt.wantBody != "" && body.String() != tt.wantBody {
+				t.Errorf("PaymentCodeHandler.exportPaymentCodesHandler() = %q, want %q", body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -1,21 +1,27 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/pevin/pevin-golang-training-beginner/config"
 	"github.com/pevin/pevin-golang-training-beginner/model"
-	"github.com/pevin/pevin-golang-training-beginner/producer"
 	"github.com/pevin/pevin-golang-training-beginner/repository"
 	"github.com/pevin/pevin-golang-training-beginner/usecase"
 
-	"gopkg.in/go-playground/validator.v9"
-
 	_ "github.com/lib/pq"
 )
 
@@ -24,29 +30,27 @@ type PaymentCodeHandler struct {
 }
 
 func (p *PaymentCodeHandler) createPaymentCode(w http.ResponseWriter, r *http.Request) (err error) {
-	paymentCode, err := p.Usecase.InitFromRequest(r)
+	var paymentCode model.PaymentCode
+	err = decodeJSON(r, &paymentCode)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		errorHandler(w, r, err)
 		return
 	}
 
 	validateError, err := p.validate(paymentCode)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		errorHandler(w, r, err)
 		return
 	}
 
 	if validateError.Message != "" {
-		resp, _ := json.Marshal(validateError)
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(resp)
+		validationErrorHandler(w, r, validateError)
 		return
 	}
 
 	err = p.Usecase.Create(r.Context(), &paymentCode)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		errorHandler(w, r, err)
 		return
 	}
 
@@ -59,17 +63,313 @@ func (p *PaymentCodeHandler) createPaymentCode(w http.ResponseWriter, r *http.Re
 	return
 }
 
+// batchCreateHandler creates the payment codes of a JSON array, or of a
+// stream of JSON objects when the body is application/x-ndjson. With
+// ?mode=atomic, the default, nothing is created unless every payment code is
+// valid; with ?mode=partial the valid ones are created. The response reports
+// the outcome of every payment code.
+func (p *PaymentCodeHandler) batchCreateHandler(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "":
+		mode = model.BATCH_MODE_ATOMIC
+	case model.BATCH_MODE_ATOMIC, model.BATCH_MODE_PARTIAL:
+	default:
+		badRequestHandler(w, r, fmt.Sprintf("query 'mode' must be one of %s, %s", model.BATCH_MODE_ATOMIC, model.BATCH_MODE_PARTIAL))
+		return
+	}
+
+	paymentCodes, err := decodeBatch(r)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	if len(paymentCodes) > usecase.MaxBatchCreateSize {
+		errorHandler(w, r, fmt.Errorf("%w: at most %d payment codes can be created at once", usecase.ErrBatchTooLarge, usecase.MaxBatchCreateSize))
+		return
+	}
+
+	batch := model.PaymentCodeBatch{Results: make([]model.PaymentCodeBatchResult, len(paymentCodes))}
+	var valid []*model.PaymentCode
+	var validIndexes []int
+	for i := range paymentCodes {
+		batch.Results[i].Index = i
+
+		validateError, err := p.validate(paymentCodes[i])
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+		if validateError.Message != "" {
+			batch.Results[i].Status = http.StatusBadRequest
+			batch.Results[i].Error = validateError.Message
+			batch.Results[i].Details = validateError.Details
+			continue
+		}
+
+		valid = append(valid, &paymentCodes[i])
+		validIndexes = append(validIndexes, i)
+	}
+
+	atomic := mode == model.BATCH_MODE_ATOMIC
+	if len(valid) > 0 && (!atomic || len(valid) == len(paymentCodes)) {
+		errs, err := p.Usecase.CreateBatch(r.Context(), valid, atomic)
+		if err != nil {
+			errorHandler(w, r, err)
+			return
+		}
+
+		for j, i := range validIndexes {
+			if errs[j] != nil {
+				batch.Results[i].Status, batch.Results[i].Error = batchItemError(r.Method+" "+r.URL.Path, errs[j])
+			}
+		}
+	}
+
+	rejected := false
+	for i := range batch.Results {
+		if batch.Results[i].Status != 0 {
+			rejected = true
+		}
+	}
+
+	for i := range batch.Results {
+		switch {
+		case batch.Results[i].Status != 0:
+		case atomic && rejected:
+			batch.Results[i].Status = http.StatusFailedDependency
+			batch.Results[i].Error = "not created because other payment codes of the batch failed"
+		default:
+			batch.Results[i].Status = http.StatusCreated
+			batch.Results[i].PaymentCode = &paymentCodes[i]
+			batch.Created++
+		}
+	}
+	batch.Failed = len(batch.Results) - batch.Created
+
+	status := http.StatusCreated
+	switch {
+	case rejected && atomic:
+		status = http.StatusUnprocessableEntity
+	case rejected:
+		status = http.StatusMultiStatus
+	}
+
+	resp, _ := json.Marshal(batch)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
+
+// decodeBatch reads the payment codes of a batch create request. It stops
+// reading one past usecase.MaxBatchCreateSize, which is enough to tell the
+// batch is too large.
+func decodeBatch(r *http.Request) (paymentCodes []model.PaymentCode, err error) {
+	err = checkContentType(r, "application/json", "application/x-ndjson", "application/jsonl")
+	if err != nil {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	stream := checkContentType(r, "application/json") != nil
+	if !stream {
+		token, tokenErr := decoder.Token()
+		if delim, ok := token.(json.Delim); tokenErr != nil || !ok || delim != '[' {
+			if isBodyTooLarge(tokenErr) {
+				err = readBodyError(tokenErr)
+				return
+			}
+			err = &bodyError{status: http.StatusBadRequest, message: "request body must be a JSON array of payment codes"}
+			return
+		}
+	}
+
+	for len(paymentCodes) <= usecase.MaxBatchCreateSize && (stream || decoder.More()) {
+		var paymentCode model.PaymentCode
+		err = decoder.Decode(&paymentCode)
+		if stream && err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			err = jsonBodyError(err)
+			if bodyErr, ok := err.(*bodyError); ok && bodyErr.status == http.StatusBadRequest {
+				bodyErr.message = fmt.Sprintf("payment code at index %d: %s", len(paymentCodes), bodyErr.message)
+			}
+			return
+		}
+		paymentCodes = append(paymentCodes, paymentCode)
+	}
+
+	if len(paymentCodes) == 0 {
+		err = &bodyError{status: http.StatusBadRequest, message: "request body must contain at least one payment code"}
+	}
+
+	return
+}
+
+// batchItemError maps the error of one payment code of a batch to the status
+// and message reported for it. Unexpected errors are logged under source, like
+// errorHandler logs them under the method and path of the request.
+func batchItemError(source string, err error) (status int, message string) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidExpiration):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, usecase.ErrDuplicatePaymentCode):
+		return http.StatusConflict, usecase.ErrDuplicatePaymentCode.Error()
+	case errors.Is(err, usecase.ErrPaymentCodeExhausted):
+		log.Printf("%s: %v", source, err)
+		return http.StatusServiceUnavailable, usecase.ErrPaymentCodeExhausted.Error()
+	default:
+		log.Printf("%s: %v", source, err)
+		return http.StatusInternalServerError, "internal server error"
+	}
+}
+
 func (p *PaymentCodeHandler) getPaymentCodeHandler(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/payment-codes/")
 
 	paymentCode, err := p.Usecase.Get(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		errorHandler(w, r, err)
 		return
 	}
 
-	if paymentCode.Id == "" {
-		notFoundHandler(w, r)
+	resp, _ := json.Marshal(paymentCode)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+func (p *PaymentCodeHandler) listPaymentCodesHandler(w http.ResponseWriter, r *http.Request) {
+	if paymentCode := r.URL.Query().Get("payment_code"); paymentCode != "" {
+		p.lookupPaymentCodeHandler(w, r, paymentCode)
+		return
+	}
+
+	filter, err := parsePaymentCodeFilter(r.URL.Query())
+	if err != nil {
+		badRequestHandler(w, r, err.Error())
+		return
+	}
+
+	list, err := p.Usecase.List(r.Context(), filter)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(list)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// lookupPaymentCodeHandler answers GET /payment-codes?payment_code=... with the
+// single matching payment code instead of a list.
+func (p *PaymentCodeHandler) lookupPaymentCodeHandler(w http.ResponseWriter, r *http.Request, paymentCode string) {
+	pc, err := p.Usecase.GetByPaymentCode(r.Context(), paymentCode)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(pc)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+func parsePaymentCodeFilter(query url.Values) (filter model.PaymentCodeFilter, err error) {
+	switch status := query.Get("status"); status {
+	case "", model.PAYMENT_CODE_STATUS_ACTIVE, model.PAYMENT_CODE_STATUS_INACTIVE, model.PAYMENT_CODE_STATUS_EXPIRED, model.PAYMENT_CODE_STATUS_PAID:
+		filter.Status = status
+	default:
+		err = fmt.Errorf("query 'status' must be one of %s, %s, %s, %s", model.PAYMENT_CODE_STATUS_ACTIVE, model.PAYMENT_CODE_STATUS_INACTIVE, model.PAYMENT_CODE_STATUS_EXPIRED, model.PAYMENT_CODE_STATUS_PAID)
+		return
+	}
+
+	filter.Name = query.Get("name")
+	filter.PaymentCodePrefix = query.Get("payment_code_prefix")
+	filter.Cursor = query.Get("cursor")
+
+	switch sortOrder := query.Get("sort"); sortOrder {
+	case "", model.SORT_ORDER_ASC, model.SORT_ORDER_DESC:
+		filter.SortOrder = sortOrder
+	default:
+		err = fmt.Errorf("query 'sort' must be one of %s, %s", model.SORT_ORDER_ASC, model.SORT_ORDER_DESC)
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 {
+			err = fmt.Errorf("query 'limit' must be a positive integer")
+			return
+		}
+	}
+
+	timeParams := []struct {
+		name  string
+		value *time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+		{"expiration_from", &filter.ExpirationFrom},
+		{"expiration_to", &filter.ExpirationTo},
+	}
+	for _, param := range timeParams {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		*param.value, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			err = fmt.Errorf("query '%s' must be an RFC 3339 timestamp", param.name)
+			return
+		}
+	}
+
+	return
+}
+
+func (p *PaymentCodeHandler) updatePaymentCodeStatusHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/payment-codes/")
+
+	var statusUpdate model.PaymentCodeStatusUpdate
+	err := decodeJSON(r, &statusUpdate)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	details, err := validatePayload(statusUpdate)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+	if len(details) > 0 {
+		validationErrorHandler(w, r, validationError(details))
+		return
+	}
+
+	var paymentCode model.PaymentCode
+	switch statusUpdate.Status {
+	case model.PAYMENT_CODE_STATUS_INACTIVE:
+		paymentCode, err = p.Usecase.Deactivate(r.Context(), id)
+	case model.PAYMENT_CODE_STATUS_ACTIVE:
+		paymentCode, err = p.Usecase.Reactivate(r.Context(), id)
+	default:
+		badRequestHandler(w, r, fmt.Sprintf("field 'status' must be one of %s, %s", model.PAYMENT_CODE_STATUS_ACTIVE, model.PAYMENT_CODE_STATUS_INACTIVE))
+		return
+	}
+
+	if err != nil {
+		errorHandler(w, r, err)
 		return
 	}
 
@@ -79,36 +379,116 @@ func (p *PaymentCodeHandler) getPaymentCodeHandler(w http.ResponseWriter, r *htt
 	w.Write(resp)
 }
 
+// validate checks paymentCode like a create request and reports every problem
+// at once.
 func (p *PaymentCodeHandler) validate(paymentCode model.PaymentCode) (valError model.Error, err error) {
-	validate := validator.New()
-	validateErrors := validate.Struct(paymentCode)
-	if validateErrors != nil {
-		for _, validateErrors := range validateErrors.(validator.ValidationErrors) {
-			switch validateErrors.Tag() {
-			case "required":
-				valError = model.Error{Message: fmt.Sprintf("field '%s' is required", validateErrors.Field())}
-				return
-			}
-		}
+	details, err := validatePayload(paymentCode)
+	if err != nil {
+		return
+	}
+
+	if amountErr := paymentCode.ValidateAmount(); amountErr != nil {
+		details = append(details, model.ErrorDetail{Rule: "amount", Message: amountErr.Error()})
 	}
+
+	if usageErr := paymentCode.ValidateUsage(); usageErr != nil {
+		details = append(details, model.ErrorDetail{Rule: "usage", Message: usageErr.Error()})
+	}
+
+	valError = validationError(details)
 	return
 }
 
-// PAYMENT CODE HANDLERS
-func paymentCodeRouteHandler(w http.ResponseWriter, r *http.Request) {
-	pcRepo := repository.PaymentCodeRepository{Db: getDB()}
-	pcProducer := producer.PaymentCodeMessageProducer{}
-	pcUsecase := usecase.PaymentCodeUseCase{Repo: pcRepo, Producer: pcProducer}
-	pcHandler := PaymentCodeHandler{
-		Usecase: pcUsecase,
+type PaymentHandler struct {
+	Usecase usecase.IPaymentUseCase
+}
+
+func (p *PaymentHandler) createPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	paymentCode, ok := parsePaymentsPath(r.URL.Path)
+	if !ok {
+		notFoundHandler(w, r)
+		return
+	}
+
+	var payment model.Payment
+	err := decodeJSON(r, &payment)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	err = p.Usecase.Pay(r.Context(), paymentCode, &payment)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(payment)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resp)
+}
+
+// parsePaymentsPath returns the payment_code value of a
+// /payment-codes/{payment_code}/payments path.
+func parsePaymentsPath(path string) (paymentCode string, ok bool) {
+	const prefix, suffix = "/payment-codes/", "/payments"
+	if len(path) <= len(prefix)+len(suffix) || !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) {
+		return
+	}
+
+	paymentCode = path[len(prefix) : len(path)-len(suffix)]
+	if strings.Contains(paymentCode, "/") {
+		return "", false
 	}
 
+	return paymentCode, true
+}
+
+// PAYMENT HANDLERS
+func (p *PaymentHandler) routeHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "POST":
-		pcHandler.createPaymentCode(w, r)
+		p.createPaymentHandler(w, r)
+		return
+	default:
+		notFoundHandler(w, r)
+	}
+}
+
+// actionRouteHandler routes the /payment-codes:{action} endpoints.
+func (p *PaymentCodeHandler) actionRouteHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method + " " + r.URL.Path {
+	case "POST /payment-codes:batch":
+		p.batchCreateHandler(w, r)
+		return
+	case "POST /payment-codes:import":
+		p.importPaymentCodesHandler(w, r)
+		return
+	case "GET /payment-codes:export":
+		p.exportPaymentCodesHandler(w, r)
+		return
+	default:
+		notFoundHandler(w, r)
+	}
+}
+
+// PAYMENT CODE HANDLERS
+func (p *PaymentCodeHandler) routeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		p.createPaymentCode(w, r)
 		return
 	case "GET":
-		pcHandler.getPaymentCodeHandler(w, r)
+		if r.URL.Path == "/payment-codes" {
+			p.listPaymentCodesHandler(w, r)
+			return
+		}
+		p.getPaymentCodeHandler(w, r)
+		return
+	case "PATCH":
+		p.updatePaymentCodeStatusHandler(w, r)
 		return
 	default:
 		notFoundHandler(w, r)
@@ -136,37 +516,135 @@ func notFoundHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(resp)
 }
 
-func main() {
-	http.HandleFunc("/health", healthHandler)
-	http.HandleFunc("/hello-world", helloWorldHandler)
-
-	http.HandleFunc("/payment-codes", paymentCodeRouteHandler)
-	http.HandleFunc("/payment-codes/", paymentCodeRouteHandler)
+func badRequestHandler(w http.ResponseWriter, r *http.Request, message string) {
+	writeError(w, http.StatusBadRequest, message)
+}
 
-	http.HandleFunc("/", notFoundHandler)
+// validationErrorHandler answers 400 with every problem of valError.
+func validationErrorHandler(w http.ResponseWriter, r *http.Request, valError model.Error) {
+	resp, _ := json.Marshal(valError)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(resp)
+}
 
-	log.Fatal(http.ListenAndServe(":8080", nil))
+// errorHandler maps errors returned by the usecases and repositories to an
+// HTTP status. Unexpected errors are logged and reported as 500 without
+// leaking their details to the client.
+func errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	var bodyErr *bodyError
+	switch {
+	case errors.As(err, &bodyErr):
+		writeError(w, bodyErr.status, bodyErr.message)
+	case errors.Is(err, usecase.ErrInvalidCheckDigit):
+		writeError(w, http.StatusNotFound, usecase.ErrInvalidCheckDigit.Error())
+	case errors.Is(err, repository.ErrNotFound):
+		notFoundHandler(w, r)
+	case errors.Is(err, usecase.ErrInvalidAPIKey):
+		unauthorizedHandler(w, usecase.ErrInvalidAPIKey.Error())
+	case errors.Is(err, usecase.ErrMerchantRequired):
+		writeError(w, http.StatusUnauthorized, usecase.ErrMerchantRequired.Error())
+	case errors.Is(err, usecase.ErrInvalidStatusTransition):
+		writeError(w, http.StatusConflict, usecase.ErrInvalidStatusTransition.Error())
+	case errors.Is(err, usecase.ErrPaymentCodeNotPayable):
+		writeError(w, http.StatusConflict, usecase.ErrPaymentCodeNotPayable.Error())
+	case errors.Is(err, usecase.ErrInvalidPayment), errors.Is(err, usecase.ErrInvalidExpiration):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrIdempotencyKeyInUse):
+		writeError(w, http.StatusConflict, usecase.ErrIdempotencyKeyInUse.Error())
+	case errors.Is(err, usecase.ErrIdempotencyKeyMismatch):
+		writeError(w, http.StatusUnprocessableEntity, usecase.ErrIdempotencyKeyMismatch.Error())
+	case errors.Is(err, usecase.ErrBatchTooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, usecase.ErrDuplicatePaymentCode):
+		writeError(w, http.StatusConflict, usecase.ErrDuplicatePaymentCode.Error())
+	case errors.Is(err, usecase.ErrPaymentCodeExhausted):
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		writeError(w, http.StatusServiceUnavailable, usecase.ErrPaymentCodeExhausted.Error())
+	case errors.Is(err, repository.ErrConflict):
+		writeError(w, http.StatusConflict, repository.ErrConflict.Error())
+	case errors.Is(err, repository.ErrInvalidCursor):
+		writeError(w, http.StatusBadRequest, repository.ErrInvalidCursor.Error())
+	case errors.Is(err, repository.ErrInvalidInput):
+		writeError(w, http.StatusBadRequest, repository.ErrInvalidInput.Error())
+	case errors.Is(err, repository.ErrUnavailable):
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		writeError(w, http.StatusServiceUnavailable, repository.ErrUnavailable.Error())
+	default:
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+	}
 }
 
-func getDB() *sql.DB {
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbUser := os.Getenv("DB_USER")
-	dbPass := os.Getenv("DB_PASS")
-	dbName := os.Getenv("DB_NAME")
+func writeError(w http.ResponseWriter, status int, message string) {
+	resp, err := json.Marshal(model.Error{Message: message})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
 
-	pgDsn := fmt.Sprintf("host=%s port=%s user=%s "+
-		"password=%s dbname=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPass, dbName)
+func main() {
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a JSON or YAML config file")
+	flag.Parse()
 
-	db, err := sql.Open("postgres", pgDsn)
+	cfg, err := config.Load(*configFile)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch flag.Arg(0) {
+	case "import":
+		code := runImport(ctx, cfg, flag.Args()[1:])
+		stop()
+		os.Exit(code)
+	case "merchant":
+		code := runMerchant(ctx, cfg, flag.Args()[1:])
+		stop()
+		os.Exit(code)
+	case "apikey":
+		code := runAPIKey(ctx, cfg, flag.Args()[1:])
+		stop()
+		os.Exit(code)
 	}
 
-	err = db.Ping()
+	app, err := NewApp(ctx, cfg)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
+	}
+
+	app.StartWorkers(ctx)
+
+	server := app.NewServer(cfg.Server)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", cfg.Server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serverErr:
+		log.Printf("server stopped: %v", err)
+	case <-ctx.Done():
+		log.Printf("shutting down")
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Duration())
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown server: %v", err)
+	}
+
+	if err := app.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown app: %v", err)
 	}
-	return db
 }
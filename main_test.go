@@ -1,17 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	_ "github.com/lib/pq"
 	mock_http "github.com/pevin/pevin-golang-training-beginner/mock/net/http"
 	mock_usecase "github.com/pevin/pevin-golang-training-beginner/mock/usecase"
 	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
 	"github.com/pevin/pevin-golang-training-beginner/usecase"
 )
 
@@ -32,13 +39,36 @@ func TestPaymentCodeHandler_createPaymentCode(t *testing.T) {
 	pc := model.PaymentCode{
 		Name:        "test-name",
 		PaymentCode: "test-payment-code",
+		AmountType:  model.AMOUNT_TYPE_FIXED,
+		Amount:      150000,
+		Currency:    "IDR",
+		UsageType:   model.USAGE_TYPE_SINGLE,
 	}
 
-	j, err := json.Marshal(pc)
+	invalidInput := pc
+	invalidInput.Name = ""
+	invalidInput.PaymentCode = ""
 
-	req, err := http.NewRequest("POST", "/payment-codes", strings.NewReader(string(j)))
-	if err != nil {
-		t.Fatal(err)
+	invalidAmount := pc
+	invalidAmount.AmountType = model.AMOUNT_TYPE_OPEN
+	invalidAmount.Amount = 0
+	invalidAmount.MinAmount = 20000
+	invalidAmount.MaxAmount = 10000
+
+	newRequest := func(body string) *http.Request {
+		req, err := http.NewRequest("POST", "/payment-codes", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+	marshal := func(p model.PaymentCode) string {
+		j, err := json.Marshal(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(j)
 	}
 
 	tests := []struct {
@@ -52,10 +82,6 @@ func TestPaymentCodeHandler_createPaymentCode(t *testing.T) {
 			fields: fields{
 				Usecase: func() usecase.IPaymentCodeUseCase {
 					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
-					uc.
-						EXPECT().
-						InitFromRequest(gomock.Any()).
-						Return(pc, nil)
 					uc.
 						EXPECT().
 						Create(gomock.Any(), &pc).
@@ -67,7 +93,7 @@ func TestPaymentCodeHandler_createPaymentCode(t *testing.T) {
 				w: func() http.ResponseWriter {
 					rw := mock_http.NewMockResponseWriter(ctrl)
 
-					rw.EXPECT().Header().Return(req.Header)
+					rw.EXPECT().Header().Return(http.Header{})
 					rw.EXPECT().WriteHeader(http.StatusCreated)
 
 					resp, _ := json.Marshal(pc)
@@ -75,21 +101,14 @@ func TestPaymentCodeHandler_createPaymentCode(t *testing.T) {
 
 					return rw
 				}(),
-				r: req,
+				r: newRequest(marshal(pc)),
 			},
 		},
 		{
 			name: "get-bad-request-for-invalid-input",
 			fields: fields{
 				Usecase: func() usecase.IPaymentCodeUseCase {
-					invalidPc := pc
-					invalidPc.Name = ""
-					invalidPc.PaymentCode = ""
 					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
-					uc.
-						EXPECT().
-						InitFromRequest(gomock.Any()).
-						Return(invalidPc, nil)
 					return uc
 				}(),
 			},
@@ -97,28 +116,48 @@ func TestPaymentCodeHandler_createPaymentCode(t *testing.T) {
 				w: func() http.ResponseWriter {
 					rw := mock_http.NewMockResponseWriter(ctrl)
 
-					rw.EXPECT().Header().Return(req.Header)
+					rw.EXPECT().Header().Return(http.Header{})
 					rw.EXPECT().WriteHeader(http.StatusBadRequest)
 
 					rw.EXPECT().Write(gomock.Any()).Return(0, nil)
 
 					return rw
 				}(),
-				r: req,
+				r: newRequest(marshal(invalidInput)),
+			},
+		},
+		{
+			name: "get-bad-request-for-invalid-amount",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					return uc
+				}(),
+			},
+			args: args{
+				w: func() http.ResponseWriter {
+					rw := mock_http.NewMockResponseWriter(ctrl)
+
+					rw.EXPECT().Header().Return(http.Header{})
+					rw.EXPECT().WriteHeader(http.StatusBadRequest)
+
+					error := model.Error{
+						Message: "field 'min_amount' must not be greater than 'max_amount'",
+						Details: []model.ErrorDetail{{Rule: "amount", Message: "field 'min_amount' must not be greater than 'max_amount'"}},
+					}
+					resp, _ := json.Marshal(error)
+					rw.EXPECT().Write(resp).Return(0, nil)
+
+					return rw
+				}(),
+				r: newRequest(marshal(invalidAmount)),
 			},
 		},
 		{
 			name: "get-internal-error-from-usecase",
 			fields: fields{
 				Usecase: func() usecase.IPaymentCodeUseCase {
-					invalidPc := pc
-					invalidPc.Name = ""
-					invalidPc.PaymentCode = ""
 					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
-					uc.
-						EXPECT().
-						InitFromRequest(gomock.Any()).
-						Return(pc, nil)
 					uc.
 						EXPECT().
 						Create(gomock.Any(), &pc).
@@ -130,15 +169,123 @@ func TestPaymentCodeHandler_createPaymentCode(t *testing.T) {
 				w: func() http.ResponseWriter {
 					rw := mock_http.NewMockResponseWriter(ctrl)
 
-					rw.EXPECT().Header().Return(req.Header)
-					rw.EXPECT().Header().Return(req.Header)
+					rw.EXPECT().Header().Return(http.Header{})
 					rw.EXPECT().WriteHeader(http.StatusInternalServerError)
 
 					rw.EXPECT().Write(gomock.Any()).Return(0, nil)
 
 					return rw
 				}(),
-				r: req,
+				r: newRequest(marshal(pc)),
+			},
+			wantErr: true,
+		},
+		{
+			name: "get-conflict-for-duplicate",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						Create(gomock.Any(), &pc).
+						Return(fmt.Errorf("%w: mock", usecase.ErrDuplicatePaymentCode))
+					return uc
+				}(),
+			},
+			args: args{
+				w: func() http.ResponseWriter {
+					rw := mock_http.NewMockResponseWriter(ctrl)
+
+					rw.EXPECT().Header().Return(http.Header{})
+					rw.EXPECT().WriteHeader(http.StatusConflict)
+
+					error := model.Error{Message: "payment_code already exists"}
+					resp, _ := json.Marshal(error)
+					rw.EXPECT().Write(resp).Return(0, nil)
+
+					return rw
+				}(),
+				r: newRequest(marshal(pc)),
+			},
+			wantErr: true,
+		},
+		{
+			name: "get-bad-request-for-invalid-expiration",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						Create(gomock.Any(), &pc).
+						Return(fmt.Errorf("%w: expiration must be in the future", usecase.ErrInvalidExpiration))
+					return uc
+				}(),
+			},
+			args: args{
+				w: func() http.ResponseWriter {
+					rw := mock_http.NewMockResponseWriter(ctrl)
+
+					rw.EXPECT().Header().Return(http.Header{})
+					rw.EXPECT().WriteHeader(http.StatusBadRequest)
+
+					error := model.Error{Message: "invalid expiration: expiration must be in the future"}
+					resp, _ := json.Marshal(error)
+					rw.EXPECT().Write(resp).Return(0, nil)
+
+					return rw
+				}(),
+				r: newRequest(marshal(pc)),
+			},
+			wantErr: true,
+		},
+		{
+			name: "get-service-unavailable-from-repository",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						Create(gomock.Any(), &pc).
+						Return(fmt.Errorf("%w: mock", repository.ErrUnavailable))
+					return uc
+				}(),
+			},
+			args: args{
+				w: func() http.ResponseWriter {
+					rw := mock_http.NewMockResponseWriter(ctrl)
+
+					rw.EXPECT().Header().Return(http.Header{})
+					rw.EXPECT().WriteHeader(http.StatusServiceUnavailable)
+
+					error := model.Error{Message: repository.ErrUnavailable.Error()}
+					resp, _ := json.Marshal(error)
+					rw.EXPECT().Write(resp).Return(0, nil)
+
+					return rw
+				}(),
+				r: newRequest(marshal(pc)),
+			},
+			wantErr: true,
+		},
+		{
+			name: "get-bad-request-for-malformed-json",
+			fields: fields{
+				Usecase: mock_usecase.NewMockIPaymentCodeUseCase(ctrl),
+			},
+			args: args{
+				w: func() http.ResponseWriter {
+					rw := mock_http.NewMockResponseWriter(ctrl)
+
+					rw.EXPECT().Header().Return(http.Header{})
+					rw.EXPECT().WriteHeader(http.StatusBadRequest)
+
+					error := model.Error{Message: "request body is not valid JSON"}
+					resp, _ := json.Marshal(error)
+					rw.EXPECT().Write(resp).Return(0, nil)
+
+					return rw
+				}(),
+				r: newRequest(`{"name":`),
 			},
 			wantErr: true,
 		},
@@ -220,7 +367,7 @@ func TestPaymentCodeHandler_getPaymentCodeHandler(t *testing.T) {
 					uc.
 						EXPECT().
 						Get(gomock.Any(), pc.Id).
-						Return(model.PaymentCode{}, nil)
+						Return(model.PaymentCode{}, repository.ErrNotFound)
 					return uc
 				}(),
 			},
@@ -255,7 +402,6 @@ func TestPaymentCodeHandler_getPaymentCodeHandler(t *testing.T) {
 				w: func() http.ResponseWriter {
 					rw := mock_http.NewMockResponseWriter(ctrl)
 
-					rw.EXPECT().Header().Return(req.Header)
 					rw.EXPECT().Header().Return(req.Header)
 					rw.EXPECT().WriteHeader(http.StatusInternalServerError)
 
@@ -277,3 +423,691 @@ func TestPaymentCodeHandler_getPaymentCodeHandler(t *testing.T) {
 	}
 
 }
+
+func TestPaymentCodeHandler_updatePaymentCodeStatusHandler(t *testing.T) {
+	type fields struct {
+		Usecase usecase.IPaymentCodeUseCase
+	}
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockErr := errors.New("Mock Error")
+
+	pc := model.PaymentCode{
+		Id:          "test-id",
+		Name:        "test-name",
+		PaymentCode: "test-payment-code",
+		Status:      model.PAYMENT_CODE_STATUS_INACTIVE,
+	}
+
+	newRequest := func(body string) *http.Request {
+		req, err := http.NewRequest("PATCH", "/payment-codes/test-id", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	tests := []struct {
+		name       string
+		fields     fields
+		body       string
+		wantStatus int
+		wantBody   []byte
+	}{
+		{
+			name: "deactivate-success",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						Deactivate(gomock.Any(), pc.Id).
+						Return(pc, nil)
+					return uc
+				}(),
+			},
+			body: `{"status":"INACTIVE"}`,
+			wantBody: func() []byte {
+				resp, _ := json.Marshal(pc)
+				return resp
+			}(),
+		},
+		{
+			name: "reactivate-success",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						Reactivate(gomock.Any(), pc.Id).
+						Return(pc, nil)
+					return uc
+				}(),
+			},
+			body: `{"status":"ACTIVE"}`,
+			wantBody: func() []byte {
+				resp, _ := json.Marshal(pc)
+				return resp
+			}(),
+		},
+		{
+			name: "bad-request-for-unknown-status",
+			fields: fields{
+				Usecase: mock_usecase.NewMockIPaymentCodeUseCase(ctrl),
+			},
+			body:       `{"status":"EXPIRED"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not-found",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						Deactivate(gomock.Any(), pc.Id).
+						Return(model.PaymentCode{}, repository.ErrNotFound)
+					return uc
+				}(),
+			},
+			body:       `{"status":"INACTIVE"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "conflict-for-invalid-transition",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						Deactivate(gomock.Any(), pc.Id).
+						Return(model.PaymentCode{}, usecase.ErrInvalidStatusTransition)
+					return uc
+				}(),
+			},
+			body:       `{"status":"INACTIVE"}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "conflict-for-invalid-reactivation",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						Reactivate(gomock.Any(), pc.Id).
+						Return(model.PaymentCode{}, usecase.ErrInvalidStatusTransition)
+					return uc
+				}(),
+			},
+			body:       `{"status":"ACTIVE"}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "internal-server-error-from-usecase",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						Deactivate(gomock.Any(), pc.Id).
+						Return(model.PaymentCode{}, mockErr)
+					return uc
+				}(),
+			},
+			body:       `{"status":"INACTIVE"}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := mock_http.NewMockResponseWriter(ctrl)
+			rw.EXPECT().Header().Return(http.Header{}).AnyTimes()
+			if tt.wantStatus != 0 {
+				rw.EXPECT().WriteHeader(tt.wantStatus)
+			}
+			if tt.wantBody != nil {
+				rw.EXPECT().Write(tt.wantBody).Return(0, nil)
+			} else {
+				rw.EXPECT().Write(gomock.Any()).Return(0, nil)
+			}
+
+			p := &PaymentCodeHandler{
+				Usecase: tt.fields.Usecase,
+			}
+			p.updatePaymentCodeStatusHandler(rw, newRequest(tt.body))
+		})
+	}
+}
+
+func TestPaymentCodeHandler_listPaymentCodesHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockErr := errors.New("Mock Error")
+
+	list := model.PaymentCodeList{
+		Data: []model.PaymentCode{
+			{Id: "test-id", Name: "test-name", PaymentCode: "test-payment-code"},
+		},
+		NextCursor: "next-cursor",
+	}
+	createdFrom, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+
+	tests := []struct {
+		name       string
+		usecase    usecase.IPaymentCodeUseCase
+		url        string
+		wantStatus int
+		wantBody   []byte
+	}{
+		{
+			name: "list-success",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+				uc.
+					EXPECT().
+					List(gomock.Any(), model.PaymentCodeFilter{
+						Status:            model.PAYMENT_CODE_STATUS_ACTIVE,
+						Name:              "test",
+						PaymentCodePrefix: "test-",
+						CreatedFrom:       createdFrom,
+						SortOrder:         model.SORT_ORDER_DESC,
+						Cursor:            "cursor",
+						Limit:             10,
+					}).
+					Return(list, nil)
+				return uc
+			}(),
+			url: "/payment-codes?status=ACTIVE&name=test&payment_code_prefix=test-&created_from=2021-01-01T00:00:00Z&sort=desc&cursor=cursor&limit=10",
+			wantBody: func() []byte {
+				resp, _ := json.Marshal(list)
+				return resp
+			}(),
+		},
+		{
+			name: "lookup-by-payment-code",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+				uc.
+					EXPECT().
+					GetByPaymentCode(gomock.Any(), "test-payment-code").
+					Return(list.Data[0], nil)
+				return uc
+			}(),
+			url: "/payment-codes?payment_code=test-payment-code",
+			wantBody: func() []byte {
+				resp, _ := json.Marshal(list.Data[0])
+				return resp
+			}(),
+		},
+		{
+			name: "lookup-by-payment-code-not-found",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+				uc.
+					EXPECT().
+					GetByPaymentCode(gomock.Any(), "unknown").
+					Return(model.PaymentCode{}, repository.ErrNotFound)
+				return uc
+			}(),
+			url:        "/payment-codes?payment_code=unknown",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "bad-request-for-invalid-limit",
+			usecase:    mock_usecase.NewMockIPaymentCodeUseCase(ctrl),
+			url:        "/payment-codes?limit=-1",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad-request-for-invalid-status",
+			usecase:    mock_usecase.NewMockIPaymentCodeUseCase(ctrl),
+			url:        "/payment-codes?status=bogus",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "bad-request-for-invalid-date",
+			usecase:    mock_usecase.NewMockIPaymentCodeUseCase(ctrl),
+			url:        "/payment-codes?expiration_to=tomorrow",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "bad-request-for-invalid-cursor",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+				uc.
+					EXPECT().
+					List(gomock.Any(), gomock.Any()).
+					Return(model.PaymentCodeList{}, repository.ErrInvalidCursor)
+				return uc
+			}(),
+			url:        "/payment-codes?cursor=invalid",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "internal-server-error-from-usecase",
+			usecase: func() usecase.IPaymentCodeUseCase {
+				uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+				uc.
+					EXPECT().
+					List(gomock.Any(), gomock.Any()).
+					Return(model.PaymentCodeList{}, mockErr)
+				return uc
+			}(),
+			url:        "/payment-codes",
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rw := mock_http.NewMockResponseWriter(ctrl)
+			rw.EXPECT().Header().Return(http.Header{}).AnyTimes()
+			if tt.wantStatus != 0 {
+				rw.EXPECT().WriteHeader(tt.wantStatus)
+			}
+			if tt.wantBody != nil {
+				rw.EXPECT().Write(tt.wantBody).Return(0, nil)
+			} else {
+				rw.EXPECT().Write(gomock.Any()).Return(0, nil)
+			}
+
+			p := &PaymentCodeHandler{
+				Usecase: tt.usecase,
+			}
+			p.listPaymentCodesHandler(rw, req)
+		})
+	}
+}
+
+func TestPaymentCodeHandler_batchCreateHandler(t *testing.T) {
+	type fields struct {
+		Usecase usecase.IPaymentCodeUseCase
+	}
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	item := `{"name":"test-name","payment_code":"%s","amount_type":"FIXED","amount":150000,"currency":"IDR","usage_type":"SINGLE"}`
+	valid := fmt.Sprintf(item, "test-payment-code-1")
+	other := fmt.Sprintf(item, "test-payment-code-2")
+	invalid := `{"name":"","payment_code":"test-payment-code-3"}`
+
+	newRequest := func(query string, contentType string, body string) *http.Request {
+		req, err := http.NewRequest("POST", "/payment-codes:batch"+query, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+
+	tests := []struct {
+		name        string
+		fields      fields
+		r           *http.Request
+		wantStatus  int
+		wantCreated int
+		wantFailed  int
+	}{
+		{
+			name: "atomic-created",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						CreateBatch(gomock.Any(), gomock.Len(2), true).
+						Return([]error{nil, nil}, nil)
+					return uc
+				}(),
+			},
+			r:           newRequest("", "application/json", "["+valid+","+other+"]"),
+			wantStatus:  http.StatusCreated,
+			wantCreated: 2,
+		},
+		{
+			name: "atomic-with-invalid-item",
+			fields: fields{
+				Usecase: mock_usecase.NewMockIPaymentCodeUseCase(ctrl),
+			},
+			r:          newRequest("?mode=atomic", "application/json", "["+valid+","+invalid+"]"),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantFailed: 2,
+		},
+		{
+			name: "atomic-with-duplicate",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						CreateBatch(gomock.Any(), gomock.Len(2), true).
+						Return([]error{nil, usecase.ErrDuplicatePaymentCode}, nil)
+					return uc
+				}(),
+			},
+			r:          newRequest("", "application/json", "["+valid+","+other+"]"),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantFailed: 2,
+		},
+		{
+			name: "partial-with-invalid-and-duplicate-items",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						CreateBatch(gomock.Any(), gomock.Len(2), false).
+						Return([]error{nil, usecase.ErrDuplicatePaymentCode}, nil)
+					return uc
+				}(),
+			},
+			r:           newRequest("?mode=partial", "application/json", "["+valid+","+invalid+","+other+"]"),
+			wantStatus:  http.StatusMultiStatus,
+			wantCreated: 1,
+			wantFailed:  2,
+		},
+		{
+			name: "ndjson-stream",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						CreateBatch(gomock.Any(), gomock.Len(2), true).
+						Return([]error{nil, nil}, nil)
+					return uc
+				}(),
+			},
+			r:           newRequest("", "application/x-ndjson", valid+"\n"+other+"\n"),
+			wantStatus:  http.StatusCreated,
+			wantCreated: 2,
+		},
+		{
+			name: "with-error-in-usecase",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						CreateBatch(gomock.Any(), gomock.Any(), true).
+						Return(nil, errors.New("Mock Error"))
+					return uc
+				}(),
+			},
+			r:          newRequest("", "application/json", "["+valid+"]"),
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "invalid-mode",
+			fields: fields{
+				Usecase: mock_usecase.NewMockIPaymentCodeUseCase(ctrl),
+			},
+			r:          newRequest("?mode=all", "application/json", "["+valid+"]"),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "not-an-array",
+			fields: fields{
+				Usecase: mock_usecase.NewMockIPaymentCodeUseCase(ctrl),
+			},
+			r:          newRequest("", "application/json", valid),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "empty-array",
+			fields: fields{
+				Usecase: mock_usecase.NewMockIPaymentCodeUseCase(ctrl),
+			},
+			r:          newRequest("", "application/json", "[]"),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "too-large",
+			fields: fields{
+				Usecase: mock_usecase.NewMockIPaymentCodeUseCase(ctrl),
+			},
+			r:          newRequest("", "application/json", "["+strings.Repeat(valid+",", usecase.MaxBatchCreateSize)+valid+"]"),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PaymentCodeHandler{
+				Usecase: tt.fields.Usecase,
+			}
+			rw := mock_http.NewMockResponseWriter(ctrl)
+			rw.EXPECT().Header().Return(http.Header{}).AnyTimes()
+			rw.EXPECT().WriteHeader(tt.wantStatus)
+			rw.EXPECT().Write(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+				if tt.wantStatus >= http.StatusBadRequest && tt.wantFailed == 0 {
+					return len(b), nil
+				}
+				var batch model.PaymentCodeBatch
+				if err := json.Unmarshal(b, &batch); err != nil {
+					t.Fatal(err)
+				}
+				if batch.Created != tt.wantCreated || batch.Failed != tt.wantFailed {
+					t.Errorf("PaymentCodeHandler.batchCreateHandler() created = %v, failed = %v, want %v, %v", batch.Created, batch.Failed, tt.wantCreated, tt.wantFailed)
+				}
+				return len(b), nil
+			})
+
+			p.actionRouteHandler(rw, tt.r)
+		})
+	}
+}
+
+func TestPaymentHandler_createPaymentHandler(t *testing.T) {
+	type fields struct {
+		Usecase usecase.IPaymentUseCase
+	}
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	payment := model.Payment{
+		Id:            "test-payment-id",
+		PaymentCodeId: "test-id",
+		PaymentCode:   "test-payment-code",
+		Amount:        150000,
+		Currency:      "IDR",
+	}
+
+	newRequest := func(body string) *http.Request {
+		req, err := http.NewRequest("POST", "/payment-codes/test-payment-code/payments", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	tests := []struct {
+		name       string
+		fields     fields
+		body       string
+		wantStatus int
+	}{
+		{
+			name: "pay-success",
+			fields: fields{
+				Usecase: func() usecase.IPaymentUseCase {
+					uc := mock_usecase.NewMockIPaymentUseCase(ctrl)
+					uc.
+						EXPECT().
+						Pay(gomock.Any(), "test-payment-code", &model.Payment{Amount: 150000}).
+						DoAndReturn(func(ctx context.Context, paymentCode string, p *model.Payment) error {
+							*p = payment
+							return nil
+						})
+					return uc
+				}(),
+			},
+			body:       `{"amount":150000}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "invalid-body",
+			fields: fields{
+				Usecase: mock_usecase.NewMockIPaymentUseCase(ctrl),
+			},
+			body:       `{"amount":`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "payment-code-not-found",
+			fields: fields{
+				Usecase: func() usecase.IPaymentUseCase {
+					uc := mock_usecase.NewMockIPaymentUseCase(ctrl)
+					uc.
+						EXPECT().
+						Pay(gomock.Any(), "test-payment-code", gomock.Any()).
+						Return(repository.ErrNotFound)
+					return uc
+				}(),
+			},
+			body:       `{"amount":150000}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "mistyped-payment-code-not-found",
+			fields: fields{
+				Usecase: func() usecase.IPaymentUseCase {
+					uc := mock_usecase.NewMockIPaymentUseCase(ctrl)
+					uc.
+						EXPECT().
+						Pay(gomock.Any(), "test-payment-code", gomock.Any()).
+						Return(usecase.ErrInvalidCheckDigit)
+					return uc
+				}(),
+			},
+			body:       `{"amount":150000}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "payment-code-not-payable",
+			fields: fields{
+				Usecase: func() usecase.IPaymentUseCase {
+					uc := mock_usecase.NewMockIPaymentUseCase(ctrl)
+					uc.
+						EXPECT().
+						Pay(gomock.Any(), "test-payment-code", gomock.Any()).
+						Return(usecase.ErrPaymentCodeNotPayable)
+					return uc
+				}(),
+			},
+			body:       `{"amount":150000}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "invalid-amount",
+			fields: fields{
+				Usecase: func() usecase.IPaymentUseCase {
+					uc := mock_usecase.NewMockIPaymentUseCase(ctrl)
+					uc.
+						EXPECT().
+						Pay(gomock.Any(), "test-payment-code", gomock.Any()).
+						Return(fmt.Errorf("%w: mock", usecase.ErrInvalidPayment))
+					return uc
+				}(),
+			},
+			body:       `{"amount":1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PaymentHandler{
+				Usecase: tt.fields.Usecase,
+			}
+			rw := mock_http.NewMockResponseWriter(ctrl)
+			rw.EXPECT().Header().Return(http.Header{}).AnyTimes()
+			rw.EXPECT().WriteHeader(tt.wantStatus)
+			rw.EXPECT().Write(gomock.Any()).Return(0, nil)
+
+			p.routeHandler(rw, newRequest(tt.body))
+		})
+	}
+}
+
+func Test_batchItemError(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+		wantLog     string
+	}{
+		{
+			name:        "duplicate",
+			err:         usecase.ErrDuplicatePaymentCode,
+			wantStatus:  http.StatusConflict,
+			wantMessage: usecase.ErrDuplicatePaymentCode.Error(),
+		},
+		{
+			name:        "exhausted",
+			err:         fmt.Errorf("%w: mock", usecase.ErrPaymentCodeExhausted),
+			wantStatus:  http.StatusServiceUnavailable,
+			wantMessage: usecase.ErrPaymentCodeExhausted.Error(),
+			wantLog:     "POST /payment-codes:batch: " + usecase.ErrPaymentCodeExhausted.Error(),
+		},
+		{
+			name:        "unexpected",
+			err:         errors.New("Mock Error"),
+			wantStatus:  http.StatusInternalServerError,
+			wantMessage: "internal server error",
+			wantLog:     "POST /payment-codes:batch: Mock Error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+
+			gotStatus, gotMessage := batchItemError("POST /payment-codes:batch", tt.err)
+			if gotStatus != tt.wantStatus || gotMessage != tt.wantMessage {
+				t.Errorf("batchItemError() = %v, %v, want %v, %v", gotStatus, gotMessage, tt.wantStatus, tt.wantMessage)
+			}
+			if !strings.Contains(logs.String(), tt.wantLog) || (tt.wantLog == "" && logs.Len() > 0) {
+				t.Errorf("batchItemError() logged %q, want %q", logs.String(), tt.wantLog)
+			}
+		})
+	}
+}
+
+func Test_parsePaymentsPath(t *testing.T) {
+	tests := []struct {
+		path            string
+		wantPaymentCode string
+		wantOk          bool
+	}{
+		{"/payment-codes/test-payment-code/payments", "test-payment-code", true},
+		{"/payment-codes/test-payment-code/payments/", "", false},
+		{"/payment-codes//payments", "", false},
+		{"/payment-codes/payments", "", false},
+		{"/payment-codes/a/b/payments", "", false},
+		{"/payment-codes/test-id", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			gotPaymentCode, gotOk := parsePaymentsPath(tt.path)
+			if gotPaymentCode != tt.wantPaymentCode || gotOk != tt.wantOk {
+				t.Errorf("parsePaymentsPath() = %v, %v, want %v, %v", gotPaymentCode, gotOk, tt.wantPaymentCode, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+)
+
+// runMerchant is the merchant subcommand. "merchant create" creates a
+// merchant and prints its id. It returns the exit code.
+func runMerchant(ctx context.Context, cfg config.Config, args []string) int {
+	flags := flag.NewFlagSet("merchant create", flag.ContinueOnError)
+	id := flags.String("id", "", "merchant id, generated when empty")
+	name := flags.String("name", "", "merchant name")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: merchant create [-id id] -name name")
+		flags.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "create" {
+		flags.Usage()
+		return 2
+	}
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	merchant := model.Merchant{Id: *id, Name: *name}
+	details, err := validatePayload(merchant)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	if len(details) > 0 {
+		log.Print(validationError(details).Message)
+		return 2
+	}
+
+	app, err := NewApp(ctx, cfg)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	defer func() {
+		if err := app.Shutdown(context.Background()); err != nil {
+			log.Printf("shutdown app: %v", err)
+		}
+	}()
+
+	err = app.Merchants.Create(ctx, &merchant)
+	if errors.Is(err, repository.ErrConflict) {
+		log.Printf("merchant %s already exists", merchant.Id)
+		return 1
+	}
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	fmt.Println(merchant.Id)
+
+	return 0
+}
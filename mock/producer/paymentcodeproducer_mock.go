@@ -5,6 +5,7 @@
 package mock_producer
 
 import (
+	context "context"
 	model "github.com/pevin/pevin-golang-training-beginner/model"
 	reflect "reflect"
 
@@ -34,16 +35,72 @@ func (m *MockIPaymentCodeMessageProducer) EXPECT() *MockIPaymentCodeMessageProdu
 	return m.recorder
 }
 
-// Produce mocks base method.
-func (m *MockIPaymentCodeMessageProducer) Produce(p *model.PaymentCode) error {
+// Close mocks base method.
+func (m *MockIPaymentCodeMessageProducer) Close() error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Produce", p)
+	ret := m.ctrl.Call(m, "Close")
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Produce indicates an expected call of Produce.
-func (mr *MockIPaymentCodeMessageProducerMockRecorder) Produce(p interface{}) *gomock.Call {
+// Close indicates an expected call of Close.
+func (mr *MockIPaymentCodeMessageProducerMockRecorder) Close() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Produce", reflect.TypeOf((*MockIPaymentCodeMessageProducer)(nil).Produce), p)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockIPaymentCodeMessageProducer)(nil).Close))
+}
+
+// ProduceCreated mocks base method.
+func (m *MockIPaymentCodeMessageProducer) ProduceCreated(ctx context.Context, p *model.PaymentCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProduceCreated", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProduceCreated indicates an expected call of ProduceCreated.
+func (mr *MockIPaymentCodeMessageProducerMockRecorder) ProduceCreated(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProduceCreated", reflect.TypeOf((*MockIPaymentCodeMessageProducer)(nil).ProduceCreated), ctx, p)
+}
+
+// ProduceExpired mocks base method.
+func (m *MockIPaymentCodeMessageProducer) ProduceExpired(ctx context.Context, p *model.PaymentCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProduceExpired", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProduceExpired indicates an expected call of ProduceExpired.
+func (mr *MockIPaymentCodeMessageProducerMockRecorder) ProduceExpired(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProduceExpired", reflect.TypeOf((*MockIPaymentCodeMessageProducer)(nil).ProduceExpired), ctx, p)
+}
+
+// ProducePaymentReceived mocks base method.
+func (m *MockIPaymentCodeMessageProducer) ProducePaymentReceived(ctx context.Context, p *model.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProducePaymentReceived", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProducePaymentReceived indicates an expected call of ProducePaymentReceived.
+func (mr *MockIPaymentCodeMessageProducerMockRecorder) ProducePaymentReceived(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProducePaymentReceived", reflect.TypeOf((*MockIPaymentCodeMessageProducer)(nil).ProducePaymentReceived), ctx, p)
+}
+
+// ProduceStatusChanged mocks base method.
+func (m *MockIPaymentCodeMessageProducer) ProduceStatusChanged(ctx context.Context, p *model.PaymentCode, previousStatus string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProduceStatusChanged", ctx, p, previousStatus)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProduceStatusChanged indicates an expected call of ProduceStatusChanged.
+func (mr *MockIPaymentCodeMessageProducerMockRecorder) ProduceStatusChanged(ctx, p, previousStatus interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProduceStatusChanged", reflect.TypeOf((*MockIPaymentCodeMessageProducer)(nil).ProduceStatusChanged), ctx, p, previousStatus)
 }
@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/idempotencykeyrepository.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	model "github.com/pevin/pevin-golang-training-beginner/model"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIIdempotencyKeyRepository is a mock of IIdempotencyKeyRepository interface.
+type MockIIdempotencyKeyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIIdempotencyKeyRepositoryMockRecorder
+}
+
+// MockIIdempotencyKeyRepositoryMockRecorder is the mock recorder for MockIIdempotencyKeyRepository.
+type MockIIdempotencyKeyRepositoryMockRecorder struct {
+	mock *MockIIdempotencyKeyRepository
+}
+
+// NewMockIIdempotencyKeyRepository creates a new mock instance.
+func NewMockIIdempotencyKeyRepository(ctrl *gomock.Controller) *MockIIdempotencyKeyRepository {
+	mock := &MockIIdempotencyKeyRepository{ctrl: ctrl}
+	mock.recorder = &MockIIdempotencyKeyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIIdempotencyKeyRepository) EXPECT() *MockIIdempotencyKeyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockIIdempotencyKeyRepository) Complete(ctx context.Context, clientId, key string, lockedUntil time.Time, statusCode int, contentType string, responseBody []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, clientId, key, lockedUntil, statusCode, contentType, responseBody)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIIdempotencyKeyRepositoryMockRecorder) Complete(ctx, clientId, key, lockedUntil, statusCode, contentType, responseBody interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIIdempotencyKeyRepository)(nil).Complete), ctx, clientId, key, lockedUntil, statusCode, contentType, responseBody)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockIIdempotencyKeyRepository) DeleteExpired(ctx context.Context, now time.Time, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, now, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockIIdempotencyKeyRepositoryMockRecorder) DeleteExpired(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockIIdempotencyKeyRepository)(nil).DeleteExpired), ctx, now, limit)
+}
+
+// Release mocks base method.
+func (m *MockIIdempotencyKeyRepository) Release(ctx context.Context, clientId, key string, lockedUntil time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, clientId, key, lockedUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockIIdempotencyKeyRepositoryMockRecorder) Release(ctx, clientId, key, lockedUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIIdempotencyKeyRepository)(nil).Release), ctx, clientId, key, lockedUntil)
+}
+
+// Reserve mocks base method.
+func (m *MockIIdempotencyKeyRepository) Reserve(ctx context.Context, k *model.IdempotencyKey, now time.Time) (model.IdempotencyKey, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, k, now)
+	ret0, _ := ret[0].(model.IdempotencyKey)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockIIdempotencyKeyRepositoryMockRecorder) Reserve(ctx, k, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockIIdempotencyKeyRepository)(nil).Reserve), ctx, k, now)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/merchantrepository.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	model "github.com/pevin/pevin-golang-training-beginner/model"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIMerchantRepository is a mock of IMerchantRepository interface.
+type MockIMerchantRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMerchantRepositoryMockRecorder
+}
+
+// MockIMerchantRepositoryMockRecorder is the mock recorder for MockIMerchantRepository.
+type MockIMerchantRepositoryMockRecorder struct {
+	mock *MockIMerchantRepository
+}
+
+// NewMockIMerchantRepository creates a new mock instance.
+func NewMockIMerchantRepository(ctrl *gomock.Controller) *MockIMerchantRepository {
+	mock := &MockIMerchantRepository{ctrl: ctrl}
+	mock.recorder = &MockIMerchantRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMerchantRepository) EXPECT() *MockIMerchantRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m_2 *MockIMerchantRepository) Create(ctx context.Context, m *model.Merchant) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "Create", ctx, m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIMerchantRepositoryMockRecorder) Create(ctx, m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIMerchantRepository)(nil).Create), ctx, m)
+}
+
+// Get mocks base method.
+func (m *MockIMerchantRepository) Get(ctx context.Context, id string) (model.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(model.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIMerchantRepositoryMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIMerchantRepository)(nil).Get), ctx, id)
+}
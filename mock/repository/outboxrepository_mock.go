@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/outboxrepository.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	model "github.com/pevin/pevin-golang-training-beginner/model"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIOutboxRepository is a mock of IOutboxRepository interface.
+type MockIOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIOutboxRepositoryMockRecorder
+}
+
+// MockIOutboxRepositoryMockRecorder is the mock recorder for MockIOutboxRepository.
+type MockIOutboxRepositoryMockRecorder struct {
+	mock *MockIOutboxRepository
+}
+
+// NewMockIOutboxRepository creates a new mock instance.
+func NewMockIOutboxRepository(ctrl *gomock.Controller) *MockIOutboxRepository {
+	mock := &MockIOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockIOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIOutboxRepository) EXPECT() *MockIOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockIOutboxRepository) Claim(ctx context.Context, now, leasedUntil time.Time, limit int) ([]model.OutboxMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, now, leasedUntil, limit)
+	ret0, _ := ret[0].([]model.OutboxMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockIOutboxRepositoryMockRecorder) Claim(ctx, now, leasedUntil, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockIOutboxRepository)(nil).Claim), ctx, now, leasedUntil, limit)
+}
+
+// Create mocks base method.
+func (m_2 *MockIOutboxRepository) Create(ctx context.Context, m *model.OutboxMessage) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "Create", ctx, m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIOutboxRepositoryMockRecorder) Create(ctx, m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIOutboxRepository)(nil).Create), ctx, m)
+}
+
+// MarkFailed mocks base method.
+func (m *MockIOutboxRepository) MarkFailed(ctx context.Context, id string, leasedUntil, nextAttemptAt time.Time, lastError string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, id, leasedUntil, nextAttemptAt, lastError)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockIOutboxRepositoryMockRecorder) MarkFailed(ctx, id, leasedUntil, nextAttemptAt, lastError interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockIOutboxRepository)(nil).MarkFailed), ctx, id, leasedUntil, nextAttemptAt, lastError)
+}
+
+// MarkPublished mocks base method.
+func (m *MockIOutboxRepository) MarkPublished(ctx context.Context, id string, leasedUntil, publishedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPublished", ctx, id, leasedUntil, publishedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPublished indicates an expected call of MarkPublished.
+func (mr *MockIOutboxRepositoryMockRecorder) MarkPublished(ctx, id, leasedUntil, publishedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPublished", reflect.TypeOf((*MockIOutboxRepository)(nil).MarkPublished), ctx, id, leasedUntil, publishedAt)
+}
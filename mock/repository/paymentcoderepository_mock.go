@@ -8,6 +8,7 @@ import (
 	context "context"
 	model "github.com/pevin/pevin-golang-training-beginner/model"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -49,17 +50,150 @@ func (mr *MockIPaymentCodeRepositoryMockRecorder) Create(ctx, p interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIPaymentCodeRepository)(nil).Create), ctx, p)
 }
 
+// CreateBatch mocks base method.
+func (m *MockIPaymentCodeRepository) CreateBatch(ctx context.Context, paymentCodes []*model.PaymentCode) ([]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, paymentCodes)
+	ret0, _ := ret[0].([]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockIPaymentCodeRepositoryMockRecorder) CreateBatch(ctx, paymentCodes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockIPaymentCodeRepository)(nil).CreateBatch), ctx, paymentCodes)
+}
+
+// ExpireBatch mocks base method.
+func (m *MockIPaymentCodeRepository) ExpireBatch(ctx context.Context, now time.Time, limit int) ([]model.PaymentCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireBatch", ctx, now, limit)
+	ret0, _ := ret[0].([]model.PaymentCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireBatch indicates an expected call of ExpireBatch.
+func (mr *MockIPaymentCodeRepositoryMockRecorder) ExpireBatch(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireBatch", reflect.TypeOf((*MockIPaymentCodeRepository)(nil).ExpireBatch), ctx, now, limit)
+}
+
 // Get mocks base method.
-func (m *MockIPaymentCodeRepository) Get(ctx context.Context, id string) (model.PaymentCode, error) {
+func (m *MockIPaymentCodeRepository) Get(ctx context.Context, merchantID, id string) (model.PaymentCode, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret := m.ctrl.Call(m, "Get", ctx, merchantID, id)
 	ret0, _ := ret[0].(model.PaymentCode)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockIPaymentCodeRepositoryMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+func (mr *MockIPaymentCodeRepositoryMockRecorder) Get(ctx, merchantID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIPaymentCodeRepository)(nil).Get), ctx, merchantID, id)
+}
+
+// GetByPaymentCode mocks base method.
+func (m *MockIPaymentCodeRepository) GetByPaymentCode(ctx context.Context, merchantID, paymentCode string) (model.PaymentCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByPaymentCode", ctx, merchantID, paymentCode)
+	ret0, _ := ret[0].(model.PaymentCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByPaymentCode indicates an expected call of GetByPaymentCode.
+func (mr *MockIPaymentCodeRepositoryMockRecorder) GetByPaymentCode(ctx, merchantID, paymentCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByPaymentCode", reflect.TypeOf((*MockIPaymentCodeRepository)(nil).GetByPaymentCode), ctx, merchantID, paymentCode)
+}
+
+// List mocks base method.
+func (m *MockIPaymentCodeRepository) List(ctx context.Context, filter model.PaymentCodeFilter) ([]model.PaymentCode, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].([]model.PaymentCode)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockIPaymentCodeRepositoryMockRecorder) List(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIPaymentCodeRepository)(nil).List), ctx, filter)
+}
+
+// RecordPayment mocks base method.
+func (m *MockIPaymentCodeRepository) RecordPayment(ctx context.Context, merchantID, id string, amount int64, now time.Time) (model.PaymentCode, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordPayment", ctx, merchantID, id, amount, now)
+	ret0, _ := ret[0].(model.PaymentCode)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RecordPayment indicates an expected call of RecordPayment.
+func (mr *MockIPaymentCodeRepositoryMockRecorder) RecordPayment(ctx, merchantID, id, amount, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPayment", reflect.TypeOf((*MockIPaymentCodeRepository)(nil).RecordPayment), ctx, merchantID, id, amount, now)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockIPaymentCodeRepository) UpdateStatus(ctx context.Context, merchantID, id, fromStatus, toStatus string, updatedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, merchantID, id, fromStatus, toStatus, updatedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockIPaymentCodeRepositoryMockRecorder) UpdateStatus(ctx, merchantID, id, fromStatus, toStatus, updatedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockIPaymentCodeRepository)(nil).UpdateStatus), ctx, merchantID, id, fromStatus, toStatus, updatedAt)
+}
+
+// Mockscanner is a mock of scanner interface.
+type Mockscanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockscannerMockRecorder
+}
+
+// MockscannerMockRecorder is the mock recorder for Mockscanner.
+type MockscannerMockRecorder struct {
+	mock *Mockscanner
+}
+
+// NewMockscanner creates a new mock instance.
+func NewMockscanner(ctrl *gomock.Controller) *Mockscanner {
+	mock := &Mockscanner{ctrl: ctrl}
+	mock.recorder = &MockscannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockscanner) EXPECT() *MockscannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *Mockscanner) Scan(dest ...interface{}) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockscannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIPaymentCodeRepository)(nil).Get), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*Mockscanner)(nil).Scan), dest...)
 }
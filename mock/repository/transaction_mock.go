@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/transaction.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockITransactor is a mock of ITransactor interface.
+type MockITransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockITransactorMockRecorder
+}
+
+// MockITransactorMockRecorder is the mock recorder for MockITransactor.
+type MockITransactorMockRecorder struct {
+	mock *MockITransactor
+}
+
+// NewMockITransactor creates a new mock instance.
+func NewMockITransactor(ctrl *gomock.Controller) *MockITransactor {
+	mock := &MockITransactor{ctrl: ctrl}
+	mock.recorder = &MockITransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockITransactor) EXPECT() *MockITransactorMockRecorder {
+	return m.recorder
+}
+
+// WithinTransaction mocks base method.
+func (m *MockITransactor) WithinTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTransaction indicates an expected call of WithinTransaction.
+func (mr *MockITransactorMockRecorder) WithinTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockITransactor)(nil).WithinTransaction), ctx, fn)
+}
@@ -0,0 +1,197 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository/webhookrepository.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	model "github.com/pevin/pevin-golang-training-beginner/model"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIWebhookRepository is a mock of IWebhookRepository interface.
+type MockIWebhookRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIWebhookRepositoryMockRecorder
+}
+
+// MockIWebhookRepositoryMockRecorder is the mock recorder for MockIWebhookRepository.
+type MockIWebhookRepositoryMockRecorder struct {
+	mock *MockIWebhookRepository
+}
+
+// NewMockIWebhookRepository creates a new mock instance.
+func NewMockIWebhookRepository(ctrl *gomock.Controller) *MockIWebhookRepository {
+	mock := &MockIWebhookRepository{ctrl: ctrl}
+	mock.recorder = &MockIWebhookRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIWebhookRepository) EXPECT() *MockIWebhookRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockIWebhookRepository) Claim(ctx context.Context, now, leasedUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, now, leasedUntil, limit)
+	ret0, _ := ret[0].([]model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockIWebhookRepositoryMockRecorder) Claim(ctx, now, leasedUntil, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockIWebhookRepository)(nil).Claim), ctx, now, leasedUntil, limit)
+}
+
+// CreateDelivery mocks base method.
+func (m *MockIWebhookRepository) CreateDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDelivery", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDelivery indicates an expected call of CreateDelivery.
+func (mr *MockIWebhookRepositoryMockRecorder) CreateDelivery(ctx, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDelivery", reflect.TypeOf((*MockIWebhookRepository)(nil).CreateDelivery), ctx, d)
+}
+
+// CreateEndpoint mocks base method.
+func (m *MockIWebhookRepository) CreateEndpoint(ctx context.Context, e *model.WebhookEndpoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEndpoint", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEndpoint indicates an expected call of CreateEndpoint.
+func (mr *MockIWebhookRepositoryMockRecorder) CreateEndpoint(ctx, e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEndpoint", reflect.TypeOf((*MockIWebhookRepository)(nil).CreateEndpoint), ctx, e)
+}
+
+// DeleteEndpoint mocks base method.
+func (m *MockIWebhookRepository) DeleteEndpoint(ctx context.Context, merchantID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEndpoint", ctx, merchantID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEndpoint indicates an expected call of DeleteEndpoint.
+func (mr *MockIWebhookRepositoryMockRecorder) DeleteEndpoint(ctx, merchantID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEndpoint", reflect.TypeOf((*MockIWebhookRepository)(nil).DeleteEndpoint), ctx, merchantID, id)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockIWebhookRepository) ListDeliveries(ctx context.Context, filter model.WebhookDeliveryFilter) ([]model.WebhookDelivery, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", ctx, filter)
+	ret0, _ := ret[0].([]model.WebhookDelivery)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockIWebhookRepositoryMockRecorder) ListDeliveries(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockIWebhookRepository)(nil).ListDeliveries), ctx, filter)
+}
+
+// ListEndpoints mocks base method.
+func (m *MockIWebhookRepository) ListEndpoints(ctx context.Context, merchantID string) ([]model.WebhookEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEndpoints", ctx, merchantID)
+	ret0, _ := ret[0].([]model.WebhookEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEndpoints indicates an expected call of ListEndpoints.
+func (mr *MockIWebhookRepositoryMockRecorder) ListEndpoints(ctx, merchantID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEndpoints", reflect.TypeOf((*MockIWebhookRepository)(nil).ListEndpoints), ctx, merchantID)
+}
+
+// ListSubscribedEndpoints mocks base method.
+func (m *MockIWebhookRepository) ListSubscribedEndpoints(ctx context.Context, merchantID, eventType string) ([]model.WebhookEndpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubscribedEndpoints", ctx, merchantID, eventType)
+	ret0, _ := ret[0].([]model.WebhookEndpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubscribedEndpoints indicates an expected call of ListSubscribedEndpoints.
+func (mr *MockIWebhookRepositoryMockRecorder) ListSubscribedEndpoints(ctx, merchantID, eventType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubscribedEndpoints", reflect.TypeOf((*MockIWebhookRepository)(nil).ListSubscribedEndpoints), ctx, merchantID, eventType)
+}
+
+// MarkDead mocks base method.
+func (m *MockIWebhookRepository) MarkDead(ctx context.Context, id string, leasedUntil time.Time, statusCode int, lastError string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDead", ctx, id, leasedUntil, statusCode, lastError, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDead indicates an expected call of MarkDead.
+func (mr *MockIWebhookRepositoryMockRecorder) MarkDead(ctx, id, leasedUntil, statusCode, lastError, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDead", reflect.TypeOf((*MockIWebhookRepository)(nil).MarkDead), ctx, id, leasedUntil, statusCode, lastError, now)
+}
+
+// MarkDelivered mocks base method.
+func (m *MockIWebhookRepository) MarkDelivered(ctx context.Context, id string, leasedUntil time.Time, statusCode int, deliveredAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDelivered", ctx, id, leasedUntil, statusCode, deliveredAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDelivered indicates an expected call of MarkDelivered.
+func (mr *MockIWebhookRepositoryMockRecorder) MarkDelivered(ctx, id, leasedUntil, statusCode, deliveredAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDelivered", reflect.TypeOf((*MockIWebhookRepository)(nil).MarkDelivered), ctx, id, leasedUntil, statusCode, deliveredAt)
+}
+
+// MarkFailed mocks base method.
+func (m *MockIWebhookRepository) MarkFailed(ctx context.Context, id string, leasedUntil time.Time, statusCode int, lastError string, nextAttemptAt, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, id, leasedUntil, statusCode, lastError, nextAttemptAt, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockIWebhookRepositoryMockRecorder) MarkFailed(ctx, id, leasedUntil, statusCode, lastError, nextAttemptAt, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockIWebhookRepository)(nil).MarkFailed), ctx, id, leasedUntil, statusCode, lastError, nextAttemptAt, now)
+}
+
+// Redeliver mocks base method.
+func (m *MockIWebhookRepository) Redeliver(ctx context.Context, merchantID, id string, now time.Time) (model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeliver", ctx, merchantID, id, now)
+	ret0, _ := ret[0].(model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeliver indicates an expected call of Redeliver.
+func (mr *MockIWebhookRepositoryMockRecorder) Redeliver(ctx, merchantID, id, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeliver", reflect.TypeOf((*MockIWebhookRepository)(nil).Redeliver), ctx, merchantID, id, now)
+}
@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usecase/apikeyusecase.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	model "github.com/pevin/pevin-golang-training-beginner/model"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIAPIKeyUseCase is a mock of IAPIKeyUseCase interface.
+type MockIAPIKeyUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAPIKeyUseCaseMockRecorder
+}
+
+// MockIAPIKeyUseCaseMockRecorder is the mock recorder for MockIAPIKeyUseCase.
+type MockIAPIKeyUseCaseMockRecorder struct {
+	mock *MockIAPIKeyUseCase
+}
+
+// NewMockIAPIKeyUseCase creates a new mock instance.
+func NewMockIAPIKeyUseCase(ctrl *gomock.Controller) *MockIAPIKeyUseCase {
+	mock := &MockIAPIKeyUseCase{ctrl: ctrl}
+	mock.recorder = &MockIAPIKeyUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAPIKeyUseCase) EXPECT() *MockIAPIKeyUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockIAPIKeyUseCase) Authenticate(ctx context.Context, key string) (model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, key)
+	ret0, _ := ret[0].(model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockIAPIKeyUseCaseMockRecorder) Authenticate(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockIAPIKeyUseCase)(nil).Authenticate), ctx, key)
+}
+
+// Create mocks base method.
+func (m *MockIAPIKeyUseCase) Create(ctx context.Context, k *model.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, k)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIAPIKeyUseCaseMockRecorder) Create(ctx, k interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIAPIKeyUseCase)(nil).Create), ctx, k)
+}
+
+// List mocks base method.
+func (m *MockIAPIKeyUseCase) List(ctx context.Context) (model.APIKeyList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].(model.APIKeyList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockIAPIKeyUseCaseMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIAPIKeyUseCase)(nil).List), ctx)
+}
+
+// Revoke mocks base method.
+func (m *MockIAPIKeyUseCase) Revoke(ctx context.Context, id string) (model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, id)
+	ret0, _ := ret[0].(model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockIAPIKeyUseCaseMockRecorder) Revoke(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockIAPIKeyUseCase)(nil).Revoke), ctx, id)
+}
+
+// Rotate mocks base method.
+func (m *MockIAPIKeyUseCase) Rotate(ctx context.Context, id string) (model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", ctx, id)
+	ret0, _ := ret[0].(model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockIAPIKeyUseCaseMockRecorder) Rotate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockIAPIKeyUseCase)(nil).Rotate), ctx, id)
+}
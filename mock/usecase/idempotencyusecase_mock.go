@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usecase/idempotencyusecase.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	model "github.com/pevin/pevin-golang-training-beginner/model"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIIdempotencyUseCase is a mock of IIdempotencyUseCase interface.
+type MockIIdempotencyUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockIIdempotencyUseCaseMockRecorder
+}
+
+// MockIIdempotencyUseCaseMockRecorder is the mock recorder for MockIIdempotencyUseCase.
+type MockIIdempotencyUseCaseMockRecorder struct {
+	mock *MockIIdempotencyUseCase
+}
+
+// NewMockIIdempotencyUseCase creates a new mock instance.
+func NewMockIIdempotencyUseCase(ctrl *gomock.Controller) *MockIIdempotencyUseCase {
+	mock := &MockIIdempotencyUseCase{ctrl: ctrl}
+	mock.recorder = &MockIIdempotencyUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIIdempotencyUseCase) EXPECT() *MockIIdempotencyUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Begin mocks base method.
+func (m *MockIIdempotencyUseCase) Begin(ctx context.Context, clientId, key, requestHash string) (model.IdempotencyKey, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin", ctx, clientId, key, requestHash)
+	ret0, _ := ret[0].(model.IdempotencyKey)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockIIdempotencyUseCaseMockRecorder) Begin(ctx, clientId, key, requestHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockIIdempotencyUseCase)(nil).Begin), ctx, clientId, key, requestHash)
+}
+
+// Complete mocks base method.
+func (m *MockIIdempotencyUseCase) Complete(ctx context.Context, clientId, key string, lockedUntil time.Time, statusCode int, contentType string, responseBody []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, clientId, key, lockedUntil, statusCode, contentType, responseBody)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIIdempotencyUseCaseMockRecorder) Complete(ctx, clientId, key, lockedUntil, statusCode, contentType, responseBody interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIIdempotencyUseCase)(nil).Complete), ctx, clientId, key, lockedUntil, statusCode, contentType, responseBody)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockIIdempotencyUseCase) DeleteExpired(ctx context.Context, batchSize int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockIIdempotencyUseCaseMockRecorder) DeleteExpired(ctx, batchSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockIIdempotencyUseCase)(nil).DeleteExpired), ctx, batchSize)
+}
+
+// Release mocks base method.
+func (m *MockIIdempotencyUseCase) Release(ctx context.Context, clientId, key string, lockedUntil time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, clientId, key, lockedUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockIIdempotencyUseCaseMockRecorder) Release(ctx, clientId, key, lockedUntil interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIIdempotencyUseCase)(nil).Release), ctx, clientId, key, lockedUntil)
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usecase/merchantusecase.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	model "github.com/pevin/pevin-golang-training-beginner/model"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIMerchantUseCase is a mock of IMerchantUseCase interface.
+type MockIMerchantUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockIMerchantUseCaseMockRecorder
+}
+
+// MockIMerchantUseCaseMockRecorder is the mock recorder for MockIMerchantUseCase.
+type MockIMerchantUseCaseMockRecorder struct {
+	mock *MockIMerchantUseCase
+}
+
+// NewMockIMerchantUseCase creates a new mock instance.
+func NewMockIMerchantUseCase(ctrl *gomock.Controller) *MockIMerchantUseCase {
+	mock := &MockIMerchantUseCase{ctrl: ctrl}
+	mock.recorder = &MockIMerchantUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIMerchantUseCase) EXPECT() *MockIMerchantUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m_2 *MockIMerchantUseCase) Create(ctx context.Context, m *model.Merchant) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "Create", ctx, m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockIMerchantUseCaseMockRecorder) Create(ctx, m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIMerchantUseCase)(nil).Create), ctx, m)
+}
+
+// Get mocks base method.
+func (m *MockIMerchantUseCase) Get(ctx context.Context, id string) (model.Merchant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(model.Merchant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIMerchantUseCaseMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIMerchantUseCase)(nil).Get), ctx, id)
+}
@@ -7,7 +7,6 @@ package mock_usecase
 import (
 	context "context"
 	model "github.com/pevin/pevin-golang-training-beginner/model"
-	http "net/http"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -50,6 +49,51 @@ func (mr *MockIPaymentCodeUseCaseMockRecorder) Create(ctx, p interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockIPaymentCodeUseCase)(nil).Create), ctx, p)
 }
 
+// CreateBatch mocks base method.
+func (m *MockIPaymentCodeUseCase) CreateBatch(ctx context.Context, paymentCodes []*model.PaymentCode, atomic bool) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, paymentCodes, atomic)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockIPaymentCodeUseCaseMockRecorder) CreateBatch(ctx, paymentCodes, atomic interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockIPaymentCodeUseCase)(nil).CreateBatch), ctx, paymentCodes, atomic)
+}
+
+// Deactivate mocks base method.
+func (m *MockIPaymentCodeUseCase) Deactivate(ctx context.Context, id string) (model.PaymentCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deactivate", ctx, id)
+	ret0, _ := ret[0].(model.PaymentCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deactivate indicates an expected call of Deactivate.
+func (mr *MockIPaymentCodeUseCaseMockRecorder) Deactivate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deactivate", reflect.TypeOf((*MockIPaymentCodeUseCase)(nil).Deactivate), ctx, id)
+}
+
+// ExpireDue mocks base method.
+func (m *MockIPaymentCodeUseCase) ExpireDue(ctx context.Context, batchSize int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireDue", ctx, batchSize)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireDue indicates an expected call of ExpireDue.
+func (mr *MockIPaymentCodeUseCaseMockRecorder) ExpireDue(ctx, batchSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireDue", reflect.TypeOf((*MockIPaymentCodeUseCase)(nil).ExpireDue), ctx, batchSize)
+}
+
 // Get mocks base method.
 func (m *MockIPaymentCodeUseCase) Get(ctx context.Context, id string) (model.PaymentCode, error) {
 	m.ctrl.T.Helper()
@@ -65,17 +109,47 @@ func (mr *MockIPaymentCodeUseCaseMockRecorder) Get(ctx, id interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIPaymentCodeUseCase)(nil).Get), ctx, id)
 }
 
-// InitFromRequest mocks base method.
-func (m *MockIPaymentCodeUseCase) InitFromRequest(r *http.Request) (model.PaymentCode, error) {
+// GetByPaymentCode mocks base method.
+func (m *MockIPaymentCodeUseCase) GetByPaymentCode(ctx context.Context, paymentCode string) (model.PaymentCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByPaymentCode", ctx, paymentCode)
+	ret0, _ := ret[0].(model.PaymentCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByPaymentCode indicates an expected call of GetByPaymentCode.
+func (mr *MockIPaymentCodeUseCaseMockRecorder) GetByPaymentCode(ctx, paymentCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByPaymentCode", reflect.TypeOf((*MockIPaymentCodeUseCase)(nil).GetByPaymentCode), ctx, paymentCode)
+}
+
+// List mocks base method.
+func (m *MockIPaymentCodeUseCase) List(ctx context.Context, filter model.PaymentCodeFilter) (model.PaymentCodeList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].(model.PaymentCodeList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockIPaymentCodeUseCaseMockRecorder) List(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIPaymentCodeUseCase)(nil).List), ctx, filter)
+}
+
+// Reactivate mocks base method.
+func (m *MockIPaymentCodeUseCase) Reactivate(ctx context.Context, id string) (model.PaymentCode, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InitFromRequest", r)
+	ret := m.ctrl.Call(m, "Reactivate", ctx, id)
 	ret0, _ := ret[0].(model.PaymentCode)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// InitFromRequest indicates an expected call of InitFromRequest.
-func (mr *MockIPaymentCodeUseCaseMockRecorder) InitFromRequest(r interface{}) *gomock.Call {
+// Reactivate indicates an expected call of Reactivate.
+func (mr *MockIPaymentCodeUseCaseMockRecorder) Reactivate(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitFromRequest", reflect.TypeOf((*MockIPaymentCodeUseCase)(nil).InitFromRequest), r)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reactivate", reflect.TypeOf((*MockIPaymentCodeUseCase)(nil).Reactivate), ctx, id)
 }
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usecase/paymentusecase.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	model "github.com/pevin/pevin-golang-training-beginner/model"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIPaymentUseCase is a mock of IPaymentUseCase interface.
+type MockIPaymentUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPaymentUseCaseMockRecorder
+}
+
+// MockIPaymentUseCaseMockRecorder is the mock recorder for MockIPaymentUseCase.
+type MockIPaymentUseCaseMockRecorder struct {
+	mock *MockIPaymentUseCase
+}
+
+// NewMockIPaymentUseCase creates a new mock instance.
+func NewMockIPaymentUseCase(ctrl *gomock.Controller) *MockIPaymentUseCase {
+	mock := &MockIPaymentUseCase{ctrl: ctrl}
+	mock.recorder = &MockIPaymentUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPaymentUseCase) EXPECT() *MockIPaymentUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Pay mocks base method.
+func (m *MockIPaymentUseCase) Pay(ctx context.Context, paymentCode string, p *model.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pay", ctx, paymentCode, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Pay indicates an expected call of Pay.
+func (mr *MockIPaymentUseCaseMockRecorder) Pay(ctx, paymentCode, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pay", reflect.TypeOf((*MockIPaymentUseCase)(nil).Pay), ctx, paymentCode, p)
+}
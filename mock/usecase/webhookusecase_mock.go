@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: usecase/webhookusecase.go
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	model "github.com/pevin/pevin-golang-training-beginner/model"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIWebhookUseCase is a mock of IWebhookUseCase interface.
+type MockIWebhookUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockIWebhookUseCaseMockRecorder
+}
+
+// MockIWebhookUseCaseMockRecorder is the mock recorder for MockIWebhookUseCase.
+type MockIWebhookUseCaseMockRecorder struct {
+	mock *MockIWebhookUseCase
+}
+
+// NewMockIWebhookUseCase creates a new mock instance.
+func NewMockIWebhookUseCase(ctrl *gomock.Controller) *MockIWebhookUseCase {
+	mock := &MockIWebhookUseCase{ctrl: ctrl}
+	mock.recorder = &MockIWebhookUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIWebhookUseCase) EXPECT() *MockIWebhookUseCaseMockRecorder {
+	return m.recorder
+}
+
+// CreateEndpoint mocks base method.
+func (m *MockIWebhookUseCase) CreateEndpoint(ctx context.Context, e *model.WebhookEndpoint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEndpoint", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEndpoint indicates an expected call of CreateEndpoint.
+func (mr *MockIWebhookUseCaseMockRecorder) CreateEndpoint(ctx, e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEndpoint", reflect.TypeOf((*MockIWebhookUseCase)(nil).CreateEndpoint), ctx, e)
+}
+
+// DeleteEndpoint mocks base method.
+func (m *MockIWebhookUseCase) DeleteEndpoint(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEndpoint", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEndpoint indicates an expected call of DeleteEndpoint.
+func (mr *MockIWebhookUseCaseMockRecorder) DeleteEndpoint(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEndpoint", reflect.TypeOf((*MockIWebhookUseCase)(nil).DeleteEndpoint), ctx, id)
+}
+
+// ListDeliveries mocks base method.
+func (m *MockIWebhookUseCase) ListDeliveries(ctx context.Context, filter model.WebhookDeliveryFilter) (model.WebhookDeliveryList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", ctx, filter)
+	ret0, _ := ret[0].(model.WebhookDeliveryList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockIWebhookUseCaseMockRecorder) ListDeliveries(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockIWebhookUseCase)(nil).ListDeliveries), ctx, filter)
+}
+
+// ListEndpoints mocks base method.
+func (m *MockIWebhookUseCase) ListEndpoints(ctx context.Context) (model.WebhookEndpointList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEndpoints", ctx)
+	ret0, _ := ret[0].(model.WebhookEndpointList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEndpoints indicates an expected call of ListEndpoints.
+func (mr *MockIWebhookUseCaseMockRecorder) ListEndpoints(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEndpoints", reflect.TypeOf((*MockIWebhookUseCase)(nil).ListEndpoints), ctx)
+}
+
+// Redeliver mocks base method.
+func (m *MockIWebhookUseCase) Redeliver(ctx context.Context, id string) (model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeliver", ctx, id)
+	ret0, _ := ret[0].(model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeliver indicates an expected call of Redeliver.
+func (mr *MockIWebhookUseCaseMockRecorder) Redeliver(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeliver", reflect.TypeOf((*MockIWebhookUseCase)(nil).Redeliver), ctx, id)
+}
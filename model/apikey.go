@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+)
+
+// An admin key can do everything, a write key can also read.
+const (
+	API_KEY_SCOPE_READ  = "read"
+	API_KEY_SCOPE_WRITE = "write"
+	API_KEY_SCOPE_ADMIN = "admin"
+)
+
+// APIKey authenticates the requests of a merchant. Only a hash of the key is
+// stored; Key holds the key itself only in the response that creates or
+// rotates it. Prefix is the public part of the key the hash is looked up by.
+//
+// SigningSecret signs requests made with the key, see package signature. It
+// is stored as is, since verifying a signature needs it, but like Key it is
+// only shown when the key is created or rotated. Keys with RequireSignature
+// are refused on unsigned requests.
+type APIKey struct {
+	Id               string     `json:"id"`
+	MerchantId       string     `json:"merchant_id"`
+	Name             string     `json:"name" validate:"required,max=255"`
+	Key              string     `json:"key,omitempty"`
+	Prefix           string     `json:"prefix"`
+	KeyHash          string     `json:"-"`
+	SigningSecret    string     `json:"signing_secret,omitempty"`
+	RequireSignature bool       `json:"require_signature"`
+	Scopes           []string   `json:"scopes" validate:"required,min=1,dive,oneof=read write admin"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyList struct {
+	Data []APIKey `json:"data"`
+}
+
+// HasScope reports whether the key grants scope, either directly or through a
+// broader scope.
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		switch {
+		case s == scope, s == API_KEY_SCOPE_ADMIN:
+			return true
+		case s == API_KEY_SCOPE_WRITE && scope == API_KEY_SCOPE_READ:
+			return true
+		}
+	}
+	return false
+}
+
+// WithoutSecrets returns the key without Key and SigningSecret, for the
+// responses that must not show them.
+func (k APIKey) WithoutSecrets() APIKey {
+	k.Key = ""
+	k.SigningSecret = ""
+	return k
+}
+
+// Revoked reports whether the key can no longer be used.
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
@@ -0,0 +1,52 @@
+package model
+
+import "testing"
+
+func TestAPIKey_HasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{
+			name:   "read-grants-read",
+			scopes: []string{API_KEY_SCOPE_READ},
+			scope:  API_KEY_SCOPE_READ,
+			want:   true,
+		},
+		{
+			name:   "read-does-not-grant-write",
+			scopes: []string{API_KEY_SCOPE_READ},
+			scope:  API_KEY_SCOPE_WRITE,
+		},
+		{
+			name:   "write-grants-read",
+			scopes: []string{API_KEY_SCOPE_WRITE},
+			scope:  API_KEY_SCOPE_READ,
+			want:   true,
+		},
+		{
+			name:   "write-does-not-grant-admin",
+			scopes: []string{API_KEY_SCOPE_READ, API_KEY_SCOPE_WRITE},
+			scope:  API_KEY_SCOPE_ADMIN,
+		},
+		{
+			name:   "admin-grants-write",
+			scopes: []string{API_KEY_SCOPE_ADMIN},
+			scope:  API_KEY_SCOPE_WRITE,
+			want:   true,
+		},
+		{
+			name:  "no-scopes",
+			scope: API_KEY_SCOPE_READ,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (APIKey{Scopes: tt.scopes}).HasScope(tt.scope); got != tt.want {
+				t.Errorf("APIKey.HasScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,16 @@
 package model
 
 type Error struct {
-	Message string `json:"error"`
+	Message string        `json:"error"`
+	Details []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail is one problem of an invalid request: the field, the rule it
+// breaks with the parameter of the rule, if any, and a message for people.
+// Rules that involve several fields have no Field.
+type ErrorDetail struct {
+	Field   string `json:"field,omitempty"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
 }
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	EVENT_TYPE_PAYMENT_CODE_CREATED        = "payment_code.created"
+	EVENT_TYPE_PAYMENT_CODE_STATUS_CHANGED = "payment_code.status_changed"
+	EVENT_TYPE_PAYMENT_CODE_EXPIRED        = "payment_code.expired"
+	EVENT_TYPE_PAYMENT_RECEIVED            = "payment.received"
+)
+
+// EVENT_VERSION is the version of the payload schema. It is bumped whenever a
+// payload changes in a way consumers have to handle.
+const EVENT_VERSION = 1
+
+// Event is the envelope every domain event is published in. Id is unique per
+// event and is used by consumers to deduplicate redelivered messages.
+type Event struct {
+	Id         string      `json:"id"`
+	Type       string      `json:"type"`
+	Version    int         `json:"version"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Payload    interface{} `json:"payload"`
+}
+
+// PaymentCodeEvent is the payload of payment_code.created and
+// payment_code.expired events.
+type PaymentCodeEvent struct {
+	PaymentCode PaymentCode `json:"payment_code"`
+}
+
+// PaymentCodeStatusChangedEvent is the payload of payment_code.status_changed
+// events.
+type PaymentCodeStatusChangedEvent struct {
+	PaymentCode    PaymentCode `json:"payment_code"`
+	PreviousStatus string      `json:"previous_status"`
+}
+
+// PaymentReceivedEvent is the payload of payment.received events.
+type PaymentReceivedEvent struct {
+	Payment Payment `json:"payment"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+)
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header so a retry of the same request gets the same
+// response. StatusCode is zero while the first request is still in flight.
+type IdempotencyKey struct {
+	ClientId     string
+	Key          string
+	RequestHash  string
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	LockedUntil  time.Time
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// Completed reports whether the response of the first request is stored.
+func (k IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+)
+
+// Merchant owns payment codes. Every request is made on behalf of one
+// merchant and only sees its payment codes.
+type Merchant struct {
+	Id        string    `json:"id" validate:"omitempty,max=255"`
+	Name      string    `json:"name" validate:"required,max=255"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+// OutboxMessage is a message waiting in the outbox table to be delivered to
+// the message broker.
+type OutboxMessage struct {
+	Id            string
+	Topic         string
+	Key           string
+	Payload       []byte
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	PublishedAt   time.Time
+	CreatedAt     time.Time
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+)
+
+// Payment records an amount paid against a payment code. Amount is in the
+// minor unit of Currency.
+type Payment struct {
+	Id            string    `json:"id"`
+	MerchantId    string    `json:"merchant_id"`
+	PaymentCodeId string    `json:"payment_code_id"`
+	PaymentCode   string    `json:"payment_code"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"created_at"`
+}
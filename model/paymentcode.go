@@ -1,6 +1,9 @@
 package model
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -8,14 +11,146 @@ const (
 	PAYMENT_CODE_STATUS_ACTIVE   = "ACTIVE"
 	PAYMENT_CODE_STATUS_INACTIVE = "INACTIVE"
 	PAYMENT_CODE_STATUS_EXPIRED  = "EXPIRED"
+	PAYMENT_CODE_STATUS_PAID     = "PAID"
+)
+
+// A FIXED payment code must be paid exactly Amount. An OPEN payment code
+// accepts any amount within the optional MinAmount and MaxAmount bounds.
+const (
+	AMOUNT_TYPE_FIXED = "FIXED"
+	AMOUNT_TYPE_OPEN  = "OPEN"
+)
+
+// A SINGLE payment code is PAID after its first payment. A MULTI payment code
+// accepts payments until it reaches MaxUsage payments or MaxTotalAmount paid,
+// when either is set.
+const (
+	USAGE_TYPE_SINGLE = "SINGLE"
+	USAGE_TYPE_MULTI  = "MULTI"
 )
 
 type PaymentCode struct {
 	Id             string    `json:"id"`
-	PaymentCode    string    `json:"payment_code" validate:"required"`
-	Name           string    `json:"name" validate:"required"`
+	MerchantId     string    `json:"merchant_id"`
+	PaymentCode    string    `json:"payment_code" validate:"omitempty,max=255,payment_code"`
+	Name           string    `json:"name" validate:"required,max=255"`
 	Status         string    `json:"status"`
-	ExpirationDate time.Time `json:"expiration_date"`
-	CreatedAt      time.Time `json:"-"`
-	UpdatedAt      time.Time `json:"-"`
+	AmountType     string    `json:"amount_type" validate:"required,oneof=FIXED OPEN"`
+	Amount         int64     `json:"amount,omitempty"`
+	MinAmount      int64     `json:"min_amount,omitempty"`
+	MaxAmount      int64     `json:"max_amount,omitempty"`
+	Currency       string    `json:"currency" validate:"required,len=3,alpha"`
+	UsageType      string    `json:"usage_type" validate:"required,oneof=SINGLE MULTI"`
+	MaxUsage       int       `json:"max_usage,omitempty"`
+	MaxTotalAmount int64     `json:"max_total_amount,omitempty"`
+	UsageCount     int       `json:"usage_count"`
+	TotalPaid      int64     `json:"total_paid"`
+	ExpirationDate time.Time `json:"expiration_date" validate:"omitempty,future"`
+	// TTLSeconds sets the expiration date relative to the creation time. It
+	// is only read from create requests and cannot be combined with
+	// ExpirationDate.
+	TTLSeconds int64     `json:"ttl_seconds,omitempty" validate:"omitempty,gt=0"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ValidateAmount checks the amount fields against AmountType. Amounts are in
+// the minor unit of Currency, and zero means the field is not set.
+func (p PaymentCode) ValidateAmount() (err error) {
+	if p.Currency != strings.ToUpper(p.Currency) {
+		return errors.New("field 'currency' must be an upper-case ISO 4217 code")
+	}
+
+	if p.Amount < 0 || p.MinAmount < 0 || p.MaxAmount < 0 {
+		return errors.New("fields 'amount', 'min_amount' and 'max_amount' must not be negative")
+	}
+
+	switch p.AmountType {
+	case AMOUNT_TYPE_FIXED:
+		if p.Amount == 0 {
+			return errors.New("field 'amount' is required when 'amount_type' is FIXED")
+		}
+		if p.MinAmount != 0 || p.MaxAmount != 0 {
+			return errors.New("fields 'min_amount' and 'max_amount' are not allowed when 'amount_type' is FIXED")
+		}
+	case AMOUNT_TYPE_OPEN:
+		if p.Amount != 0 {
+			return errors.New("field 'amount' is not allowed when 'amount_type' is OPEN")
+		}
+		if p.MaxAmount != 0 && p.MinAmount > p.MaxAmount {
+			return errors.New("field 'min_amount' must not be greater than 'max_amount'")
+		}
+	}
+
+	return
+}
+
+// ValidateUsage checks the usage limits against UsageType. Zero means the
+// limit is not set.
+func (p PaymentCode) ValidateUsage() (err error) {
+	if p.MaxUsage < 0 || p.MaxTotalAmount < 0 {
+		return errors.New("fields 'max_usage' and 'max_total_amount' must not be negative")
+	}
+
+	if p.UsageType == USAGE_TYPE_SINGLE && (p.MaxUsage != 0 || p.MaxTotalAmount != 0) {
+		return errors.New("fields 'max_usage' and 'max_total_amount' are not allowed when 'usage_type' is SINGLE")
+	}
+
+	return
+}
+
+// IsPayable reports whether the payment code accepts payments at now. Only
+// ACTIVE codes that have not reached their expiration date do.
+func (p PaymentCode) IsPayable(now time.Time) bool {
+	if p.Status != PAYMENT_CODE_STATUS_ACTIVE {
+		return false
+	}
+	return p.ExpirationDate.IsZero() || p.ExpirationDate.After(now)
+}
+
+// ValidatePayment checks that a payment of amount in currency satisfies the
+// amount rules of the payment code.
+func (p PaymentCode) ValidatePayment(amount int64, currency string) (err error) {
+	if amount <= 0 {
+		return errors.New("field 'amount' must be greater than 0")
+	}
+
+	if currency != p.Currency {
+		return fmt.Errorf("field 'currency' must be %s", p.Currency)
+	}
+
+	switch {
+	case p.AmountType == AMOUNT_TYPE_FIXED && amount != p.Amount:
+		return fmt.Errorf("field 'amount' must be %d", p.Amount)
+	case p.MinAmount != 0 && amount < p.MinAmount:
+		return fmt.Errorf("field 'amount' must be at least %d", p.MinAmount)
+	case p.MaxAmount != 0 && amount > p.MaxAmount:
+		return fmt.Errorf("field 'amount' must be at most %d", p.MaxAmount)
+	case p.MaxTotalAmount != 0 && p.TotalPaid+amount > p.MaxTotalAmount:
+		return fmt.Errorf("field 'amount' must be at most %d, the amount left to pay", p.MaxTotalAmount-p.TotalPaid)
+	}
+
+	return
+}
+
+type PaymentCodeStatusUpdate struct {
+	Status string `json:"status" validate:"required,oneof=ACTIVE INACTIVE"`
+}
+
+// paymentCodeStatusTransitions lists the statuses a payment code may move to
+// from its current status. EXPIRED and PAID are terminal.
+var paymentCodeStatusTransitions = map[string][]string{
+	PAYMENT_CODE_STATUS_ACTIVE:   {PAYMENT_CODE_STATUS_INACTIVE, PAYMENT_CODE_STATUS_EXPIRED, PAYMENT_CODE_STATUS_PAID},
+	PAYMENT_CODE_STATUS_INACTIVE: {PAYMENT_CODE_STATUS_ACTIVE, PAYMENT_CODE_STATUS_EXPIRED},
+}
+
+// CanTransitionTo reports whether the payment code may move from its current
+// status to the given one. Transitions to the current status are rejected.
+func (p PaymentCode) CanTransitionTo(status string) bool {
+	for _, allowed := range paymentCodeStatusTransitions[p.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
 }
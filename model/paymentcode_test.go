@@ -0,0 +1,117 @@
+package model
+
+import "testing"
+
+func TestPaymentCode_ValidateAmount(t *testing.T) {
+	tests := []struct {
+		name        string
+		paymentCode PaymentCode
+		wantErr     bool
+	}{
+		{
+			name:        "fixed-amount",
+			paymentCode: PaymentCode{AmountType: AMOUNT_TYPE_FIXED, Amount: 150000},
+		},
+		{
+			name:        "fixed-without-amount",
+			paymentCode: PaymentCode{AmountType: AMOUNT_TYPE_FIXED},
+			wantErr:     true,
+		},
+		{
+			name:        "fixed-with-bounds",
+			paymentCode: PaymentCode{AmountType: AMOUNT_TYPE_FIXED, Amount: 150000, MaxAmount: 200000},
+			wantErr:     true,
+		},
+		{
+			name:        "open-without-bounds",
+			paymentCode: PaymentCode{AmountType: AMOUNT_TYPE_OPEN},
+		},
+		{
+			name:        "open-with-bounds",
+			paymentCode: PaymentCode{AmountType: AMOUNT_TYPE_OPEN, MinAmount: 10000, MaxAmount: 500000},
+		},
+		{
+			name:        "open-with-min-only",
+			paymentCode: PaymentCode{AmountType: AMOUNT_TYPE_OPEN, MinAmount: 10000},
+		},
+		{
+			name:        "open-with-amount",
+			paymentCode: PaymentCode{AmountType: AMOUNT_TYPE_OPEN, Amount: 150000},
+			wantErr:     true,
+		},
+		{
+			name:        "open-with-min-above-max",
+			paymentCode: PaymentCode{AmountType: AMOUNT_TYPE_OPEN, MinAmount: 20000, MaxAmount: 10000},
+			wantErr:     true,
+		},
+		{
+			name:        "lower-case-currency",
+			paymentCode: PaymentCode{AmountType: AMOUNT_TYPE_FIXED, Amount: 150000, Currency: "idr"},
+			wantErr:     true,
+		},
+		{
+			name:        "negative-amount",
+			paymentCode: PaymentCode{AmountType: AMOUNT_TYPE_OPEN, MinAmount: -1},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.paymentCode.ValidateAmount(); (err != nil) != tt.wantErr {
+				t.Errorf("PaymentCode.ValidateAmount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPaymentCode_ValidatePayment(t *testing.T) {
+	fixed := PaymentCode{AmountType: AMOUNT_TYPE_FIXED, Amount: 150000, Currency: "IDR"}
+	open := PaymentCode{AmountType: AMOUNT_TYPE_OPEN, MinAmount: 10000, MaxAmount: 500000, Currency: "IDR"}
+
+	tests := []struct {
+		name        string
+		paymentCode PaymentCode
+		amount      int64
+		currency    string
+		wantErr     bool
+	}{
+		{"fixed-exact-amount", fixed, 150000, "IDR", false},
+		{"fixed-other-amount", fixed, 100000, "IDR", true},
+		{"other-currency", fixed, 150000, "USD", true},
+		{"zero-amount", open, 0, "IDR", true},
+		{"open-within-bounds", open, 10000, "IDR", false},
+		{"open-below-min", open, 9999, "IDR", true},
+		{"open-above-max", open, 500001, "IDR", true},
+		{"open-without-bounds", PaymentCode{AmountType: AMOUNT_TYPE_OPEN, Currency: "IDR"}, 1, "IDR", false},
+		{"within-total-left", PaymentCode{AmountType: AMOUNT_TYPE_OPEN, Currency: "IDR", MaxTotalAmount: 100000, TotalPaid: 60000}, 40000, "IDR", false},
+		{"above-total-left", PaymentCode{AmountType: AMOUNT_TYPE_OPEN, Currency: "IDR", MaxTotalAmount: 100000, TotalPaid: 60000}, 40001, "IDR", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.paymentCode.ValidatePayment(tt.amount, tt.currency); (err != nil) != tt.wantErr {
+				t.Errorf("PaymentCode.ValidatePayment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPaymentCode_ValidateUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		paymentCode PaymentCode
+		wantErr     bool
+	}{
+		{"single-use", PaymentCode{UsageType: USAGE_TYPE_SINGLE}, false},
+		{"single-use-with-limits", PaymentCode{UsageType: USAGE_TYPE_SINGLE, MaxUsage: 2}, true},
+		{"multi-use", PaymentCode{UsageType: USAGE_TYPE_MULTI}, false},
+		{"multi-use-with-limits", PaymentCode{UsageType: USAGE_TYPE_MULTI, MaxUsage: 5, MaxTotalAmount: 500000}, false},
+		{"negative-limit", PaymentCode{UsageType: USAGE_TYPE_MULTI, MaxUsage: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.paymentCode.ValidateUsage(); (err != nil) != tt.wantErr {
+				t.Errorf("PaymentCode.ValidateUsage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
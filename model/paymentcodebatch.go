@@ -0,0 +1,22 @@
+package model
+
+const (
+	BATCH_MODE_ATOMIC  = "atomic"
+	BATCH_MODE_PARTIAL = "partial"
+)
+
+// PaymentCodeBatchResult is the outcome of one payment code of a batch create
+// request. Index is its position in the request.
+type PaymentCodeBatchResult struct {
+	Index       int           `json:"index"`
+	Status      int           `json:"status"`
+	PaymentCode *PaymentCode  `json:"payment_code,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Details     []ErrorDetail `json:"details,omitempty"`
+}
+
+type PaymentCodeBatch struct {
+	Created int                      `json:"created"`
+	Failed  int                      `json:"failed"`
+	Results []PaymentCodeBatchResult `json:"results"`
+}
@@ -0,0 +1,16 @@
+package model
+
+// PaymentCodeImportError is a row of an import that was not created. Row
+// counts data rows from 1.
+type PaymentCodeImportError struct {
+	Row     int           `json:"row"`
+	Status  int           `json:"status"`
+	Error   string        `json:"error"`
+	Details []ErrorDetail `json:"details,omitempty"`
+}
+
+type PaymentCodeImport struct {
+	Created int                      `json:"created"`
+	Failed  int                      `json:"failed"`
+	Errors  []PaymentCodeImportError `json:"errors"`
+}
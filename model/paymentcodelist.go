@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	SORT_ORDER_ASC  = "asc"
+	SORT_ORDER_DESC = "desc"
+)
+
+// PaymentCodeFilter narrows down and pages through payment codes. Zero values
+// mean the filter is not applied.
+type PaymentCodeFilter struct {
+	// MerchantId is always applied, an empty one matches nothing.
+	MerchantId        string
+	Status            string
+	Name              string
+	PaymentCodePrefix string
+	CreatedFrom       time.Time
+	CreatedTo         time.Time
+	ExpirationFrom    time.Time
+	ExpirationTo      time.Time
+	SortOrder         string
+	Cursor            string
+	Limit             int
+}
+
+type PaymentCodeList struct {
+	Data       []PaymentCode `json:"data"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
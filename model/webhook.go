@@ -0,0 +1,69 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// A delivery is PENDING until the endpoint accepts it, and DEAD once it has
+// failed too many times to be retried automatically.
+const (
+	WEBHOOK_DELIVERY_STATUS_PENDING   = "PENDING"
+	WEBHOOK_DELIVERY_STATUS_DELIVERED = "DELIVERED"
+	WEBHOOK_DELIVERY_STATUS_DEAD      = "DEAD"
+)
+
+// WebhookEndpoint is a URL of a merchant that is sent the events of the
+// given types. SigningSecret signs every delivery, see package signature; it
+// is only shown in the response that creates the endpoint.
+type WebhookEndpoint struct {
+	Id            string    `json:"id"`
+	MerchantId    string    `json:"merchant_id"`
+	URL           string    `json:"url" validate:"required,max=2048,webhook_url"`
+	EventTypes    []string  `json:"event_types" validate:"required,min=1,dive,oneof=payment_code.created payment_code.status_changed payment_code.expired payment.received"`
+	SigningSecret string    `json:"signing_secret,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type WebhookEndpointList struct {
+	Data []WebhookEndpoint `json:"data"`
+}
+
+// WebhookDelivery is an event queued for an endpoint. Payload is the event
+// envelope that is POSTed as is. URL and SigningSecret are those of the
+// endpoint, read along with the delivery.
+type WebhookDelivery struct {
+	Id             string          `json:"id"`
+	MerchantId     string          `json:"merchant_id"`
+	EndpointId     string          `json:"endpoint_id"`
+	URL            string          `json:"url"`
+	SigningSecret  string          `json:"-"`
+	EventId        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// WebhookDeliveryFilter narrows down and pages through deliveries, newest
+// first. Zero values mean the filter is not applied.
+type WebhookDeliveryFilter struct {
+	// MerchantId is always applied, an empty one matches nothing.
+	MerchantId string
+	EndpointId string
+	Status     string
+	Cursor     string
+	Limit      int
+}
+
+type WebhookDeliveryList struct {
+	Data       []WebhookDelivery `json:"data"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
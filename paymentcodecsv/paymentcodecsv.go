@@ -0,0 +1,287 @@
+// Package paymentcodecsv reads and writes payment codes as CSV. The header row
+// names the columns, using the JSON field names of model.PaymentCode.
+// payment_code and name cells that a spreadsheet would run as a formula are
+// written with a leading ', and read back without it.
+package paymentcodecsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+)
+
+type column struct {
+	name string
+	get  func(p model.PaymentCode) string
+	// set is nil for the columns that are only exported, such as id and
+	// status, since the service sets them on create.
+	set func(p *model.PaymentCode, value string) (err error)
+}
+
+var columns = []column{
+	{"id", func(p model.PaymentCode) string { return p.Id }, nil},
+	{"payment_code", func(p model.PaymentCode) string { return escapeFormula(p.PaymentCode) }, setText(func(p *model.PaymentCode) *string { return &p.PaymentCode })},
+	{"name", func(p model.PaymentCode) string { return escapeFormula(p.Name) }, setText(func(p *model.PaymentCode) *string { return &p.Name })},
+	{"status", func(p model.PaymentCode) string { return p.Status }, nil},
+	{"amount_type", func(p model.PaymentCode) string { return p.AmountType }, setString(func(p *model.PaymentCode) *string { return &p.AmountType })},
+	{"amount", func(p model.PaymentCode) string { return formatInt(p.Amount) }, setInt(func(p *model.PaymentCode) *int64 { return &p.Amount })},
+	{"min_amount", func(p model.PaymentCode) string { return formatInt(p.MinAmount) }, setInt(func(p *model.PaymentCode) *int64 { return &p.MinAmount })},
+	{"max_amount", func(p model.PaymentCode) string { return formatInt(p.MaxAmount) }, setInt(func(p *model.PaymentCode) *int64 { return &p.MaxAmount })},
+	{"currency", func(p model.PaymentCode) string { return p.Currency }, setString(func(p *model.PaymentCode) *string { return &p.Currency })},
+	{"usage_type", func(p model.PaymentCode) string { return p.UsageType }, setString(func(p *model.PaymentCode) *string { return &p.UsageType })},
+	{"max_usage", func(p model.PaymentCode) string { return formatInt(int64(p.MaxUsage)) }, func(p *model.PaymentCode, value string) (err error) {
+		var maxUsage int64
+		err = parseInt(value, &maxUsage)
+		p.MaxUsage = int(maxUsage)
+		return
+	}},
+	{"max_total_amount", func(p model.PaymentCode) string { return formatInt(p.MaxTotalAmount) }, setInt(func(p *model.PaymentCode) *int64 { return &p.MaxTotalAmount })},
+	{"usage_count", func(p model.PaymentCode) string { return strconv.Itoa(p.UsageCount) }, nil},
+	{"total_paid", func(p model.PaymentCode) string { return strconv.FormatInt(p.TotalPaid, 10) }, nil},
+	{"expiration_date", func(p model.PaymentCode) string { return formatTime(p.ExpirationDate) }, setTime(func(p *model.PaymentCode) *time.Time { return &p.ExpirationDate })},
+	{"ttl_seconds", nil, setInt(func(p *model.PaymentCode) *int64 { return &p.TTLSeconds })},
+	{"created_at", func(p model.PaymentCode) string { return formatTime(p.CreatedAt) }, nil},
+	{"updated_at", func(p model.PaymentCode) string { return formatTime(p.UpdatedAt) }, nil},
+}
+
+func setString(field func(p *model.PaymentCode) *string) func(p *model.PaymentCode, value string) error {
+	return func(p *model.PaymentCode, value string) error {
+		*field(p) = value
+		return nil
+	}
+}
+
+// setText reads a column written with escapeFormula.
+func setText(field func(p *model.PaymentCode) *string) func(p *model.PaymentCode, value string) error {
+	return func(p *model.PaymentCode, value string) error {
+		*field(p) = unescapeFormula(value)
+		return nil
+	}
+}
+
+// escapeFormula prefixes free text that a spreadsheet would run as a formula
+// with ', which spreadsheets show as text. Values that already start with '
+// before such text get another one, so unescapeFormula restores every value.
+func escapeFormula(value string) string {
+	if isFormula(value) {
+		return "'" + value
+	}
+	return value
+}
+
+func unescapeFormula(value string) string {
+	if strings.HasPrefix(value, "'") && isFormula(value) {
+		return value[1:]
+	}
+	return value
+}
+
+func isFormula(value string) bool {
+	value = strings.TrimLeft(value, "'")
+	return value != "" && strings.ContainsAny(value[:1], "=+-@\t\r")
+}
+
+func setInt(field func(p *model.PaymentCode) *int64) func(p *model.PaymentCode, value string) error {
+	return func(p *model.PaymentCode, value string) error {
+		return parseInt(value, field(p))
+	}
+}
+
+// parseInt leaves dst unset when value is empty.
+func parseInt(value string, dst *int64) (err error) {
+	if value == "" {
+		return
+	}
+	*dst, err = strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		err = errors.New("must be an integer")
+	}
+	return
+}
+
+func setTime(field func(p *model.PaymentCode) *time.Time) func(p *model.PaymentCode, value string) error {
+	return func(p *model.PaymentCode, value string) (err error) {
+		if value == "" {
+			return
+		}
+		*field(p), err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			err = errors.New("must be an RFC 3339 timestamp")
+		}
+		return
+	}
+}
+
+// formatInt leaves unset (zero) amounts empty.
+func formatInt(value int64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+func formatTime(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return value.UTC().Format(time.RFC3339)
+}
+
+// RowError is a row that could not be read. Reading can go on with the next
+// row.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads payment codes from CSV. Columns can appear in any order and
+// optional ones can be left out; the columns that are only exported are
+// ignored, so an exported file can be imported again.
+type Reader struct {
+	csv     *csv.Reader
+	columns []column
+	row     int
+}
+
+// NewReader reads the header row of r.
+func NewReader(r io.Reader) (reader *Reader, err error) {
+	reader = &Reader{csv: csv.NewReader(r)}
+	reader.csv.FieldsPerRecord = -1
+	reader.csv.TrimLeadingSpace = true
+
+	header, err := reader.csv.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv has no header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		c, ok := columnByName(name)
+		if !ok {
+			return nil, fmt.Errorf("csv header: unknown column '%s'", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("csv header: duplicate column '%s'", name)
+		}
+		seen[name] = true
+		reader.columns = append(reader.columns, c)
+	}
+
+	return
+}
+
+func columnByName(name string) (c column, ok bool) {
+	for _, c = range columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return
+}
+
+// Read returns the next payment code and its row number, counting data rows
+// from 1. It returns io.EOF after the last row and a *RowError for a row that
+// is malformed.
+func (r *Reader) Read() (paymentCode model.PaymentCode, row int, err error) {
+	record, err := r.csv.Read()
+	if err == io.EOF {
+		return
+	}
+
+	r.row++
+	row = r.row
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		err = &RowError{Row: row, Err: parseErr.Err}
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if len(record) != len(r.columns) {
+		err = &RowError{Row: row, Err: fmt.Errorf("has %d fields, the header has %d", len(record), len(r.columns))}
+		return
+	}
+
+	for i, c := range r.columns {
+		if c.set == nil {
+			continue
+		}
+		if setErr := c.set(&paymentCode, strings.TrimSpace(record[i])); setErr != nil {
+			err = &RowError{Row: row, Err: fmt.Errorf("column '%s' %v", c.name, setErr)}
+			return
+		}
+	}
+
+	return
+}
+
+// Writer writes payment codes as CSV, starting with the header row.
+type Writer struct {
+	csv         *csv.Writer
+	wroteHeader bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{csv: csv.NewWriter(w)}
+}
+
+func (w *Writer) Write(paymentCode model.PaymentCode) (err error) {
+	if !w.wroteHeader {
+		err = w.WriteHeader()
+		if err != nil {
+			return
+		}
+	}
+
+	record := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if c.get == nil {
+			continue
+		}
+		record = append(record, c.get(paymentCode))
+	}
+	return w.csv.Write(record)
+}
+
+// WriteHeader writes the header row. Write calls it for the first payment
+// code, so it is only needed to write a file without any.
+func (w *Writer) WriteHeader() (err error) {
+	w.wroteHeader = true
+
+	header := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if c.get == nil {
+			continue
+		}
+		header = append(header, c.name)
+	}
+	return w.csv.Write(header)
+}
+
+// Flush writes the buffered rows and reports any error of the underlying
+// writer.
+func (w *Writer) Flush() (err error) {
+	w.csv.Flush()
+	return w.csv.Error()
+}
@@ -0,0 +1,180 @@
+package paymentcodecsv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+)
+
+func TestWriterReader(t *testing.T) {
+	now := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	paymentCode := model.PaymentCode{
+		Id:             "test-id",
+		PaymentCode:    "test-payment-code",
+		Name:           "test, name",
+		Status:         model.PAYMENT_CODE_STATUS_ACTIVE,
+		AmountType:     model.AMOUNT_TYPE_OPEN,
+		MinAmount:      10000,
+		MaxAmount:      500000,
+		Currency:       "IDR",
+		UsageType:      model.USAGE_TYPE_MULTI,
+		MaxUsage:       3,
+		MaxTotalAmount: 900000,
+		UsageCount:     1,
+		TotalPaid:      10000,
+		ExpirationDate: now.Add(time.Hour),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.Write(paymentCode); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantCSV := "id,payment_code,name,status,amount_type,amount,min_amount,max_amount,currency,usage_type,max_usage,max_total_amount,usage_count,total_paid,expiration_date,created_at,updated_at\n" +
+		"test-id,test-payment-code,\"test, name\",ACTIVE,OPEN,,10000,500000,IDR,MULTI,3,900000,1,10000,2021-05-01T11:00:00Z,2021-05-01T10:00:00Z,2021-05-01T10:00:00Z\n"
+	if buf.String() != wantCSV {
+		t.Fatalf("Writer.Write() = %q, want %q", buf.String(), wantCSV)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, row, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the columns set by the service are not imported
+	want := paymentCode
+	want.Id, want.Status, want.UsageCount, want.TotalPaid = "", "", 0, 0
+	want.CreatedAt, want.UpdatedAt = time.Time{}, time.Time{}
+	if !reflect.DeepEqual(got, want) || row != 1 {
+		t.Errorf("Reader.Read() = %+v, %v, want %+v, 1", got, row, want)
+	}
+
+	if _, _, err = r.Read(); err != io.EOF {
+		t.Errorf("Reader.Read() error = %v, want io.EOF", err)
+	}
+}
+
+func TestWriterReader_formulas(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantCell string
+	}{
+		{name: "=HYPERLINK(\"http://example.com\")", wantCell: "'=HYPERLINK(\"http://example.com\")"},
+		{name: "+1", wantCell: "'+1"},
+		{name: "-1", wantCell: "'-1"},
+		{name: "@SUM(A1)", wantCell: "'@SUM(A1)"},
+		{name: "'=1", wantCell: "''=1"},
+		{name: "it's fine", wantCell: "it's fine"},
+		{name: "'quoted'", wantCell: "'quoted'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf)
+			if err := w.Write(model.PaymentCode{PaymentCode: tt.name, Name: tt.name}); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			records, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := records[1][1:3]; got[0] != tt.wantCell || got[1] != tt.wantCell {
+				t.Errorf("Writer.Write() payment_code, name = %q, want %q", got, tt.wantCell)
+			}
+
+			r, err := NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := r.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.PaymentCode != tt.name || got.Name != tt.name {
+				t.Errorf("Reader.Read() payment_code, name = %q, %q, want %q", got.PaymentCode, got.Name, tt.name)
+			}
+		})
+	}
+}
+
+func TestReader_Read(t *testing.T) {
+	input := "name,amount_type,amount,ttl_seconds\n" +
+		"first,FIXED,150000,3600\n" +
+		"second,FIXED,abc,\n" +
+		"third,FIXED\n" +
+		"fourth,\"FIXED,150000,\n" +
+		"fifth,OPEN,,\n"
+
+	r, err := NewReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	var errorRows []int
+	for {
+		paymentCode, row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			errorRows = append(errorRows, row)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, paymentCode.Name)
+	}
+
+	if !reflect.DeepEqual(names, []string{"first"}) {
+		t.Errorf("Reader.Read() names = %v, want [first]", names)
+	}
+	if !reflect.DeepEqual(errorRows, []int{2, 3, 4}) {
+		t.Errorf("Reader.Read() error rows = %v, want [2 3 4]", errorRows)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"valid", "payment_code, Name\n", false},
+		{"empty", "", true},
+		{"unknown-column", "name,color\n", true},
+		{"duplicate-column", "name,name\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
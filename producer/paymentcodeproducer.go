@@ -1,16 +1,143 @@
 package producer
 
 import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
 	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/google/uuid"
 )
 
+// IPaymentCodeMessageProducer publishes the domain events of a payment code.
+// Every event is wrapped in a model.Event envelope.
 type IPaymentCodeMessageProducer interface {
-	Produce(p *model.PaymentCode) (err error)
+	ProduceCreated(ctx context.Context, p *model.PaymentCode) (err error)
+	ProduceExpired(ctx context.Context, p *model.PaymentCode) (err error)
+	ProduceStatusChanged(ctx context.Context, p *model.PaymentCode, previousStatus string) (err error)
+	ProducePaymentReceived(ctx context.Context, p *model.Payment) (err error)
+	Close() (err error)
+}
+
+// PaymentCodeMessageProducer writes events to the outbox, and queues a
+// webhook delivery for every endpoint of the merchant subscribed to the
+// event. Calling it within a repository transaction commits both together
+// with the change they describe; the outbox relay and the webhook dispatcher
+// then deliver them. Webhooks are queued even when the producer is disabled.
+type PaymentCodeMessageProducer struct {
+	Config   config.Producer
+	Outbox   repository.IOutboxRepository
+	Webhooks repository.IWebhookRepository
 }
 
-type PaymentCodeMessageProducer struct{}
+func (r PaymentCodeMessageProducer) ProduceCreated(ctx context.Context, p *model.PaymentCode) (err error) {
+	return r.produce(ctx, p.MerchantId, p.Id, model.EVENT_TYPE_PAYMENT_CODE_CREATED, model.PaymentCodeEvent{PaymentCode: *p})
+}
+
+func (r PaymentCodeMessageProducer) ProduceExpired(ctx context.Context, p *model.PaymentCode) (err error) {
+	return r.produce(ctx, p.MerchantId, p.Id, model.EVENT_TYPE_PAYMENT_CODE_EXPIRED, model.PaymentCodeEvent{PaymentCode: *p})
+}
+
+func (r PaymentCodeMessageProducer) ProduceStatusChanged(ctx context.Context, p *model.PaymentCode, previousStatus string) (err error) {
+	return r.produce(ctx, p.MerchantId, p.Id, model.EVENT_TYPE_PAYMENT_CODE_STATUS_CHANGED, model.PaymentCodeStatusChangedEvent{
+		PaymentCode:    *p,
+		PreviousStatus: previousStatus,
+	})
+}
+
+// ProducePaymentReceived is keyed by the payment code id, like the other
+// events of the code. The relay may publish them out of order, e.g. after a
+// retry, so consumers order them by occurred_at.
+func (r PaymentCodeMessageProducer) ProducePaymentReceived(ctx context.Context, p *model.Payment) (err error) {
+	return r.produce(ctx, p.MerchantId, p.PaymentCodeId, model.EVENT_TYPE_PAYMENT_RECEIVED, model.PaymentReceivedEvent{Payment: *p})
+}
+
+// Close releases the producer. Events already in the outbox are published
+// by the relay, so there is nothing to flush.
+func (r PaymentCodeMessageProducer) Close() (err error) {
+	return
+}
+
+// produce wraps payload in an event envelope and writes it to the outbox and
+// the webhook queue. The event id doubles as the message id so consumers can
+// deduplicate on either.
+func (r PaymentCodeMessageProducer) produce(ctx context.Context, merchantID string, key string, eventType string, payload interface{}) (err error) {
+	if !r.Config.Enabled && r.Webhooks == nil {
+		return
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	body, err := json.Marshal(model.Event{
+		Id:         id.String(),
+		Type:       eventType,
+		Version:    model.EVENT_VERSION,
+		OccurredAt: now,
+		Payload:    payload,
+	})
+	if err != nil {
+		return
+	}
+
+	if r.Config.Enabled {
+		err = r.Outbox.Create(ctx, &model.OutboxMessage{
+			Id:            id.String(),
+			Topic:         r.Config.Topic,
+			Key:           key,
+			Payload:       body,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	if r.Webhooks != nil {
+		err = r.queueWebhooks(ctx, merchantID, id.String(), eventType, body, now)
+	}
+
+	return
+}
+
+// queueWebhooks queues a delivery of the event for every endpoint of the
+// merchant subscribed to eventType.
+func (r PaymentCodeMessageProducer) queueWebhooks(ctx context.Context, merchantID string, eventID string, eventType string, body []byte, now time.Time) (err error) {
+	endpoints, err := r.Webhooks.ListSubscribedEndpoints(ctx, merchantID, eventType)
+	if err != nil {
+		return
+	}
+
+	for _, e := range endpoints {
+		var id uuid.UUID
+		id, err = uuid.NewRandom()
+		if err != nil {
+			return
+		}
+
+		err = r.Webhooks.CreateDelivery(ctx, &model.WebhookDelivery{
+			Id:            id.String(),
+			MerchantId:    merchantID,
+			EndpointId:    e.Id,
+			EventId:       eventID,
+			EventType:     eventType,
+			Payload:       body,
+			Status:        model.WEBHOOK_DELIVERY_STATUS_PENDING,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		if err != nil {
+			return
+		}
+	}
 
-func (r PaymentCodeMessageProducer) Produce(p *model.PaymentCode) (err error) {
-	// this is a fake message producer
 	return
 }
@@ -0,0 +1,191 @@
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	mock_repository "github.com/pevin/pevin-golang-training-beginner/mock/repository"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestPaymentCodeMessageProducer_ProduceCreated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	err := errors.New("Mock Error")
+
+	paymentCode := model.PaymentCode{
+		Id:          "test-id",
+		PaymentCode: "test-payment-code",
+		Name:        "test name",
+		Status:      model.PAYMENT_CODE_STATUS_ACTIVE,
+	}
+
+	tests := []struct {
+		name    string
+		config  config.Producer
+		outbox  repository.IOutboxRepository
+		wantErr bool
+	}{
+		{
+			name:   "write-to-outbox",
+			config: config.Producer{Enabled: true, Topic: "payment-codes"},
+			outbox: func() repository.IOutboxRepository {
+				outbox := mock_repository.NewMockIOutboxRepository(ctrl)
+				outbox.
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, m *model.OutboxMessage) error {
+						if m.Id == "" || m.Topic != "payment-codes" || m.Key != paymentCode.Id {
+							t.Errorf("unexpected outbox message %+v", m)
+						}
+
+						var event struct {
+							Id      string
+							Type    string
+							Version int
+							Payload model.PaymentCodeEvent
+						}
+						if err := json.Unmarshal(m.Payload, &event); err != nil {
+							t.Errorf("unmarshal event: %v", err)
+						}
+						if event.Id != m.Id || event.Type != model.EVENT_TYPE_PAYMENT_CODE_CREATED || event.Version != model.EVENT_VERSION {
+							t.Errorf("unexpected event envelope %+v", event)
+						}
+						if event.Payload.PaymentCode != paymentCode {
+							t.Errorf("event payload = %+v, want %+v", event.Payload.PaymentCode, paymentCode)
+						}
+						return nil
+					})
+				return outbox
+			}(),
+		},
+		{
+			name:   "disabled",
+			config: config.Producer{Enabled: false, Topic: "payment-codes"},
+			outbox: mock_repository.NewMockIOutboxRepository(ctrl),
+		},
+		{
+			name:   "with-error-in-outbox",
+			config: config.Producer{Enabled: true, Topic: "payment-codes"},
+			outbox: func() repository.IOutboxRepository {
+				outbox := mock_repository.NewMockIOutboxRepository(ctrl)
+				outbox.
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(err)
+				return outbox
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := PaymentCodeMessageProducer{
+				Config: tt.config,
+				Outbox: tt.outbox,
+			}
+			if err := r.ProduceCreated(context.TODO(), &paymentCode); (err != nil) != tt.wantErr {
+				t.Errorf("PaymentCodeMessageProducer.ProduceCreated() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPaymentCodeMessageProducer_queuesWebhooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	err := errors.New("Mock Error")
+
+	payment := model.Payment{
+		Id:            "test-payment-id",
+		MerchantId:    "test-merchant",
+		PaymentCodeId: "test-id",
+		Amount:        10000,
+		Currency:      "IDR",
+	}
+	endpoints := []model.WebhookEndpoint{{Id: "first-endpoint"}, {Id: "second-endpoint"}}
+
+	tests := []struct {
+		name     string
+		webhooks func() repository.IWebhookRepository
+		wantErr  bool
+	}{
+		{
+			name: "one-delivery-per-endpoint",
+			webhooks: func() repository.IWebhookRepository {
+				webhooks := mock_repository.NewMockIWebhookRepository(ctrl)
+				webhooks.
+					EXPECT().
+					ListSubscribedEndpoints(gomock.Any(), "test-merchant", model.EVENT_TYPE_PAYMENT_RECEIVED).
+					Return(endpoints, nil)
+				for _, e := range endpoints {
+					endpointId := e.Id
+					webhooks.
+						EXPECT().
+						CreateDelivery(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(ctx context.Context, d *model.WebhookDelivery) error {
+							if d.Id == "" || d.EndpointId != endpointId || d.MerchantId != "test-merchant" || d.Status != model.WEBHOOK_DELIVERY_STATUS_PENDING {
+								t.Errorf("unexpected webhook delivery %+v", d)
+							}
+
+							var event model.Event
+							if err := json.Unmarshal(d.Payload, &event); err != nil {
+								t.Errorf("unmarshal event: %v", err)
+							}
+							if event.Id != d.EventId || event.Type != model.EVENT_TYPE_PAYMENT_RECEIVED {
+								t.Errorf("unexpected event envelope %+v", event)
+							}
+							return nil
+						})
+				}
+				return webhooks
+			},
+		},
+		{
+			name: "without-endpoints",
+			webhooks: func() repository.IWebhookRepository {
+				webhooks := mock_repository.NewMockIWebhookRepository(ctrl)
+				webhooks.
+					EXPECT().
+					ListSubscribedEndpoints(gomock.Any(), "test-merchant", model.EVENT_TYPE_PAYMENT_RECEIVED).
+					Return(nil, nil)
+				return webhooks
+			},
+		},
+		{
+			name: "with-error-in-webhooks",
+			webhooks: func() repository.IWebhookRepository {
+				webhooks := mock_repository.NewMockIWebhookRepository(ctrl)
+				webhooks.
+					EXPECT().
+					ListSubscribedEndpoints(gomock.Any(), "test-merchant", model.EVENT_TYPE_PAYMENT_RECEIVED).
+					Return(nil, err)
+				return webhooks
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the outbox is not written to while the producer is disabled
+			r := PaymentCodeMessageProducer{
+				Config:   config.Producer{Enabled: false},
+				Outbox:   mock_repository.NewMockIOutboxRepository(ctrl),
+				Webhooks: tt.webhooks(),
+			}
+			if err := r.ProducePaymentReceived(context.TODO(), &payment); (err != nil) != tt.wantErr {
+				t.Errorf("PaymentCodeMessageProducer.ProducePaymentReceived() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
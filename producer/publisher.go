@@ -0,0 +1,63 @@
+package producer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+)
+
+// Publisher delivers outbox messages to the message broker. Messages may be
+// delivered more than once, so consumers must deduplicate by message id.
+type Publisher interface {
+	Publish(ctx context.Context, m model.OutboxMessage) (err error)
+}
+
+// LogPublisher writes messages to the log. It is meant for local development.
+type LogPublisher struct{}
+
+func (p LogPublisher) Publish(ctx context.Context, m model.OutboxMessage) (err error) {
+	log.Printf("publish [%s] key=%s id=%s: %s", m.Topic, m.Key, m.Id, m.Payload)
+	return
+}
+
+// HTTPPublisher POSTs every message to URL. Any non-2xx response is treated
+// as a failed delivery.
+type HTTPPublisher struct {
+	URL    string
+	Client *http.Client
+}
+
+func (p HTTPPublisher) Publish(ctx context.Context, m model.OutboxMessage) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(m.Payload))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Message-Id", m.Id)
+	req.Header.Set("X-Message-Topic", m.Topic)
+	req.Header.Set("X-Message-Key", m.Key)
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = fmt.Errorf("publish message %s: unexpected status %d", m.Id, resp.StatusCode)
+	}
+
+	return
+}
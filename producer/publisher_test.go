@@ -0,0 +1,56 @@
+package producer
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+)
+
+func TestHTTPPublisher_Publish(t *testing.T) {
+	message := model.OutboxMessage{
+		Id:      "test-id",
+		Topic:   "payment-codes",
+		Key:     "test-key",
+		Payload: []byte(`{"id":"test-key"}`),
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{
+			name:   "publish-success",
+			status: http.StatusAccepted,
+		},
+		{
+			name:    "rejected-by-broker",
+			status:  http.StatusServiceUnavailable,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				if string(body) != string(message.Payload) {
+					t.Errorf("body = %s, want %s", body, message.Payload)
+				}
+				if r.Header.Get("X-Message-Id") != message.Id || r.Header.Get("X-Message-Topic") != message.Topic || r.Header.Get("X-Message-Key") != message.Key {
+					t.Errorf("unexpected message headers %v", r.Header)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			p := HTTPPublisher{URL: server.URL}
+			if err := p.Publish(context.TODO(), message); (err != nil) != tt.wantErr {
+				t.Errorf("HTTPPublisher.Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+
+	"github.com/lib/pq"
+)
+
+type IAPIKeyRepository interface {
+	Create(ctx context.Context, k *model.APIKey) (err error)
+	GetByPrefix(ctx context.Context, prefix string) (k model.APIKey, err error)
+	List(ctx context.Context, merchantID string) (apiKeys []model.APIKey, err error)
+	Rotate(ctx context.Context, merchantID string, id string, prefix string, keyHash string, signingSecret string, now time.Time) (k model.APIKey, err error)
+	Revoke(ctx context.Context, merchantID string, id string, now time.Time) (k model.APIKey, err error)
+	TouchLastUsed(ctx context.Context, id string, now time.Time, staleBefore time.Time) (err error)
+}
+
+type APIKeyRepository struct {
+	Db     *sql.DB
+	Config config.DB
+}
+
+const apiKeyColumns = "id, merchant_id, name, prefix, key_hash, signing_secret, require_signature, scopes, created_at, updated_at, last_used_at, revoked_at"
+
+func scanAPIKey(row scanner) (k model.APIKey, err error) {
+	var signingSecret sql.NullString
+	var lastUsedAt, revokedAt sql.NullTime
+	err = row.Scan(
+		&k.Id,
+		&k.MerchantId,
+		&k.Name,
+		&k.Prefix,
+		&k.KeyHash,
+		&signingSecret,
+		&k.RequireSignature,
+		pq.Array(&k.Scopes),
+		&k.CreatedAt,
+		&k.UpdatedAt,
+		&lastUsedAt,
+		&revokedAt,
+	)
+	k.SigningSecret = signingSecret.String
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return
+}
+
+// Create returns ErrConflict when the prefix is already taken.
+func (r APIKeyRepository) Create(ctx context.Context, k *model.APIKey) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err = conn(ctx, r.Db).ExecContext(
+		ctx,
+		`INSERT INTO api_keys (id, merchant_id, name, prefix, key_hash, signing_secret, require_signature, scopes, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		k.Id, k.MerchantId, k.Name, k.Prefix, k.KeyHash, k.SigningSecret, k.RequireSignature, pq.Array(k.Scopes), k.CreatedAt, k.UpdatedAt,
+	)
+	err = classifyError(err)
+
+	return
+}
+
+// GetByPrefix returns the key with the given prefix, revoked or not, or
+// ErrNotFound when there is none.
+func (r APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (k model.APIKey, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	row := conn(ctx, r.Db).QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = $1", prefix)
+
+	k, err = scanAPIKey(row)
+	err = classifyError(err)
+
+	return
+}
+
+// List returns every key of the merchant, oldest first.
+func (r APIKeyRepository) List(ctx context.Context, merchantID string) (apiKeys []model.APIKey, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := conn(ctx, r.Db).QueryContext(
+		ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE merchant_id = $1 ORDER BY created_at, id",
+		merchantID,
+	)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var k model.APIKey
+		k, err = scanAPIKey(rows)
+		if err != nil {
+			err = classifyError(err)
+			return
+		}
+		apiKeys = append(apiKeys, k)
+	}
+
+	err = classifyError(rows.Err())
+
+	return
+}
+
+// Rotate replaces the key and the signing secret of an unrevoked key of the
+// merchant, so the old ones stop working at once. It returns ErrNotFound when
+// the merchant has no such key or it is revoked.
+func (r APIKeyRepository) Rotate(ctx context.Context, merchantID string, id string, prefix string, keyHash string, signingSecret string, now time.Time) (k model.APIKey, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	row := conn(ctx, r.Db).QueryRowContext(
+		ctx,
+		`UPDATE api_keys SET prefix = $1, key_hash = $2, signing_secret = $3, updated_at = $4
+		WHERE id = $5 AND merchant_id = $6 AND revoked_at IS NULL
+		RETURNING `+apiKeyColumns,
+		prefix, keyHash, signingSecret, now, id, merchantID,
+	)
+
+	k, err = scanAPIKey(row)
+	err = classifyError(err)
+
+	return
+}
+
+// Revoke revokes a key of the merchant. Revoking a revoked key keeps its
+// revocation time. It returns ErrNotFound when the merchant has no such key.
+func (r APIKeyRepository) Revoke(ctx context.Context, merchantID string, id string, now time.Time) (k model.APIKey, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	row := conn(ctx, r.Db).QueryRowContext(
+		ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $1), updated_at = $1
+		WHERE id = $2 AND merchant_id = $3
+		RETURNING `+apiKeyColumns,
+		now, id, merchantID,
+	)
+
+	k, err = scanAPIKey(row)
+	err = classifyError(err)
+
+	return
+}
+
+// TouchLastUsed sets the last use of the key to now unless it was recorded
+// after staleBefore, so a busy key is not written on every request.
+func (r APIKeyRepository) TouchLastUsed(ctx context.Context, id string, now time.Time, staleBefore time.Time) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err = conn(ctx, r.Db).ExecContext(
+		ctx,
+		"UPDATE api_keys SET last_used_at = $1 WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $3)",
+		now, id, staleBefore,
+	)
+	err = classifyError(err)
+
+	return
+}
@@ -0,0 +1,180 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	postgresTest "github.com/pevin/pevin-golang-training-beginner/postgres"
+	repository "github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type apiKeyRepositoryTestSuite struct {
+	postgresTest.Suite
+}
+
+func TestSuiteAPIKeyRepository(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		dsn = postgresTest.DefaultTestDsn
+	}
+
+	apiKeyRepoSuite := &apiKeyRepositoryTestSuite{
+		postgresTest.Suite{
+			DSN:                     dsn,
+			MigrationLocationFolder: "../db/migrations",
+		},
+	}
+
+	suite.Run(t, apiKeyRepoSuite)
+}
+
+func (s apiKeyRepositoryTestSuite) BeforeTest(suiteName, testName string) {
+	ok, err := s.Migration.Up()
+	s.Require().NoError(err)
+	s.Require().True(ok)
+}
+
+func (s apiKeyRepositoryTestSuite) AfterTest(suiteName, testName string) {
+	ok, err := s.Migration.Down()
+	s.Require().NoError(err)
+	s.Require().True(ok)
+}
+
+func newTestAPIKey(id string, prefix string, now time.Time) model.APIKey {
+	return model.APIKey{
+		Id:            id,
+		MerchantId:    testMerchantId,
+		Name:          "test name",
+		Prefix:        prefix,
+		KeyHash:       "test-hash-" + id,
+		SigningSecret: "test-secret-" + id,
+		Scopes:        []string{model.API_KEY_SCOPE_READ, model.API_KEY_SCOPE_WRITE},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+func (s apiKeyRepositoryTestSuite) TestCreateAndGetByPrefix() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	repo := repository.APIKeyRepository{Db: s.DBConn}
+
+	apiKey := newTestAPIKey("test-id", "0123456789abcdef", now)
+	apiKey.RequireSignature = true
+	err := repo.Create(context.TODO(), &apiKey)
+	s.Require().NoError(err)
+
+	duplicate := newTestAPIKey("other-id", apiKey.Prefix, now)
+	err = repo.Create(context.TODO(), &duplicate)
+	s.Require().True(errors.Is(err, repository.ErrConflict), "expected ErrConflict, got %v", err)
+
+	unknownMerchant := newTestAPIKey("unknown-merchant-id", "fedcba9876543210", now)
+	unknownMerchant.MerchantId = "unknown-merchant"
+	err = repo.Create(context.TODO(), &unknownMerchant)
+	s.Require().True(errors.Is(err, repository.ErrInvalidInput), "expected ErrInvalidInput, got %v", err)
+
+	res, err := repo.GetByPrefix(context.TODO(), apiKey.Prefix)
+	s.Require().NoError(err)
+	s.Require().Equal(apiKey.Id, res.Id)
+	s.Require().Equal(apiKey.KeyHash, res.KeyHash)
+	s.Require().Equal(apiKey.SigningSecret, res.SigningSecret)
+	s.Require().True(res.RequireSignature)
+	s.Require().Equal(apiKey.Scopes, res.Scopes)
+	s.Require().Nil(res.LastUsedAt)
+	s.Require().Nil(res.RevokedAt)
+
+	_, err = repo.GetByPrefix(context.TODO(), "unknown-prefix")
+	s.Require().Equal(repository.ErrNotFound, err)
+}
+
+func (s apiKeyRepositoryTestSuite) TestList() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	repo := repository.APIKeyRepository{Db: s.DBConn}
+
+	first := newTestAPIKey("first-id", "0000000000000001", now)
+	second := newTestAPIKey("second-id", "0000000000000002", now.Add(time.Second))
+	s.Require().NoError(repo.Create(context.TODO(), &second))
+	s.Require().NoError(repo.Create(context.TODO(), &first))
+
+	res, err := repo.List(context.TODO(), testMerchantId)
+	s.Require().NoError(err)
+	s.Require().Len(res, 2)
+	s.Require().Equal(first.Id, res[0].Id)
+	s.Require().Equal(second.Id, res[1].Id)
+
+	res, err = repo.List(context.TODO(), "other-merchant")
+	s.Require().NoError(err)
+	s.Require().Empty(res)
+}
+
+func (s apiKeyRepositoryTestSuite) TestRotateAndRevoke() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	repo := repository.APIKeyRepository{Db: s.DBConn}
+
+	apiKey := newTestAPIKey("test-id", "0123456789abcdef", now)
+	s.Require().NoError(repo.Create(context.TODO(), &apiKey))
+
+	_, err := repo.Rotate(context.TODO(), "other-merchant", apiKey.Id, "fedcba9876543210", "new-hash", "new-secret", now)
+	s.Require().Equal(repository.ErrNotFound, err)
+
+	rotatedAt := now.Add(time.Minute)
+	res, err := repo.Rotate(context.TODO(), testMerchantId, apiKey.Id, "fedcba9876543210", "new-hash", "new-secret", rotatedAt)
+	s.Require().NoError(err)
+	s.Require().Equal("fedcba9876543210", res.Prefix)
+	s.Require().Equal("new-hash", res.KeyHash)
+	s.Require().Equal("new-secret", res.SigningSecret)
+	s.Require().Equal(apiKey.Name, res.Name)
+	s.Require().True(rotatedAt.Equal(res.UpdatedAt), "updated_at = %v, want %v", res.UpdatedAt, rotatedAt)
+
+	_, err = repo.GetByPrefix(context.TODO(), apiKey.Prefix)
+	s.Require().Equal(repository.ErrNotFound, err)
+
+	_, err = repo.Revoke(context.TODO(), "other-merchant", apiKey.Id, now)
+	s.Require().Equal(repository.ErrNotFound, err)
+
+	revokedAt := now.Add(2 * time.Minute)
+	res, err = repo.Revoke(context.TODO(), testMerchantId, apiKey.Id, revokedAt)
+	s.Require().NoError(err)
+	s.Require().NotNil(res.RevokedAt)
+	s.Require().True(revokedAt.Equal(*res.RevokedAt), "revoked_at = %v, want %v", res.RevokedAt, revokedAt)
+
+	res, err = repo.Revoke(context.TODO(), testMerchantId, apiKey.Id, revokedAt.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().True(revokedAt.Equal(*res.RevokedAt), "revoked_at = %v, want %v", res.RevokedAt, revokedAt)
+
+	_, err = repo.Rotate(context.TODO(), testMerchantId, apiKey.Id, "0000000000000003", "other-hash", "other-secret", now)
+	s.Require().Equal(repository.ErrNotFound, err)
+}
+
+func (s apiKeyRepositoryTestSuite) TestTouchLastUsed() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	repo := repository.APIKeyRepository{Db: s.DBConn}
+
+	apiKey := newTestAPIKey("test-id", "0123456789abcdef", now)
+	s.Require().NoError(repo.Create(context.TODO(), &apiKey))
+
+	err := repo.TouchLastUsed(context.TODO(), apiKey.Id, now, now.Add(-time.Minute))
+	s.Require().NoError(err)
+
+	// A use within the resolution is not recorded.
+	err = repo.TouchLastUsed(context.TODO(), apiKey.Id, now.Add(time.Second), now.Add(time.Second-time.Minute))
+	s.Require().NoError(err)
+
+	res, err := repo.GetByPrefix(context.TODO(), apiKey.Prefix)
+	s.Require().NoError(err)
+	s.Require().NotNil(res.LastUsedAt)
+	s.Require().True(now.Equal(*res.LastUsedAt), "last_used_at = %v, want %v", res.LastUsedAt, now)
+
+	later := now.Add(2 * time.Minute)
+	err = repo.TouchLastUsed(context.TODO(), apiKey.Id, later, later.Add(-time.Minute))
+	s.Require().NoError(err)
+
+	res, err = repo.GetByPrefix(context.TODO(), apiKey.Prefix)
+	s.Require().NoError(err)
+	s.Require().True(later.Equal(*res.LastUsedAt), "last_used_at = %v, want %v", res.LastUsedAt, later)
+}
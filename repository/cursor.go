@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidCursor = fmt.Errorf("%w: cursor", ErrInvalidInput)
+
+// cursor points at the last payment code of a page. Pages are ordered by
+// created_at and then id, so the pair is unique and stable across inserts.
+// Query identifies the sort order and filters the page was listed with, so a
+// cursor is only accepted for the listing it came from.
+type cursor struct {
+	CreatedAt time.Time `json:"c"`
+	Id        string    `json:"i"`
+	Query     string    `json:"q"`
+}
+
+// cursorQuery hashes the sort order and filters of a listing into the Query
+// of its cursors.
+func cursorQuery(parts ...interface{}) string {
+	h := sha256.New()
+	for _, part := range parts {
+		if t, ok := part.(time.Time); ok {
+			part = t.UTC().Format(time.RFC3339Nano)
+		}
+		fmt.Fprintf(h, "%v\x00", part)
+	}
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:12])
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor returns ErrInvalidCursor when s is malformed or was issued for
+// another query.
+func decodeCursor(s string, query string) (c cursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		err = ErrInvalidCursor
+		return
+	}
+
+	err = json.Unmarshal(b, &c)
+	if err != nil || c.Id == "" || c.Query != query {
+		err = ErrInvalidCursor
+		return
+	}
+
+	return
+}
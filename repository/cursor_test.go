@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_decodeCursor(t *testing.T) {
+	createdAt := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	query := cursorQuery("ASC", "ACTIVE", createdAt)
+	valid := encodeCursor(cursor{CreatedAt: createdAt, Id: "test-id", Query: query})
+
+	tests := []struct {
+		name    string
+		cursor  string
+		query   string
+		wantErr error
+	}{
+		{
+			name:   "same-query",
+			cursor: valid,
+			query:  query,
+		},
+		{
+			name:   "same-instant-in-another-zone",
+			cursor: valid,
+			query:  cursorQuery("ASC", "ACTIVE", createdAt.In(time.FixedZone("UTC+7", 7*60*60))),
+		},
+		{
+			name:    "other-sort-order",
+			cursor:  valid,
+			query:   cursorQuery("DESC", "ACTIVE", createdAt),
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "other-filter",
+			cursor:  valid,
+			query:   cursorQuery("ASC", "INACTIVE", createdAt),
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "without-query",
+			cursor:  encodeCursor(cursor{CreatedAt: createdAt, Id: "test-id"}),
+			query:   query,
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "malformed",
+			cursor:  "invalid",
+			query:   query,
+			wantErr: ErrInvalidCursor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.cursor, tt.query)
+			if err != tt.wantErr {
+				t.Fatalf("decodeCursor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (!got.CreatedAt.Equal(createdAt) || got.Id != "test-id") {
+				t.Errorf("decodeCursor() = %v", got)
+			}
+		})
+	}
+}
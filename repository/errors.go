@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// Errors returned by the repositories. Callers should compare with errors.Is,
+// since the underlying database error is usually wrapped with them.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflicts with existing data")
+	ErrUnavailable  = errors.New("database is unavailable")
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+// classifyError wraps a database error with the matching repository error.
+// Errors that cannot be classified are returned unchanged.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "23505":
+			return fmt.Errorf("%w: %v", ErrConflict, err)
+		case pqErr.Code.Class() == "22", pqErr.Code.Class() == "23":
+			return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		case pqErr.Code.Class() == "08", pqErr.Code.Class() == "40",
+			pqErr.Code.Class() == "53", pqErr.Code.Class() == "57":
+			return fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	return err
+}
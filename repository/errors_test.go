@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func Test_classifyError(t *testing.T) {
+	mockErr := errors.New("Mock Error")
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "nil",
+			err:     nil,
+			wantErr: nil,
+		},
+		{
+			name:    "no-rows",
+			err:     sql.ErrNoRows,
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "unique-violation",
+			err:     &pq.Error{Code: "23505"},
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "value-too-long",
+			err:     &pq.Error{Code: "22001"},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "not-null-violation",
+			err:     &pq.Error{Code: "23502"},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "admin-shutdown",
+			err:     &pq.Error{Code: "57P01"},
+			wantErr: ErrUnavailable,
+		},
+		{
+			name:    "bad-connection",
+			err:     driver.ErrBadConn,
+			wantErr: ErrUnavailable,
+		},
+		{
+			name:    "deadline-exceeded",
+			err:     context.DeadlineExceeded,
+			wantErr: ErrUnavailable,
+		},
+		{
+			name:    "unclassified",
+			err:     mockErr,
+			wantErr: mockErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := classifyError(tt.err); !errors.Is(err, tt.wantErr) {
+				t.Errorf("classifyError() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+)
+
+type IIdempotencyKeyRepository interface {
+	Reserve(ctx context.Context, k *model.IdempotencyKey, now time.Time) (existing model.IdempotencyKey, reserved bool, err error)
+	Complete(ctx context.Context, clientId string, key string, lockedUntil time.Time, statusCode int, contentType string, responseBody []byte) (err error)
+	Release(ctx context.Context, clientId string, key string, lockedUntil time.Time) (err error)
+	DeleteExpired(ctx context.Context, now time.Time, limit int) (deleted int, err error)
+}
+
+type IdempotencyKeyRepository struct {
+	Db     *sql.DB
+	Config config.DB
+}
+
+// Reserve stores k unless the client already used the key. A key that expired,
+// or whose first request stopped without completing before its lock ran out,
+// is taken over. When the key is not reserved the stored one is returned.
+//
+// k.LockedUntil identifies the reservation: Complete and Release only change
+// a key that is still held under it, so a request whose key was taken over
+// cannot touch the reservation of the request that took it.
+func (r IdempotencyKeyRepository) Reserve(ctx context.Context, k *model.IdempotencyKey, now time.Time) (existing model.IdempotencyKey, reserved bool, err error) {
+	// the stored key is read after the insert, so a Release or DeleteExpired
+	// in between leaves nothing to read; the key is free again then
+	for attempt := 1; ; attempt++ {
+		existing, reserved, err = r.reserve(ctx, k, now)
+		if !errors.Is(err, ErrNotFound) || attempt == reserveAttempts {
+			return
+		}
+	}
+}
+
+// reserveAttempts bounds how often Reserve retries a key that was deleted
+// while it was being reserved.
+const reserveAttempts = 3
+
+func (r IdempotencyKeyRepository) reserve(ctx context.Context, k *model.IdempotencyKey, now time.Time) (existing model.IdempotencyKey, reserved bool, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	res, err := conn(ctx, r.Db).ExecContext(
+		ctx,
+		`INSERT INTO idempotency_keys (client_id, idempotency_key, request_hash, locked_until, created_at, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (client_id, idempotency_key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			locked_until = EXCLUDED.locked_until,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= $7
+			OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until <= $7)`,
+		k.ClientId, k.Key, k.RequestHash, k.LockedUntil, k.CreatedAt, k.ExpiresAt, now,
+	)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	if rowAffected == 1 {
+		reserved = true
+		return
+	}
+
+	var (
+		statusCode  sql.NullInt64
+		contentType sql.NullString
+	)
+	err = conn(ctx, r.Db).QueryRowContext(
+		ctx,
+		`SELECT client_id, idempotency_key, request_hash, status_code, content_type, response_body, locked_until, created_at, expires_at
+		FROM idempotency_keys
+		WHERE client_id = $1 AND idempotency_key = $2`,
+		k.ClientId, k.Key,
+	).Scan(&existing.ClientId, &existing.Key, &existing.RequestHash, &statusCode, &contentType, &existing.ResponseBody, &existing.LockedUntil, &existing.CreatedAt, &existing.ExpiresAt)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+	existing.StatusCode = int(statusCode.Int64)
+	existing.ContentType = contentType.String
+
+	return
+}
+
+// Complete stores the response to the request that reserved the key until
+// lockedUntil. It returns ErrNotFound when the key was completed or taken
+// over since.
+func (r IdempotencyKeyRepository) Complete(ctx context.Context, clientId string, key string, lockedUntil time.Time, statusCode int, contentType string, responseBody []byte) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	res, err := conn(ctx, r.Db).ExecContext(
+		ctx,
+		`UPDATE idempotency_keys SET status_code = $1, content_type = $2, response_body = $3
+		WHERE client_id = $4 AND idempotency_key = $5 AND locked_until = $6 AND status_code IS NULL`,
+		statusCode, contentType, responseBody, clientId, key, lockedUntil,
+	)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	if rowAffected != 1 {
+		err = fmt.Errorf("%w: idempotency key", ErrNotFound)
+		return
+	}
+
+	return
+}
+
+// Release deletes the key reserved until lockedUntil while it has no
+// response, so the request can be retried with it.
+func (r IdempotencyKeyRepository) Release(ctx context.Context, clientId string, key string, lockedUntil time.Time) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err = conn(ctx, r.Db).ExecContext(
+		ctx,
+		"DELETE FROM idempotency_keys WHERE client_id = $1 AND idempotency_key = $2 AND locked_until = $3 AND status_code IS NULL",
+		clientId, key, lockedUntil,
+	)
+	err = classifyError(err)
+
+	return
+}
+
+// DeleteExpired deletes up to limit keys that expired before now.
+func (r IdempotencyKeyRepository) DeleteExpired(ctx context.Context, now time.Time, limit int) (deleted int, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	res, err := conn(ctx, r.Db).ExecContext(
+		ctx,
+		`DELETE FROM idempotency_keys
+		WHERE (client_id, idempotency_key) IN (
+			SELECT client_id, idempotency_key FROM idempotency_keys
+			WHERE expires_at <= $1
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)`,
+		now, limit,
+	)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+	deleted = int(rowAffected)
+
+	return
+}
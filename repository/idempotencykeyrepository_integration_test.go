@@ -0,0 +1,158 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	postgresTest "github.com/pevin/pevin-golang-training-beginner/postgres"
+	repository "github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type idempotencyKeyRepositoryTestSuite struct {
+	postgresTest.Suite
+}
+
+func TestSuiteIdempotencyKeyRepository(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		dsn = postgresTest.DefaultTestDsn
+	}
+
+	idempotencyKeyRepoSuite := &idempotencyKeyRepositoryTestSuite{
+		postgresTest.Suite{
+			DSN:                     dsn,
+			MigrationLocationFolder: "../db/migrations",
+		},
+	}
+
+	suite.Run(t, idempotencyKeyRepoSuite)
+}
+
+func (s idempotencyKeyRepositoryTestSuite) BeforeTest(suiteName, testName string) {
+	ok, err := s.Migration.Up()
+	s.Require().NoError(err)
+	s.Require().True(ok)
+}
+
+func (s idempotencyKeyRepositoryTestSuite) AfterTest(suiteName, testName string) {
+	ok, err := s.Migration.Down()
+	s.Require().NoError(err)
+	s.Require().True(ok)
+}
+
+func CreateIdempotencyKeyPayload(now time.Time) model.IdempotencyKey {
+	return model.IdempotencyKey{
+		ClientId:    "test-client",
+		Key:         "test-key",
+		RequestHash: "test-hash",
+		LockedUntil: now.Add(time.Minute).Truncate(time.Microsecond),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+}
+
+func (s idempotencyKeyRepositoryTestSuite) TestReserveFlow() {
+	now := time.Now().UTC()
+	repo := repository.IdempotencyKeyRepository{Db: s.DBConn}
+
+	k := CreateIdempotencyKeyPayload(now)
+	_, reserved, err := repo.Reserve(context.TODO(), &k, now)
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+
+	existing, reserved, err := repo.Reserve(context.TODO(), &k, now)
+	s.Require().NoError(err)
+	s.Require().False(reserved)
+	s.Require().False(existing.Completed())
+
+	err = repo.Complete(context.TODO(), k.ClientId, k.Key, k.LockedUntil, 201, "application/json", []byte(`{"id":"test-id"}`))
+	s.Require().NoError(err)
+
+	existing, reserved, err = repo.Reserve(context.TODO(), &k, now)
+	s.Require().NoError(err)
+	s.Require().False(reserved)
+	s.Require().Equal(201, existing.StatusCode)
+	s.Require().Equal(`{"id":"test-id"}`, string(existing.ResponseBody))
+
+	err = repo.Complete(context.TODO(), k.ClientId, k.Key, k.LockedUntil, 201, "application/json", nil)
+	s.Require().True(errors.Is(err, repository.ErrNotFound))
+
+	other := k
+	other.ClientId = "other-client"
+	_, reserved, err = repo.Reserve(context.TODO(), &other, now)
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+}
+
+func (s idempotencyKeyRepositoryTestSuite) TestReserveTakesOverStaleKeys() {
+	now := time.Now().UTC()
+	repo := repository.IdempotencyKeyRepository{Db: s.DBConn}
+
+	k := CreateIdempotencyKeyPayload(now)
+	_, reserved, err := repo.Reserve(context.TODO(), &k, now)
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+
+	// the first request never completed and its lock ran out
+	takeover := CreateIdempotencyKeyPayload(now.Add(2 * time.Minute))
+	takeover.RequestHash = "other-hash"
+	_, reserved, err = repo.Reserve(context.TODO(), &takeover, now.Add(2*time.Minute))
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+
+	// the slow first request can neither complete nor release the new
+	// reservation
+	err = repo.Complete(context.TODO(), k.ClientId, k.Key, k.LockedUntil, 201, "application/json", []byte(`{"id":"stale"}`))
+	s.Require().True(errors.Is(err, repository.ErrNotFound), "expected ErrNotFound, got %v", err)
+
+	err = repo.Release(context.TODO(), k.ClientId, k.Key, k.LockedUntil)
+	s.Require().NoError(err)
+
+	existing, reserved, err := repo.Reserve(context.TODO(), &k, now.Add(2*time.Minute))
+	s.Require().NoError(err)
+	s.Require().False(reserved)
+	s.Require().Equal("other-hash", existing.RequestHash)
+	s.Require().False(existing.Completed())
+
+	err = repo.Complete(context.TODO(), takeover.ClientId, takeover.Key, takeover.LockedUntil, 201, "application/json", []byte(`{"id":"test-id"}`))
+	s.Require().NoError(err)
+
+	existing, reserved, err = repo.Reserve(context.TODO(), &takeover, now.Add(2*time.Minute))
+	s.Require().NoError(err)
+	s.Require().False(reserved)
+	s.Require().Equal(`{"id":"test-id"}`, string(existing.ResponseBody))
+
+	_, reserved, err = repo.Reserve(context.TODO(), &k, now.Add(2*time.Hour))
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+}
+
+func (s idempotencyKeyRepositoryTestSuite) TestReleaseAndDeleteExpired() {
+	now := time.Now().UTC()
+	repo := repository.IdempotencyKeyRepository{Db: s.DBConn}
+
+	k := CreateIdempotencyKeyPayload(now)
+	_, _, err := repo.Reserve(context.TODO(), &k, now)
+	s.Require().NoError(err)
+
+	err = repo.Release(context.TODO(), k.ClientId, k.Key, k.LockedUntil)
+	s.Require().NoError(err)
+
+	_, reserved, err := repo.Reserve(context.TODO(), &k, now)
+	s.Require().NoError(err)
+	s.Require().True(reserved)
+
+	deleted, err := repo.DeleteExpired(context.TODO(), now, 10)
+	s.Require().NoError(err)
+	s.Require().Equal(0, deleted)
+
+	deleted, err = repo.DeleteExpired(context.TODO(), now.Add(2*time.Hour), 10)
+	s.Require().NoError(err)
+	s.Require().Equal(1, deleted)
+}
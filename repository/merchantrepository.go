@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+)
+
+type IMerchantRepository interface {
+	Create(ctx context.Context, m *model.Merchant) (err error)
+	Get(ctx context.Context, id string) (merchant model.Merchant, err error)
+}
+
+type MerchantRepository struct {
+	Db     *sql.DB
+	Config config.DB
+}
+
+// Create returns ErrConflict when a merchant with the same id exists.
+func (r MerchantRepository) Create(ctx context.Context, m *model.Merchant) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err = conn(ctx, r.Db).ExecContext(
+		ctx,
+		"INSERT INTO merchants (id, name, created_at, updated_at) VALUES($1, $2, $3, $4)",
+		m.Id, m.Name, m.CreatedAt, m.UpdatedAt,
+	)
+	err = classifyError(err)
+
+	return
+}
+
+// Get returns ErrNotFound when there is no merchant with the given id.
+func (r MerchantRepository) Get(ctx context.Context, id string) (merchant model.Merchant, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	err = conn(ctx, r.Db).QueryRowContext(
+		ctx,
+		"SELECT id, name, created_at, updated_at FROM merchants WHERE id = $1",
+		id,
+	).Scan(&merchant.Id, &merchant.Name, &merchant.CreatedAt, &merchant.UpdatedAt)
+	err = classifyError(err)
+
+	return
+}
@@ -0,0 +1,70 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	postgresTest "github.com/pevin/pevin-golang-training-beginner/postgres"
+	repository "github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type merchantRepositoryTestSuite struct {
+	postgresTest.Suite
+}
+
+func TestSuiteMerchantRepository(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		dsn = postgresTest.DefaultTestDsn
+	}
+
+	merchantRepoSuite := &merchantRepositoryTestSuite{
+		postgresTest.Suite{
+			DSN:                     dsn,
+			MigrationLocationFolder: "../db/migrations",
+		},
+	}
+
+	suite.Run(t, merchantRepoSuite)
+}
+
+func (s merchantRepositoryTestSuite) BeforeTest(suiteName, testName string) {
+	ok, err := s.Migration.Up()
+	s.Require().NoError(err)
+	s.Require().True(ok)
+}
+
+func (s merchantRepositoryTestSuite) AfterTest(suiteName, testName string) {
+	ok, err := s.Migration.Down()
+	s.Require().NoError(err)
+	s.Require().True(ok)
+}
+
+func (s merchantRepositoryTestSuite) TestCreateAndGet() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	repo := repository.MerchantRepository{Db: s.DBConn}
+
+	merchant := model.Merchant{Id: "test-merchant", Name: "test name", CreatedAt: now, UpdatedAt: now}
+	err := repo.Create(context.TODO(), &merchant)
+	s.Require().NoError(err)
+
+	err = repo.Create(context.TODO(), &merchant)
+	s.Require().True(errors.Is(err, repository.ErrConflict), "expected ErrConflict, got %v", err)
+
+	res, err := repo.Get(context.TODO(), merchant.Id)
+	s.Require().NoError(err)
+	s.Require().Equal(merchant.Name, res.Name)
+	s.Require().True(merchant.CreatedAt.Equal(res.CreatedAt), "created_at = %v, want %v", res.CreatedAt, merchant.CreatedAt)
+
+	_, err = repo.Get(context.TODO(), "unknown-merchant")
+	s.Require().Equal(repository.ErrNotFound, err)
+
+	_, err = repo.Get(context.TODO(), "default")
+	s.Require().NoError(err)
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+)
+
+type IOutboxRepository interface {
+	Create(ctx context.Context, m *model.OutboxMessage) (err error)
+	Claim(ctx context.Context, now time.Time, leasedUntil time.Time, limit int) (messages []model.OutboxMessage, err error)
+	MarkPublished(ctx context.Context, id string, leasedUntil time.Time, publishedAt time.Time) (err error)
+	MarkFailed(ctx context.Context, id string, leasedUntil time.Time, nextAttemptAt time.Time, lastError string) (err error)
+}
+
+type OutboxRepository struct {
+	Db     *sql.DB
+	Config config.DB
+}
+
+// Create stores a message in the outbox. Call it within the transaction that
+// changes the data the message is about, so both are committed together.
+func (r OutboxRepository) Create(ctx context.Context, m *model.OutboxMessage) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err = conn(ctx, r.Db).ExecContext(
+		ctx,
+		"INSERT INTO outbox_messages (id, topic, message_key, payload, attempts, next_attempt_at, created_at) VALUES($1, $2, $3, $4, $5, $6, $7)",
+		m.Id, m.Topic, m.Key, m.Payload, m.Attempts, m.NextAttemptAt, m.CreatedAt,
+	)
+	err = classifyError(err)
+
+	return
+}
+
+// Claim leases up to limit unpublished messages that are due by moving their
+// next attempt to leasedUntil, and returns them oldest first with that lease.
+// Messages are claimed in a single statement, so no lock is held while they
+// are published; another relay only picks one up again once its lease is over.
+func (r OutboxRepository) Claim(ctx context.Context, now time.Time, leasedUntil time.Time, limit int) (messages []model.OutboxMessage, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := conn(ctx, r.Db).QueryContext(
+		ctx,
+		`WITH claimed AS (
+			UPDATE outbox_messages SET next_attempt_at = $2
+			WHERE id IN (
+				SELECT id FROM outbox_messages
+				WHERE published_at IS NULL AND next_attempt_at <= $1
+				ORDER BY created_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, topic, message_key, payload, attempts, last_error, next_attempt_at, created_at
+		)
+		SELECT id, topic, message_key, payload, attempts, COALESCE(last_error, ''), next_attempt_at, created_at
+		FROM claimed
+		ORDER BY created_at`,
+		now, leasedUntil, limit,
+	)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m model.OutboxMessage
+		err = rows.Scan(&m.Id, &m.Topic, &m.Key, &m.Payload, &m.Attempts, &m.LastError, &m.NextAttemptAt, &m.CreatedAt)
+		if err != nil {
+			err = classifyError(err)
+			return
+		}
+		messages = append(messages, m)
+	}
+
+	err = classifyError(rows.Err())
+
+	return
+}
+
+// MarkPublished records that the message claimed until leasedUntil was
+// published. Like MarkFailed, it returns ErrNotFound when the lease was lost
+// to another relay.
+func (r OutboxRepository) MarkPublished(ctx context.Context, id string, leasedUntil time.Time, publishedAt time.Time) (err error) {
+	return r.update(
+		ctx,
+		"UPDATE outbox_messages SET published_at = $1 WHERE id = $2 AND next_attempt_at = $3 AND published_at IS NULL",
+		publishedAt, id, leasedUntil,
+	)
+}
+
+// MarkFailed records a failed delivery and schedules the next attempt.
+func (r OutboxRepository) MarkFailed(ctx context.Context, id string, leasedUntil time.Time, nextAttemptAt time.Time, lastError string) (err error) {
+	return r.update(
+		ctx,
+		"UPDATE outbox_messages SET attempts = attempts + 1, next_attempt_at = $1, last_error = $2 WHERE id = $3 AND next_attempt_at = $4 AND published_at IS NULL",
+		nextAttemptAt, lastError, id, leasedUntil,
+	)
+}
+
+func (r OutboxRepository) update(ctx context.Context, query string, args ...interface{}) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	res, err := conn(ctx, r.Db).ExecContext(ctx, query, args...)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	if rowAffected != 1 {
+		err = fmt.Errorf("%w: outbox message lease", ErrNotFound)
+		return
+	}
+
+	return
+}
@@ -0,0 +1,119 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	postgresTest "github.com/pevin/pevin-golang-training-beginner/postgres"
+	repository "github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type outboxRepositoryTestSuite struct {
+	postgresTest.Suite
+}
+
+func TestSuiteOutboxRepository(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		dsn = postgresTest.DefaultTestDsn
+	}
+
+	outboxRepoSuite := &outboxRepositoryTestSuite{
+		postgresTest.Suite{
+			DSN:                     dsn,
+			MigrationLocationFolder: "../db/migrations",
+		},
+	}
+
+	suite.Run(t, outboxRepoSuite)
+}
+
+func (s outboxRepositoryTestSuite) BeforeTest(suiteName, testName string) {
+	ok, err := s.Migration.Up()
+	s.Require().NoError(err)
+	s.Require().True(ok)
+}
+
+func (s outboxRepositoryTestSuite) AfterTest(suiteName, testName string) {
+	ok, err := s.Migration.Down()
+	s.Require().NoError(err)
+	s.Require().True(ok)
+}
+
+func CreateOutboxMessagePayload(now time.Time) model.OutboxMessage {
+	id, _ := uuid.NewRandom()
+	return model.OutboxMessage{
+		Id:            id.String(),
+		Topic:         "payment-codes",
+		Key:           "test-key",
+		Payload:       []byte(`{"id":"test-key"}`),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+func (s outboxRepositoryTestSuite) TestPublishFlow() {
+	now := time.Now().UTC()
+	repo := repository.OutboxRepository{Db: s.DBConn}
+
+	published := CreateOutboxMessagePayload(now)
+	failed := CreateOutboxMessagePayload(now)
+	notDue := CreateOutboxMessagePayload(now)
+	notDue.NextAttemptAt = now.Add(time.Hour)
+
+	for _, m := range []*model.OutboxMessage{&published, &failed, &notDue} {
+		err := repo.Create(context.TODO(), m)
+		if err != nil {
+			s.Fail("Error in creating seed settings", err)
+		}
+	}
+
+	leasedUntil := now.Add(time.Minute).Truncate(time.Microsecond)
+	messages, err := repo.Claim(context.TODO(), now, leasedUntil, 10)
+	s.Require().NoError(err)
+	s.Require().Len(messages, 2)
+	s.Require().True(leasedUntil.Equal(messages[0].NextAttemptAt))
+
+	// claimed messages are not claimed again until the lease is over
+	messages, err = repo.Claim(context.TODO(), now, leasedUntil, 10)
+	s.Require().NoError(err)
+	s.Require().Len(messages, 0)
+
+	err = repo.MarkPublished(context.TODO(), published.Id, now, now)
+	s.Require().True(errors.Is(err, repository.ErrNotFound), "expected ErrNotFound for a lost lease, got %v", err)
+
+	s.Require().NoError(repo.MarkPublished(context.TODO(), published.Id, leasedUntil, now))
+	s.Require().NoError(repo.MarkFailed(context.TODO(), failed.Id, leasedUntil, now.Add(2*time.Minute), "broker unavailable"))
+
+	messages, err = repo.Claim(context.TODO(), now.Add(2*time.Minute), now.Add(3*time.Minute), 10)
+	s.Require().NoError(err)
+	s.Require().Len(messages, 1)
+	s.Require().Equal(failed.Id, messages[0].Id)
+	s.Require().Equal(1, messages[0].Attempts)
+	s.Require().Equal("broker unavailable", messages[0].LastError)
+}
+
+func (s outboxRepositoryTestSuite) TestWithinTransactionRollback() {
+	now := time.Now().UTC()
+	repo := repository.OutboxRepository{Db: s.DBConn}
+	transactor := repository.Transactor{Db: s.DBConn}
+	mockErr := errors.New("Mock Error")
+
+	message := CreateOutboxMessagePayload(now)
+	err := transactor.WithinTransaction(context.TODO(), func(ctx context.Context) error {
+		s.Require().NoError(repo.Create(ctx, &message))
+		return mockErr
+	})
+	s.Require().Equal(mockErr, err)
+
+	messages, err := repo.Claim(context.TODO(), now, now.Add(time.Minute), 10)
+	s.Require().NoError(err)
+	s.Require().Len(messages, 0)
+}
@@ -3,8 +3,12 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/pevin/pevin-golang-training-beginner/config"
 	"github.com/pevin/pevin-golang-training-beginner/model"
 
 	_ "github.com/lib/pq"
@@ -12,58 +16,414 @@ import (
 
 type IPaymentCodeRepository interface {
 	Create(ctx context.Context, p *model.PaymentCode) (err error)
-	Get(ctx context.Context, id string) (paymentCode model.PaymentCode, err error)
+	CreateBatch(ctx context.Context, paymentCodes []*model.PaymentCode) (inserted []bool, err error)
+	Get(ctx context.Context, merchantID string, id string) (paymentCode model.PaymentCode, err error)
+	GetByPaymentCode(ctx context.Context, merchantID string, paymentCode string) (p model.PaymentCode, err error)
+	RecordPayment(ctx context.Context, merchantID string, id string, amount int64, now time.Time) (paymentCode model.PaymentCode, recorded bool, err error)
+	ExpireBatch(ctx context.Context, now time.Time, limit int) (paymentCodes []model.PaymentCode, err error)
+	UpdateStatus(ctx context.Context, merchantID string, id string, fromStatus string, toStatus string, updatedAt time.Time) (updated bool, err error)
+	List(ctx context.Context, filter model.PaymentCodeFilter) (paymentCodes []model.PaymentCode, nextCursor string, err error)
 }
 
 type PaymentCodeRepository struct {
-	Db *sql.DB
+	Db     *sql.DB
+	Config config.DB
+}
+
+const paymentCodeColumns = `id, merchant_id, payment_code, name, status, amount_type, amount, min_amount, max_amount, currency,
+	usage_type, max_usage, max_total_amount, usage_count, total_paid, expiration_date, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPaymentCode(row scanner) (paymentCode model.PaymentCode, err error) {
+	var amount, minAmount, maxAmount, maxUsage, maxTotalAmount sql.NullInt64
+	err = row.Scan(
+		&paymentCode.Id,
+		&paymentCode.MerchantId,
+		&paymentCode.PaymentCode,
+		&paymentCode.Name,
+		&paymentCode.Status,
+		&paymentCode.AmountType,
+		&amount,
+		&minAmount,
+		&maxAmount,
+		&paymentCode.Currency,
+		&paymentCode.UsageType,
+		&maxUsage,
+		&maxTotalAmount,
+		&paymentCode.UsageCount,
+		&paymentCode.TotalPaid,
+		&paymentCode.ExpirationDate,
+		&paymentCode.CreatedAt,
+		&paymentCode.UpdatedAt,
+	)
+	paymentCode.Amount = amount.Int64
+	paymentCode.MinAmount = minAmount.Int64
+	paymentCode.MaxAmount = maxAmount.Int64
+	paymentCode.MaxUsage = int(maxUsage.Int64)
+	paymentCode.MaxTotalAmount = maxTotalAmount.Int64
+	return
+}
+
+// nullAmount stores unset (zero) amounts as NULL.
+func nullAmount(amount int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: amount, Valid: amount != 0}
 }
 
 func (r PaymentCodeRepository) Create(ctx context.Context, p *model.PaymentCode) (err error) {
-	res, err := r.Db.ExecContext(
-		context.Background(),
-		"INSERT INTO payment_codes (id, payment_code, name, status, expiration_date, created_at, updated_at) VALUES($1 ,$2 ,$3, $4, $5, $6, $7)",
-		p.Id, p.PaymentCode, p.Name, p.Status, p.ExpirationDate, p.CreatedAt, p.UpdatedAt,
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	res, err := conn(ctx, r.Db).ExecContext(
+		ctx,
+		"INSERT INTO payment_codes ("+paymentCodeColumns+`)
+		VALUES($1 ,$2 ,$3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+		p.Id, p.MerchantId, p.PaymentCode, p.Name, p.Status, p.AmountType, nullAmount(p.Amount), nullAmount(p.MinAmount), nullAmount(p.MaxAmount), p.Currency,
+		p.UsageType, nullAmount(int64(p.MaxUsage)), nullAmount(p.MaxTotalAmount), p.UsageCount, p.TotalPaid, p.ExpirationDate, p.CreatedAt, p.UpdatedAt,
 	)
 
 	if err != nil {
-		log.Fatal(err)
+		err = classifyError(err)
 		return
 	}
 
 	rowAffected, err := res.RowsAffected()
 
 	if err != nil {
-		log.Fatal(err)
+		err = classifyError(err)
 		return
 	}
 
 	if rowAffected != 1 {
-		log.Fatalf("Expected row affected equal to 1 but got %d", rowAffected)
+		err = fmt.Errorf("expected row affected equal to 1 but got %d", rowAffected)
+		return
+	}
+
+	return
+}
+
+// createBatchChunkSize keeps the parameters of one insert well below the
+// 65535 PostgreSQL allows.
+const createBatchChunkSize = 500
+
+// CreateBatch inserts paymentCodes with multi-row inserts. Payment codes that
+// conflict with an existing one, or with an earlier one of the batch, are
+// skipped instead of failing the batch; inserted reports which ones were
+// stored. Call it within a transaction so the batch is stored atomically.
+func (r PaymentCodeRepository) CreateBatch(ctx context.Context, paymentCodes []*model.PaymentCode) (inserted []bool, err error) {
+	inserted = make([]bool, len(paymentCodes))
+
+	for start := 0; start < len(paymentCodes); start += createBatchChunkSize {
+		end := start + createBatchChunkSize
+		if end > len(paymentCodes) {
+			end = len(paymentCodes)
+		}
+
+		err = r.createChunk(ctx, paymentCodes[start:end], inserted[start:end])
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (r PaymentCodeRepository) createChunk(ctx context.Context, paymentCodes []*model.PaymentCode, inserted []bool) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	const columns = 18
+	values := make([]string, 0, len(paymentCodes))
+	args := make([]interface{}, 0, len(paymentCodes)*columns)
+	for i, p := range paymentCodes {
+		placeholders := make([]string, columns)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", i*columns+j+1)
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		args = append(args,
+			p.Id, p.MerchantId, p.PaymentCode, p.Name, p.Status, p.AmountType, nullAmount(p.Amount), nullAmount(p.MinAmount), nullAmount(p.MaxAmount), p.Currency,
+			p.UsageType, nullAmount(int64(p.MaxUsage)), nullAmount(p.MaxTotalAmount), p.UsageCount, p.TotalPaid, p.ExpirationDate, p.CreatedAt, p.UpdatedAt,
+		)
+	}
+
+	rows, err := conn(ctx, r.Db).QueryContext(
+		ctx,
+		"INSERT INTO payment_codes ("+paymentCodeColumns+") VALUES "+strings.Join(values, ", ")+" ON CONFLICT DO NOTHING RETURNING id",
+		args...,
+	)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool, len(paymentCodes))
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			err = classifyError(err)
+			return
+		}
+		ids[id] = true
+	}
+
+	err = classifyError(rows.Err())
+	if err != nil {
+		return
+	}
+
+	for i, p := range paymentCodes {
+		inserted[i] = ids[p.Id]
+	}
+
+	return
+}
+
+// Get returns ErrNotFound when the merchant has no payment code with the given
+// id.
+func (r PaymentCodeRepository) Get(ctx context.Context, merchantID string, id string) (paymentCode model.PaymentCode, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	row := conn(ctx, r.Db).QueryRowContext(ctx, "SELECT "+paymentCodeColumns+" FROM payment_codes where id = $1 AND merchant_id = $2 limit 1", id, merchantID)
+
+	paymentCode, err = scanPaymentCode(row)
+	err = classifyError(err)
+
+	return
+}
+
+// GetByPaymentCode returns the payment code of the merchant with the given
+// payment_code value, or ErrNotFound when there is none.
+func (r PaymentCodeRepository) GetByPaymentCode(ctx context.Context, merchantID string, paymentCode string) (p model.PaymentCode, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	row := conn(ctx, r.Db).QueryRowContext(
+		ctx,
+		"SELECT "+paymentCodeColumns+" FROM payment_codes WHERE merchant_id = $1 AND payment_code = $2",
+		merchantID, paymentCode,
+	)
+
+	p, err = scanPaymentCode(row)
+	err = classifyError(err)
+
+	return
+}
+
+// RecordPayment adds a payment of amount to the usage of an ACTIVE, unexpired
+// payment code and returns the updated row. The code becomes PAID when it is
+// single-use or the payment uses up its max usage or max total amount. The
+// checks and the update are one statement, so concurrent payments cannot
+// exceed the limits. recorded is false when the code is no longer payable or
+// the payment would go over max total amount.
+func (r PaymentCodeRepository) RecordPayment(ctx context.Context, merchantID string, id string, amount int64, now time.Time) (paymentCode model.PaymentCode, recorded bool, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	row := conn(ctx, r.Db).QueryRowContext(
+		ctx,
+		`UPDATE payment_codes SET
+			usage_count = usage_count + 1,
+			total_paid = total_paid + $2,
+			status = CASE
+				WHEN usage_type = $4
+					OR usage_count + 1 >= max_usage
+					OR total_paid + $2 >= max_total_amount
+				THEN $5 ELSE status END,
+			updated_at = $3
+		WHERE id = $1
+			AND merchant_id = $7
+			AND status = $6
+			AND expiration_date > $3
+			AND (max_total_amount IS NULL OR total_paid + $2 <= max_total_amount)
+		RETURNING `+paymentCodeColumns,
+		id, amount, now, model.USAGE_TYPE_SINGLE, model.PAYMENT_CODE_STATUS_PAID, model.PAYMENT_CODE_STATUS_ACTIVE, merchantID,
+	)
+
+	paymentCode, err = scanPaymentCode(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+		return
+	}
+	if err != nil {
+		err = classifyError(err)
 		return
 	}
 
+	recorded = true
+
 	return
 }
 
-func (r PaymentCodeRepository) Get(ctx context.Context, id string) (paymentCode model.PaymentCode, err error) {
-	rows, err := r.Db.QueryContext(context.Background(), "SELECT id, payment_code, name, status FROM payment_codes where id = $1 limit 1", id)
+// ExpireBatch moves at most limit ACTIVE or INACTIVE payment codes of every
+// merchant whose expiration date is before now to EXPIRED and returns the
+// updated rows. Rows locked by another worker are skipped, so several
+// instances can expire codes concurrently without touching the same row twice.
+func (r PaymentCodeRepository) ExpireBatch(ctx context.Context, now time.Time, limit int) (paymentCodes []model.PaymentCode, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := conn(ctx, r.Db).QueryContext(
+		ctx,
+		`UPDATE payment_codes SET status = $1, updated_at = $2
+		WHERE id IN (
+			SELECT id FROM payment_codes
+			WHERE status IN ($3, $4) AND expiration_date <= $2
+			ORDER BY expiration_date
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+paymentCodeColumns,
+		model.PAYMENT_CODE_STATUS_EXPIRED, now, model.PAYMENT_CODE_STATUS_ACTIVE, model.PAYMENT_CODE_STATUS_INACTIVE, limit,
+	)
 	if err != nil {
-		log.Fatal(err)
+		err = classifyError(err)
+		return
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		if err := rows.Scan(
-			&paymentCode.Id,
-			&paymentCode.PaymentCode,
-			&paymentCode.Name,
-			&paymentCode.Status,
-		); err != nil {
-			log.Fatal(err)
+		var paymentCode model.PaymentCode
+		paymentCode, err = scanPaymentCode(rows)
+		if err != nil {
+			err = classifyError(err)
+			return
 		}
+		paymentCodes = append(paymentCodes, paymentCode)
+	}
+
+	err = classifyError(rows.Err())
+
+	return
+}
+
+// UpdateStatus moves the payment code to toStatus only if it is still in
+// fromStatus, so concurrent transitions cannot overwrite each other. updated is
+// false when the merchant has no such payment code or its status has changed.
+func (r PaymentCodeRepository) UpdateStatus(ctx context.Context, merchantID string, id string, fromStatus string, toStatus string, updatedAt time.Time) (updated bool, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	res, err := conn(ctx, r.Db).ExecContext(
+		ctx,
+		"UPDATE payment_codes SET status = $1, updated_at = $2 WHERE id = $3 AND merchant_id = $4 AND status = $5",
+		toStatus, updatedAt, id, merchantID, fromStatus,
+	)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		err = classifyError(err)
 		return
 	}
 
+	updated = rowAffected == 1
+
 	return
 }
+
+// List returns one page of the payment codes of filter.MerchantId matching
+// filter, ordered by created_at and id. nextCursor is empty when there are no
+// more pages. A cursor listed with another sort order or other filters is
+// ErrInvalidCursor.
+func (r PaymentCodeRepository) List(ctx context.Context, filter model.PaymentCodeFilter) (paymentCodes []model.PaymentCode, nextCursor string, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	where("merchant_id = $%d", filter.MerchantId)
+	if filter.Status != "" {
+		where("status = $%d", filter.Status)
+	}
+	if filter.Name != "" {
+		where("name ILIKE '%%' || $%d || '%%'", escapeLike(filter.Name))
+	}
+	if filter.PaymentCodePrefix != "" {
+		where("payment_code LIKE $%d || '%%'", escapeLike(filter.PaymentCodePrefix))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		where("created_at >= $%d", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		where("created_at < $%d", filter.CreatedTo)
+	}
+	if !filter.ExpirationFrom.IsZero() {
+		where("expiration_date >= $%d", filter.ExpirationFrom)
+	}
+	if !filter.ExpirationTo.IsZero() {
+		where("expiration_date < $%d", filter.ExpirationTo)
+	}
+
+	order, comparator := "ASC", ">"
+	if filter.SortOrder == model.SORT_ORDER_DESC {
+		order, comparator = "DESC", "<"
+	}
+
+	listQuery := cursorQuery(order, filter.Status, filter.Name, filter.PaymentCodePrefix, filter.CreatedFrom, filter.CreatedTo, filter.ExpirationFrom, filter.ExpirationTo)
+	if filter.Cursor != "" {
+		var c cursor
+		c, err = decodeCursor(filter.Cursor, listQuery)
+		if err != nil {
+			return
+		}
+		args = append(args, c.CreatedAt, c.Id)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) %s ($%d, $%d)", comparator, len(args)-1, len(args)))
+	}
+
+	query := "SELECT " + paymentCodeColumns + " FROM payment_codes WHERE " + strings.Join(conditions, " AND ")
+
+	// one extra row tells whether there is a next page
+	args = append(args, filter.Limit+1)
+	query += fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT $%d", order, order, len(args))
+
+	rows, err := conn(ctx, r.Db).QueryContext(ctx, query, args...)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var paymentCode model.PaymentCode
+		paymentCode, err = scanPaymentCode(rows)
+		if err != nil {
+			err = classifyError(err)
+			return
+		}
+		paymentCodes = append(paymentCodes, paymentCode)
+	}
+
+	err = classifyError(rows.Err())
+	if err != nil {
+		return
+	}
+
+	if len(paymentCodes) > filter.Limit {
+		paymentCodes = paymentCodes[:filter.Limit]
+		last := paymentCodes[len(paymentCodes)-1]
+		nextCursor = encodeCursor(cursor{CreatedAt: last.CreatedAt, Id: last.Id, Query: listQuery})
+	}
+
+	return
+}
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
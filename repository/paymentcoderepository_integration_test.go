@@ -2,8 +2,10 @@ package repository_test
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/pevin/pevin-golang-training-beginner/model"
 	postgresTest "github.com/pevin/pevin-golang-training-beginner/postgres"
@@ -45,13 +47,22 @@ func (s paymentCodeRepositoryTestSuite) AfterTest(suiteName, testName string) {
 	s.Require().True(ok)
 }
 
+// testMerchantId is the merchant seeded by the merchants migration.
+const testMerchantId = "default"
+
 func CreatePaymentCodePayload() model.PaymentCode {
 	id, _ := uuid.NewRandom()
 	model := model.PaymentCode{
 		Id:          id.String(),
+		MerchantId:  testMerchantId,
 		PaymentCode: "test-payment-code-" + id.String(),
 		Name:        "test name",
 		Status:      "test-status",
+		AmountType:  model.AMOUNT_TYPE_OPEN,
+		MinAmount:   10000,
+		MaxAmount:   500000,
+		Currency:    "IDR",
+		UsageType:   model.USAGE_TYPE_MULTI,
 	}
 	return model
 }
@@ -86,7 +97,11 @@ func (s paymentCodeRepositoryTestSuite) TestCreatePaymentCode() {
 }
 
 func (s paymentCodeRepositoryTestSuite) TestGetPaymentCodeById() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
 	mockPaymentCode := CreatePaymentCodePayload()
+	mockPaymentCode.ExpirationDate = now.Add(time.Hour)
+	mockPaymentCode.CreatedAt = now
+	mockPaymentCode.UpdatedAt = now
 
 	repo := repository.PaymentCodeRepository{Db: s.DBConn}
 	err := repo.Create(context.TODO(), &mockPaymentCode)
@@ -118,7 +133,7 @@ func (s paymentCodeRepositoryTestSuite) TestGetPaymentCodeById() {
 				repo := repository.PaymentCodeRepository{Db: s.DBConn}
 				return repo
 			}(),
-			expectedError:    nil,
+			expectedError:    repository.ErrNotFound,
 			expectedResponse: model.PaymentCode{},
 			id:               "invalid-id",
 			ctx:              context.TODO(),
@@ -128,14 +143,390 @@ func (s paymentCodeRepositoryTestSuite) TestGetPaymentCodeById() {
 	for _, tC := range testCases {
 		// Run tests
 		s.T().Run(tC.desc, func(t *testing.T) {
-			res, err := tC.repo.Get(tC.ctx, tC.id)
+			res, err := tC.repo.Get(tC.ctx, testMerchantId, tC.id)
 			s.Require().Equal(tC.expectedError, err)
 
 			if err == nil {
 				s.Require().Equal(tC.expectedResponse.Id, res.Id)
 				s.Require().Equal(tC.expectedResponse.PaymentCode, res.PaymentCode)
 				s.Require().Equal(tC.expectedResponse.Name, res.Name)
+				s.Require().Equal(tC.expectedResponse.AmountType, res.AmountType)
+				s.Require().Equal(tC.expectedResponse.MinAmount, res.MinAmount)
+				s.Require().Equal(tC.expectedResponse.MaxAmount, res.MaxAmount)
+				s.Require().Equal(tC.expectedResponse.Currency, res.Currency)
+				s.Require().True(tC.expectedResponse.ExpirationDate.Equal(res.ExpirationDate), "expiration_date = %v, want %v", res.ExpirationDate, tC.expectedResponse.ExpirationDate)
+				s.Require().True(tC.expectedResponse.CreatedAt.Equal(res.CreatedAt), "created_at = %v, want %v", res.CreatedAt, tC.expectedResponse.CreatedAt)
+				s.Require().True(tC.expectedResponse.UpdatedAt.Equal(res.UpdatedAt), "updated_at = %v, want %v", res.UpdatedAt, tC.expectedResponse.UpdatedAt)
 			}
 		})
 	}
 }
+
+func (s paymentCodeRepositoryTestSuite) TestExpireBatch() {
+	now := time.Now().UTC()
+	repo := repository.PaymentCodeRepository{Db: s.DBConn}
+
+	duePaymentCode := CreatePaymentCodePayload()
+	duePaymentCode.Status = model.PAYMENT_CODE_STATUS_ACTIVE
+	duePaymentCode.ExpirationDate = now.Add(-time.Hour)
+
+	notDuePaymentCode := CreatePaymentCodePayload()
+	notDuePaymentCode.Status = model.PAYMENT_CODE_STATUS_ACTIVE
+	notDuePaymentCode.ExpirationDate = now.Add(time.Hour)
+
+	inactivePaymentCode := CreatePaymentCodePayload()
+	inactivePaymentCode.Status = model.PAYMENT_CODE_STATUS_INACTIVE
+	inactivePaymentCode.ExpirationDate = now.Add(-2 * time.Hour)
+
+	expiredPaymentCode := CreatePaymentCodePayload()
+	expiredPaymentCode.Status = model.PAYMENT_CODE_STATUS_EXPIRED
+	expiredPaymentCode.ExpirationDate = now.Add(-3 * time.Hour)
+
+	for _, p := range []*model.PaymentCode{&duePaymentCode, &notDuePaymentCode, &inactivePaymentCode, &expiredPaymentCode} {
+		err := repo.Create(context.TODO(), p)
+		if err != nil {
+			s.Fail("Error in creating seed settings", err)
+		}
+	}
+
+	expired, err := repo.ExpireBatch(context.TODO(), now, 10)
+	s.Require().NoError(err)
+	var expiredIds []string
+	for _, p := range expired {
+		s.Require().Equal(model.PAYMENT_CODE_STATUS_EXPIRED, p.Status)
+		expiredIds = append(expiredIds, p.Id)
+	}
+	s.Require().ElementsMatch([]string{duePaymentCode.Id, inactivePaymentCode.Id}, expiredIds)
+
+	expired, err = repo.ExpireBatch(context.TODO(), now, 10)
+	s.Require().NoError(err)
+	s.Require().Len(expired, 0)
+}
+
+func (s paymentCodeRepositoryTestSuite) TestUpdateStatus() {
+	repo := repository.PaymentCodeRepository{Db: s.DBConn}
+
+	mockPaymentCode := CreatePaymentCodePayload()
+	mockPaymentCode.Status = model.PAYMENT_CODE_STATUS_ACTIVE
+	err := repo.Create(context.TODO(), &mockPaymentCode)
+	if err != nil {
+		s.Fail("Error in creating seed settings", err)
+	}
+
+	testCases := []struct {
+		desc            string
+		id              string
+		fromStatus      string
+		toStatus        string
+		expectedUpdated bool
+	}{
+		{
+			desc:            "update-success",
+			id:              mockPaymentCode.Id,
+			fromStatus:      model.PAYMENT_CODE_STATUS_ACTIVE,
+			toStatus:        model.PAYMENT_CODE_STATUS_INACTIVE,
+			expectedUpdated: true,
+		},
+		{
+			desc:            "stale-from-status",
+			id:              mockPaymentCode.Id,
+			fromStatus:      model.PAYMENT_CODE_STATUS_ACTIVE,
+			toStatus:        model.PAYMENT_CODE_STATUS_INACTIVE,
+			expectedUpdated: false,
+		},
+		{
+			desc:            "not-found",
+			id:              "invalid-id",
+			fromStatus:      model.PAYMENT_CODE_STATUS_ACTIVE,
+			toStatus:        model.PAYMENT_CODE_STATUS_INACTIVE,
+			expectedUpdated: false,
+		},
+	}
+
+	for _, tC := range testCases {
+		s.T().Run(tC.desc, func(t *testing.T) {
+			updated, err := repo.UpdateStatus(context.TODO(), testMerchantId, tC.id, tC.fromStatus, tC.toStatus, time.Now().UTC())
+			s.Require().NoError(err)
+			s.Require().Equal(tC.expectedUpdated, updated)
+		})
+	}
+
+	res, err := repo.Get(context.TODO(), testMerchantId, mockPaymentCode.Id)
+	s.Require().NoError(err)
+	s.Require().Equal(model.PAYMENT_CODE_STATUS_INACTIVE, res.Status)
+}
+
+func (s paymentCodeRepositoryTestSuite) TestList() {
+	repo := repository.PaymentCodeRepository{Db: s.DBConn}
+	createdAt := time.Now().UTC().Truncate(time.Second)
+
+	var seeded []model.PaymentCode
+	for i := 0; i < 3; i++ {
+		mockPaymentCode := CreatePaymentCodePayload()
+		mockPaymentCode.PaymentCode = "list-" + mockPaymentCode.PaymentCode
+		mockPaymentCode.Status = model.PAYMENT_CODE_STATUS_ACTIVE
+		mockPaymentCode.CreatedAt = createdAt.Add(time.Duration(i) * time.Second)
+		mockPaymentCode.UpdatedAt = mockPaymentCode.CreatedAt
+		err := repo.Create(context.TODO(), &mockPaymentCode)
+		if err != nil {
+			s.Fail("Error in creating seed settings", err)
+		}
+		seeded = append(seeded, mockPaymentCode)
+	}
+
+	other := CreatePaymentCodePayload()
+	other.Name = "100% other"
+	other.Status = model.PAYMENT_CODE_STATUS_INACTIVE
+	err := repo.Create(context.TODO(), &other)
+	if err != nil {
+		s.Fail("Error in creating seed settings", err)
+	}
+
+	s.T().Run("paginate-asc", func(t *testing.T) {
+		filter := model.PaymentCodeFilter{MerchantId: testMerchantId, PaymentCodePrefix: "list-", SortOrder: model.SORT_ORDER_ASC, Limit: 2}
+
+		firstPage, nextCursor, err := repo.List(context.TODO(), filter)
+		s.Require().NoError(err)
+		s.Require().Len(firstPage, 2)
+		s.Require().Equal(seeded[0].Id, firstPage[0].Id)
+		s.Require().Equal(seeded[1].Id, firstPage[1].Id)
+		s.Require().NotEmpty(nextCursor)
+
+		filter.Cursor = nextCursor
+		secondPage, nextCursor, err := repo.List(context.TODO(), filter)
+		s.Require().NoError(err)
+		s.Require().Len(secondPage, 1)
+		s.Require().Equal(seeded[2].Id, secondPage[0].Id)
+		s.Require().Empty(nextCursor)
+	})
+
+	s.T().Run("cursor-bound-to-sort-order-and-filters", func(t *testing.T) {
+		filter := model.PaymentCodeFilter{MerchantId: testMerchantId, PaymentCodePrefix: "list-", SortOrder: model.SORT_ORDER_ASC, Limit: 2}
+
+		_, nextCursor, err := repo.List(context.TODO(), filter)
+		s.Require().NoError(err)
+
+		otherOrder := filter
+		otherOrder.SortOrder, otherOrder.Cursor = model.SORT_ORDER_DESC, nextCursor
+		_, _, err = repo.List(context.TODO(), otherOrder)
+		s.Require().Equal(repository.ErrInvalidCursor, err)
+
+		otherFilter := filter
+		otherFilter.Status, otherFilter.Cursor = model.PAYMENT_CODE_STATUS_ACTIVE, nextCursor
+		_, _, err = repo.List(context.TODO(), otherFilter)
+		s.Require().Equal(repository.ErrInvalidCursor, err)
+	})
+
+	s.T().Run("sort-desc", func(t *testing.T) {
+		filter := model.PaymentCodeFilter{MerchantId: testMerchantId, PaymentCodePrefix: "list-", SortOrder: model.SORT_ORDER_DESC, Limit: 10}
+
+		res, _, err := repo.List(context.TODO(), filter)
+		s.Require().NoError(err)
+		s.Require().Len(res, 3)
+		s.Require().Equal(seeded[2].Id, res[0].Id)
+	})
+
+	s.T().Run("filter-status-and-name", func(t *testing.T) {
+		filter := model.PaymentCodeFilter{MerchantId: testMerchantId, Status: model.PAYMENT_CODE_STATUS_INACTIVE, Name: "100%", Limit: 10}
+
+		res, _, err := repo.List(context.TODO(), filter)
+		s.Require().NoError(err)
+		s.Require().Len(res, 1)
+		s.Require().Equal(other.Id, res[0].Id)
+	})
+
+	s.T().Run("invalid-cursor", func(t *testing.T) {
+		_, _, err := repo.List(context.TODO(), model.PaymentCodeFilter{MerchantId: testMerchantId, Cursor: "invalid", Limit: 10})
+		s.Require().Equal(repository.ErrInvalidCursor, err)
+	})
+}
+
+func (s paymentCodeRepositoryTestSuite) TestCreateDuplicatePaymentCode() {
+	repo := repository.PaymentCodeRepository{Db: s.DBConn}
+
+	mockPaymentCode := CreatePaymentCodePayload()
+	err := repo.Create(context.TODO(), &mockPaymentCode)
+	s.Require().NoError(err)
+
+	err = repo.Create(context.TODO(), &mockPaymentCode)
+	s.Require().True(errors.Is(err, repository.ErrConflict), "expected ErrConflict, got %v", err)
+
+	samePaymentCode := CreatePaymentCodePayload()
+	samePaymentCode.PaymentCode = mockPaymentCode.PaymentCode
+	err = repo.Create(context.TODO(), &samePaymentCode)
+	s.Require().True(errors.Is(err, repository.ErrConflict), "expected ErrConflict, got %v", err)
+}
+
+func (s paymentCodeRepositoryTestSuite) TestCreateBatch() {
+	repo := repository.PaymentCodeRepository{Db: s.DBConn}
+
+	existing := CreatePaymentCodePayload()
+	err := repo.Create(context.TODO(), &existing)
+	s.Require().NoError(err)
+
+	first := CreatePaymentCodePayload()
+	conflicting := CreatePaymentCodePayload()
+	conflicting.PaymentCode = existing.PaymentCode
+	duplicate := CreatePaymentCodePayload()
+	duplicate.PaymentCode = first.PaymentCode
+
+	inserted, err := repo.CreateBatch(context.TODO(), []*model.PaymentCode{&first, &conflicting, &duplicate})
+	s.Require().NoError(err)
+	s.Require().Equal([]bool{true, false, false}, inserted)
+
+	paymentCode, err := repo.Get(context.TODO(), testMerchantId, first.Id)
+	s.Require().NoError(err)
+	s.Require().Equal(first.PaymentCode, paymentCode.PaymentCode)
+
+	_, err = repo.Get(context.TODO(), testMerchantId, conflicting.Id)
+	s.Require().True(errors.Is(err, repository.ErrNotFound), "expected ErrNotFound, got %v", err)
+}
+
+func (s paymentCodeRepositoryTestSuite) TestCreateInvalidAmount() {
+	repo := repository.PaymentCodeRepository{Db: s.DBConn}
+
+	mockPaymentCode := CreatePaymentCodePayload()
+	mockPaymentCode.AmountType = model.AMOUNT_TYPE_FIXED
+
+	err := repo.Create(context.TODO(), &mockPaymentCode)
+	s.Require().True(errors.Is(err, repository.ErrInvalidInput), "expected ErrInvalidInput, got %v", err)
+}
+
+func (s paymentCodeRepositoryTestSuite) TestGetByPaymentCode() {
+	repo := repository.PaymentCodeRepository{Db: s.DBConn}
+
+	mockPaymentCode := CreatePaymentCodePayload()
+	mockPaymentCode.CreatedAt = time.Now().UTC()
+	err := repo.Create(context.TODO(), &mockPaymentCode)
+	s.Require().NoError(err)
+
+	res, err := repo.GetByPaymentCode(context.TODO(), testMerchantId, mockPaymentCode.PaymentCode)
+	s.Require().NoError(err)
+	s.Require().Equal(mockPaymentCode.Id, res.Id)
+	s.Require().Equal(mockPaymentCode.Currency, res.Currency)
+
+	_, err = repo.GetByPaymentCode(context.TODO(), testMerchantId, "invalid-payment-code")
+	s.Require().Equal(repository.ErrNotFound, err)
+}
+
+func (s paymentCodeRepositoryTestSuite) TestCreatePayment() {
+	repo := repository.PaymentCodeRepository{Db: s.DBConn}
+	paymentRepo := repository.PaymentRepository{Db: s.DBConn}
+
+	mockPaymentCode := CreatePaymentCodePayload()
+	err := repo.Create(context.TODO(), &mockPaymentCode)
+	s.Require().NoError(err)
+
+	id, _ := uuid.NewRandom()
+	payment := model.Payment{
+		Id:            id.String(),
+		PaymentCodeId: mockPaymentCode.Id,
+		Amount:        150000,
+		Currency:      "IDR",
+		CreatedAt:     time.Now().UTC(),
+	}
+	err = paymentRepo.Create(context.TODO(), &payment)
+	s.Require().NoError(err)
+
+	payment.Id = "unknown-payment-code"
+	payment.PaymentCodeId = "invalid-id"
+	err = paymentRepo.Create(context.TODO(), &payment)
+	s.Require().True(errors.Is(err, repository.ErrInvalidInput), "expected ErrInvalidInput, got %v", err)
+}
+
+func (s paymentCodeRepositoryTestSuite) TestRecordPayment() {
+	now := time.Now().UTC()
+	repo := repository.PaymentCodeRepository{Db: s.DBConn}
+
+	singleUse := CreatePaymentCodePayload()
+	singleUse.Status = model.PAYMENT_CODE_STATUS_ACTIVE
+	singleUse.UsageType = model.USAGE_TYPE_SINGLE
+	singleUse.ExpirationDate = now.Add(time.Hour)
+
+	capped := CreatePaymentCodePayload()
+	capped.Status = model.PAYMENT_CODE_STATUS_ACTIVE
+	capped.MaxTotalAmount = 100000
+	capped.ExpirationDate = now.Add(time.Hour)
+
+	for _, p := range []*model.PaymentCode{&singleUse, &capped} {
+		err := repo.Create(context.TODO(), p)
+		if err != nil {
+			s.Fail("Error in creating seed settings", err)
+		}
+	}
+
+	res, recorded, err := repo.RecordPayment(context.TODO(), testMerchantId, singleUse.Id, 50000, now)
+	s.Require().NoError(err)
+	s.Require().True(recorded)
+	s.Require().Equal(model.PAYMENT_CODE_STATUS_PAID, res.Status)
+	s.Require().Equal(1, res.UsageCount)
+
+	_, recorded, err = repo.RecordPayment(context.TODO(), testMerchantId, singleUse.Id, 50000, now)
+	s.Require().NoError(err)
+	s.Require().False(recorded)
+
+	res, recorded, err = repo.RecordPayment(context.TODO(), testMerchantId, capped.Id, 60000, now)
+	s.Require().NoError(err)
+	s.Require().True(recorded)
+	s.Require().Equal(model.PAYMENT_CODE_STATUS_ACTIVE, res.Status)
+	s.Require().Equal(int64(60000), res.TotalPaid)
+
+	_, recorded, err = repo.RecordPayment(context.TODO(), testMerchantId, capped.Id, 50000, now)
+	s.Require().NoError(err)
+	s.Require().False(recorded)
+
+	res, recorded, err = repo.RecordPayment(context.TODO(), testMerchantId, capped.Id, 40000, now)
+	s.Require().NoError(err)
+	s.Require().True(recorded)
+	s.Require().Equal(model.PAYMENT_CODE_STATUS_PAID, res.Status)
+	s.Require().Equal(int64(100000), res.TotalPaid)
+}
+
+func (s paymentCodeRepositoryTestSuite) TestMerchantIsolation() {
+	now := time.Now().UTC()
+	repo := repository.PaymentCodeRepository{Db: s.DBConn}
+	merchantRepo := repository.MerchantRepository{Db: s.DBConn}
+
+	otherMerchant := model.Merchant{Id: "other-merchant", Name: "other", CreatedAt: now, UpdatedAt: now}
+	err := merchantRepo.Create(context.TODO(), &otherMerchant)
+	s.Require().NoError(err)
+
+	mockPaymentCode := CreatePaymentCodePayload()
+	mockPaymentCode.Status = model.PAYMENT_CODE_STATUS_ACTIVE
+	mockPaymentCode.ExpirationDate = now.Add(time.Hour)
+	err = repo.Create(context.TODO(), &mockPaymentCode)
+	s.Require().NoError(err)
+
+	// payment_code is only unique per merchant
+	otherPaymentCode := CreatePaymentCodePayload()
+	otherPaymentCode.MerchantId = otherMerchant.Id
+	otherPaymentCode.PaymentCode = mockPaymentCode.PaymentCode
+	err = repo.Create(context.TODO(), &otherPaymentCode)
+	s.Require().NoError(err)
+
+	_, err = repo.Get(context.TODO(), otherMerchant.Id, mockPaymentCode.Id)
+	s.Require().Equal(repository.ErrNotFound, err)
+
+	res, err := repo.GetByPaymentCode(context.TODO(), otherMerchant.Id, mockPaymentCode.PaymentCode)
+	s.Require().NoError(err)
+	s.Require().Equal(otherPaymentCode.Id, res.Id)
+	s.Require().Equal(otherMerchant.Id, res.MerchantId)
+
+	list, _, err := repo.List(context.TODO(), model.PaymentCodeFilter{MerchantId: otherMerchant.Id, Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(list, 1)
+	s.Require().Equal(otherPaymentCode.Id, list[0].Id)
+
+	updated, err := repo.UpdateStatus(context.TODO(), otherMerchant.Id, mockPaymentCode.Id, model.PAYMENT_CODE_STATUS_ACTIVE, model.PAYMENT_CODE_STATUS_INACTIVE, now)
+	s.Require().NoError(err)
+	s.Require().False(updated)
+
+	_, recorded, err := repo.RecordPayment(context.TODO(), otherMerchant.Id, mockPaymentCode.Id, 50000, now)
+	s.Require().NoError(err)
+	s.Require().False(recorded)
+
+	unknownMerchant := CreatePaymentCodePayload()
+	unknownMerchant.MerchantId = "unknown-merchant"
+	err = repo.Create(context.TODO(), &unknownMerchant)
+	s.Require().True(errors.Is(err, repository.ErrInvalidInput), "expected ErrInvalidInput, got %v", err)
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+)
+
+type IPaymentRepository interface {
+	Create(ctx context.Context, p *model.Payment) (err error)
+}
+
+type PaymentRepository struct {
+	Db     *sql.DB
+	Config config.DB
+}
+
+func (r PaymentRepository) Create(ctx context.Context, p *model.Payment) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err = conn(ctx, r.Db).ExecContext(
+		ctx,
+		"INSERT INTO payments (id, payment_code_id, amount, currency, created_at) VALUES($1, $2, $3, $4, $5)",
+		p.Id, p.PaymentCodeId, p.Amount, p.Currency, p.CreatedAt,
+	)
+	err = classifyError(err)
+
+	return
+}
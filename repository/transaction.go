@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ITransactor runs several repository calls in one database transaction.
+type ITransactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error)
+}
+
+type Transactor struct {
+	Db *sql.DB
+}
+
+type txKey struct{}
+
+// WithinTransaction calls fn with a context carrying a transaction. Every
+// repository called with that context joins the transaction, which is
+// committed when fn returns nil and rolled back otherwise. Nested calls join
+// the outer transaction.
+func (t Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.Db.BeginTx(ctx, nil)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		tx.Rollback()
+		return
+	}
+
+	err = classifyError(tx.Commit())
+
+	return
+}
@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+
+	"github.com/lib/pq"
+)
+
+type IWebhookRepository interface {
+	CreateEndpoint(ctx context.Context, e *model.WebhookEndpoint) (err error)
+	ListEndpoints(ctx context.Context, merchantID string) (endpoints []model.WebhookEndpoint, err error)
+	ListSubscribedEndpoints(ctx context.Context, merchantID string, eventType string) (endpoints []model.WebhookEndpoint, err error)
+	DeleteEndpoint(ctx context.Context, merchantID string, id string) (err error)
+	CreateDelivery(ctx context.Context, d *model.WebhookDelivery) (err error)
+	ListDeliveries(ctx context.Context, filter model.WebhookDeliveryFilter) (deliveries []model.WebhookDelivery, nextCursor string, err error)
+	Redeliver(ctx context.Context, merchantID string, id string, now time.Time) (d model.WebhookDelivery, err error)
+	Claim(ctx context.Context, now time.Time, leasedUntil time.Time, limit int) (deliveries []model.WebhookDelivery, err error)
+	MarkDelivered(ctx context.Context, id string, leasedUntil time.Time, statusCode int, deliveredAt time.Time) (err error)
+	MarkFailed(ctx context.Context, id string, leasedUntil time.Time, statusCode int, lastError string, nextAttemptAt time.Time, now time.Time) (err error)
+	MarkDead(ctx context.Context, id string, leasedUntil time.Time, statusCode int, lastError string, now time.Time) (err error)
+}
+
+type WebhookRepository struct {
+	Db     *sql.DB
+	Config config.DB
+}
+
+const webhookEndpointColumns = "id, merchant_id, url, event_types, signing_secret, created_at, updated_at"
+
+// webhookDeliveryColumns are read from webhook_deliveries joined with its
+// endpoint as e.
+const webhookDeliveryColumns = `d.id, d.merchant_id, d.endpoint_id, e.url, e.signing_secret, d.event_id, d.event_type, d.payload, d.status,
+	d.attempts, d.last_status_code, d.last_error, d.next_attempt_at, d.delivered_at, d.created_at, d.updated_at`
+
+const webhookDeliveryFrom = " FROM webhook_deliveries d JOIN webhook_endpoints e ON e.id = d.endpoint_id"
+
+func scanWebhookEndpoint(row scanner) (e model.WebhookEndpoint, err error) {
+	err = row.Scan(
+		&e.Id,
+		&e.MerchantId,
+		&e.URL,
+		pq.Array(&e.EventTypes),
+		&e.SigningSecret,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	)
+	return
+}
+
+func scanWebhookDelivery(row scanner) (d model.WebhookDelivery, err error) {
+	var lastStatusCode sql.NullInt64
+	var lastError sql.NullString
+	var deliveredAt sql.NullTime
+	err = row.Scan(
+		&d.Id,
+		&d.MerchantId,
+		&d.EndpointId,
+		&d.URL,
+		&d.SigningSecret,
+		&d.EventId,
+		&d.EventType,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&lastStatusCode,
+		&lastError,
+		&d.NextAttemptAt,
+		&deliveredAt,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+	d.LastStatusCode = int(lastStatusCode.Int64)
+	d.LastError = lastError.String
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return
+}
+
+func (r WebhookRepository) CreateEndpoint(ctx context.Context, e *model.WebhookEndpoint) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err = conn(ctx, r.Db).ExecContext(
+		ctx,
+		"INSERT INTO webhook_endpoints ("+webhookEndpointColumns+") VALUES($1, $2, $3, $4, $5, $6, $7)",
+		e.Id, e.MerchantId, e.URL, pq.Array(e.EventTypes), e.SigningSecret, e.CreatedAt, e.UpdatedAt,
+	)
+	err = classifyError(err)
+
+	return
+}
+
+// ListEndpoints returns every endpoint of the merchant, oldest first.
+func (r WebhookRepository) ListEndpoints(ctx context.Context, merchantID string) (endpoints []model.WebhookEndpoint, err error) {
+	return r.listEndpoints(ctx, "merchant_id = $1", merchantID)
+}
+
+// ListSubscribedEndpoints returns the endpoints of the merchant that are sent
+// events of eventType.
+func (r WebhookRepository) ListSubscribedEndpoints(ctx context.Context, merchantID string, eventType string) (endpoints []model.WebhookEndpoint, err error) {
+	return r.listEndpoints(ctx, "merchant_id = $1 AND $2 = ANY(event_types)", merchantID, eventType)
+}
+
+func (r WebhookRepository) listEndpoints(ctx context.Context, condition string, args ...interface{}) (endpoints []model.WebhookEndpoint, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := conn(ctx, r.Db).QueryContext(
+		ctx,
+		"SELECT "+webhookEndpointColumns+" FROM webhook_endpoints WHERE "+condition+" ORDER BY created_at, id",
+		args...,
+	)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e model.WebhookEndpoint
+		e, err = scanWebhookEndpoint(rows)
+		if err != nil {
+			err = classifyError(err)
+			return
+		}
+		endpoints = append(endpoints, e)
+	}
+
+	err = classifyError(rows.Err())
+
+	return
+}
+
+// DeleteEndpoint deletes an endpoint of the merchant along with its
+// deliveries. It returns ErrNotFound when the merchant has no such endpoint.
+func (r WebhookRepository) DeleteEndpoint(ctx context.Context, merchantID string, id string) (err error) {
+	return r.update(ctx, "webhook endpoint", "DELETE FROM webhook_endpoints WHERE id = $1 AND merchant_id = $2", id, merchantID)
+}
+
+// CreateDelivery queues a delivery. Call it within the transaction that
+// changes the data the event is about, so both are committed together. It
+// returns ErrConflict when the event is already queued for the endpoint.
+func (r WebhookRepository) CreateDelivery(ctx context.Context, d *model.WebhookDelivery) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err = conn(ctx, r.Db).ExecContext(
+		ctx,
+		`INSERT INTO webhook_deliveries (id, merchant_id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		d.Id, d.MerchantId, d.EndpointId, d.EventId, d.EventType, []byte(d.Payload), d.Status, d.Attempts, d.NextAttemptAt, d.CreatedAt, d.UpdatedAt,
+	)
+	err = classifyError(err)
+
+	return
+}
+
+// ListDeliveries returns a page of the deliveries matching filter, newest
+// first, and the cursor of the next page when there is one. A cursor listed
+// with other filters is ErrInvalidCursor.
+func (r WebhookRepository) ListDeliveries(ctx context.Context, filter model.WebhookDeliveryFilter) (deliveries []model.WebhookDelivery, nextCursor string, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	where("d.merchant_id = $%d", filter.MerchantId)
+	if filter.EndpointId != "" {
+		where("d.endpoint_id = $%d", filter.EndpointId)
+	}
+	if filter.Status != "" {
+		where("d.status = $%d", filter.Status)
+	}
+
+	listQuery := cursorQuery(filter.EndpointId, filter.Status)
+	if filter.Cursor != "" {
+		var c cursor
+		c, err = decodeCursor(filter.Cursor, listQuery)
+		if err != nil {
+			return
+		}
+		args = append(args, c.CreatedAt, c.Id)
+		conditions = append(conditions, fmt.Sprintf("(d.created_at, d.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := "SELECT " + webhookDeliveryColumns + webhookDeliveryFrom + " WHERE " + strings.Join(conditions, " AND ")
+
+	// one extra row tells whether there is a next page
+	args = append(args, filter.Limit+1)
+	query += fmt.Sprintf(" ORDER BY d.created_at DESC, d.id DESC LIMIT $%d", len(args))
+
+	deliveries, err = r.queryDeliveries(ctx, query, args...)
+	if err != nil {
+		return
+	}
+
+	if len(deliveries) > filter.Limit {
+		deliveries = deliveries[:filter.Limit]
+		last := deliveries[len(deliveries)-1]
+		nextCursor = encodeCursor(cursor{CreatedAt: last.CreatedAt, Id: last.Id, Query: listQuery})
+	}
+
+	return
+}
+
+// Redeliver queues a delivery of the merchant again, whatever its status,
+// with a fresh set of attempts. It returns ErrNotFound when the merchant has
+// no such delivery.
+func (r WebhookRepository) Redeliver(ctx context.Context, merchantID string, id string, now time.Time) (d model.WebhookDelivery, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	row := conn(ctx, r.Db).QueryRowContext(
+		ctx,
+		`UPDATE webhook_deliveries d SET status = $1, attempts = 0, next_attempt_at = $2, updated_at = $2
+		FROM webhook_endpoints e
+		WHERE e.id = d.endpoint_id AND d.id = $3 AND d.merchant_id = $4
+		RETURNING `+webhookDeliveryColumns,
+		model.WEBHOOK_DELIVERY_STATUS_PENDING, now, id, merchantID,
+	)
+
+	d, err = scanWebhookDelivery(row)
+	err = classifyError(err)
+
+	return
+}
+
+// Claim leases up to limit pending deliveries that are due, oldest first, by
+// moving their next attempt to leasedUntil, and returns them with that lease.
+// Deliveries are claimed in a single statement, so no lock is held while they
+// are sent; another dispatcher only picks one up again once its lease is over.
+func (r WebhookRepository) Claim(ctx context.Context, now time.Time, leasedUntil time.Time, limit int) (deliveries []model.WebhookDelivery, err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	return r.queryDeliveries(
+		ctx,
+		`UPDATE webhook_deliveries d SET next_attempt_at = $3, updated_at = $2
+		FROM webhook_endpoints e
+		WHERE e.id = d.endpoint_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $1 AND next_attempt_at <= $2
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+webhookDeliveryColumns,
+		model.WEBHOOK_DELIVERY_STATUS_PENDING, now, leasedUntil, limit,
+	)
+}
+
+func (r WebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) (deliveries []model.WebhookDelivery, err error) {
+	rows, err := conn(ctx, r.Db).QueryContext(ctx, query, args...)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d model.WebhookDelivery
+		d, err = scanWebhookDelivery(rows)
+		if err != nil {
+			err = classifyError(err)
+			return
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	err = classifyError(rows.Err())
+
+	return
+}
+
+// MarkDelivered records a successful attempt. leasedUntil is the lease the
+// delivery was claimed with: like MarkFailed and MarkDead, it returns
+// ErrNotFound when the lease was lost to another dispatcher or a redelivery.
+func (r WebhookRepository) MarkDelivered(ctx context.Context, id string, leasedUntil time.Time, statusCode int, deliveredAt time.Time) (err error) {
+	return r.update(
+		ctx,
+		"webhook delivery lease",
+		`UPDATE webhook_deliveries SET status = $1, attempts = attempts + 1, last_status_code = $2, last_error = NULL, delivered_at = $3, updated_at = $3
+		WHERE id = $4 AND status = $5 AND next_attempt_at = $6`,
+		model.WEBHOOK_DELIVERY_STATUS_DELIVERED, statusCode, deliveredAt, id, model.WEBHOOK_DELIVERY_STATUS_PENDING, leasedUntil,
+	)
+}
+
+// MarkFailed records a failed attempt and schedules the next one. statusCode
+// is zero when the endpoint could not be reached.
+func (r WebhookRepository) MarkFailed(ctx context.Context, id string, leasedUntil time.Time, statusCode int, lastError string, nextAttemptAt time.Time, now time.Time) (err error) {
+	return r.update(
+		ctx,
+		"webhook delivery lease",
+		`UPDATE webhook_deliveries SET attempts = attempts + 1, last_status_code = NULLIF($1, 0), last_error = $2, next_attempt_at = $3, updated_at = $4
+		WHERE id = $5 AND status = $6 AND next_attempt_at = $7`,
+		statusCode, lastError, nextAttemptAt, now, id, model.WEBHOOK_DELIVERY_STATUS_PENDING, leasedUntil,
+	)
+}
+
+// MarkDead records a failed attempt and moves the delivery to the dead letter
+// state, where it stays until it is redelivered by hand.
+func (r WebhookRepository) MarkDead(ctx context.Context, id string, leasedUntil time.Time, statusCode int, lastError string, now time.Time) (err error) {
+	return r.update(
+		ctx,
+		"webhook delivery lease",
+		`UPDATE webhook_deliveries SET status = $1, attempts = attempts + 1, last_status_code = NULLIF($2, 0), last_error = $3, updated_at = $4
+		WHERE id = $5 AND status = $6 AND next_attempt_at = $7`,
+		model.WEBHOOK_DELIVERY_STATUS_DEAD, statusCode, lastError, now, id, model.WEBHOOK_DELIVERY_STATUS_PENDING, leasedUntil,
+	)
+}
+
+func (r WebhookRepository) update(ctx context.Context, what string, query string, args ...interface{}) (err error) {
+	ctx, cancel := withQueryTimeout(ctx, r.Config)
+	defer cancel()
+
+	res, err := conn(ctx, r.Db).ExecContext(ctx, query, args...)
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	rowAffected, err := res.RowsAffected()
+	if err != nil {
+		err = classifyError(err)
+		return
+	}
+
+	if rowAffected != 1 {
+		err = fmt.Errorf("%w: %s", ErrNotFound, what)
+		return
+	}
+
+	return
+}
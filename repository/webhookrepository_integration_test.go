@@ -0,0 +1,197 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	postgresTest "github.com/pevin/pevin-golang-training-beginner/postgres"
+	repository "github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type webhookRepositoryTestSuite struct {
+	postgresTest.Suite
+}
+
+func TestSuiteWebhookRepository(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		dsn = postgresTest.DefaultTestDsn
+	}
+
+	webhookRepoSuite := &webhookRepositoryTestSuite{
+		postgresTest.Suite{
+			DSN:                     dsn,
+			MigrationLocationFolder: "../db/migrations",
+		},
+	}
+
+	suite.Run(t, webhookRepoSuite)
+}
+
+func (s webhookRepositoryTestSuite) BeforeTest(suiteName, testName string) {
+	ok, err := s.Migration.Up()
+	s.Require().NoError(err)
+	s.Require().True(ok)
+}
+
+func (s webhookRepositoryTestSuite) AfterTest(suiteName, testName string) {
+	ok, err := s.Migration.Down()
+	s.Require().NoError(err)
+	s.Require().True(ok)
+}
+
+func (s webhookRepositoryTestSuite) createEndpoint(repo repository.WebhookRepository, id string, eventTypes []string, now time.Time) model.WebhookEndpoint {
+	endpoint := model.WebhookEndpoint{
+		Id:            id,
+		MerchantId:    testMerchantId,
+		URL:           "https://example.com/" + id,
+		EventTypes:    eventTypes,
+		SigningSecret: "test-secret-" + id,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.Require().NoError(repo.CreateEndpoint(context.TODO(), &endpoint))
+	return endpoint
+}
+
+func newTestWebhookDelivery(id string, endpointId string, now time.Time) model.WebhookDelivery {
+	return model.WebhookDelivery{
+		Id:            id,
+		MerchantId:    testMerchantId,
+		EndpointId:    endpointId,
+		EventId:       "event-" + id,
+		EventType:     model.EVENT_TYPE_PAYMENT_RECEIVED,
+		Payload:       []byte(`{"id": "event"}`),
+		Status:        model.WEBHOOK_DELIVERY_STATUS_PENDING,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+func (s webhookRepositoryTestSuite) TestEndpoints() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	repo := repository.WebhookRepository{Db: s.DBConn}
+
+	paid := s.createEndpoint(repo, "paid-id", []string{model.EVENT_TYPE_PAYMENT_RECEIVED}, now)
+	s.createEndpoint(repo, "expired-id", []string{model.EVENT_TYPE_PAYMENT_CODE_EXPIRED}, now.Add(time.Second))
+
+	res, err := repo.ListEndpoints(context.TODO(), testMerchantId)
+	s.Require().NoError(err)
+	s.Require().Len(res, 2)
+	s.Require().Equal(paid.Id, res[0].Id)
+	s.Require().Equal(paid.URL, res[0].URL)
+	s.Require().Equal(paid.EventTypes, res[0].EventTypes)
+	s.Require().Equal(paid.SigningSecret, res[0].SigningSecret)
+
+	res, err = repo.ListSubscribedEndpoints(context.TODO(), testMerchantId, model.EVENT_TYPE_PAYMENT_RECEIVED)
+	s.Require().NoError(err)
+	s.Require().Len(res, 1)
+	s.Require().Equal(paid.Id, res[0].Id)
+
+	res, err = repo.ListSubscribedEndpoints(context.TODO(), "other-merchant", model.EVENT_TYPE_PAYMENT_RECEIVED)
+	s.Require().NoError(err)
+	s.Require().Empty(res)
+
+	err = repo.DeleteEndpoint(context.TODO(), "other-merchant", paid.Id)
+	s.Require().True(errors.Is(err, repository.ErrNotFound), "expected ErrNotFound, got %v", err)
+
+	delivery := newTestWebhookDelivery("test-id", paid.Id, now)
+	s.Require().NoError(repo.CreateDelivery(context.TODO(), &delivery))
+
+	err = repo.DeleteEndpoint(context.TODO(), testMerchantId, paid.Id)
+	s.Require().NoError(err)
+
+	deliveries, _, err := repo.ListDeliveries(context.TODO(), model.WebhookDeliveryFilter{MerchantId: testMerchantId, Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Empty(deliveries)
+}
+
+func (s webhookRepositoryTestSuite) TestDeliveries() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	repo := repository.WebhookRepository{Db: s.DBConn}
+
+	endpoint := s.createEndpoint(repo, "endpoint-id", []string{model.EVENT_TYPE_PAYMENT_RECEIVED}, now)
+
+	first := newTestWebhookDelivery("first-id", endpoint.Id, now)
+	second := newTestWebhookDelivery("second-id", endpoint.Id, now.Add(time.Second))
+	s.Require().NoError(repo.CreateDelivery(context.TODO(), &first))
+	s.Require().NoError(repo.CreateDelivery(context.TODO(), &second))
+
+	duplicate := newTestWebhookDelivery("duplicate-id", endpoint.Id, now)
+	duplicate.EventId = first.EventId
+	err := repo.CreateDelivery(context.TODO(), &duplicate)
+	s.Require().True(errors.Is(err, repository.ErrConflict), "expected ErrConflict, got %v", err)
+
+	// newest first, one per page
+	page, nextCursor, err := repo.ListDeliveries(context.TODO(), model.WebhookDeliveryFilter{MerchantId: testMerchantId, Limit: 1})
+	s.Require().NoError(err)
+	s.Require().Len(page, 1)
+	s.Require().Equal(second.Id, page[0].Id)
+	s.Require().Equal(endpoint.URL, page[0].URL)
+	s.Require().NotEmpty(nextCursor)
+
+	page, nextCursor, err = repo.ListDeliveries(context.TODO(), model.WebhookDeliveryFilter{MerchantId: testMerchantId, Cursor: nextCursor, Limit: 1})
+	s.Require().NoError(err)
+	s.Require().Len(page, 1)
+	s.Require().Equal(first.Id, page[0].Id)
+	s.Require().Empty(nextCursor)
+
+	leasedUntil := now.Add(time.Minute)
+	claimed, err := repo.Claim(context.TODO(), now, leasedUntil, 10)
+	s.Require().NoError(err)
+	s.Require().Len(claimed, 1)
+	s.Require().Equal(first.Id, claimed[0].Id)
+	s.Require().Equal(endpoint.SigningSecret, claimed[0].SigningSecret)
+	s.Require().True(leasedUntil.Equal(claimed[0].NextAttemptAt))
+	s.Require().JSONEq(string(first.Payload), string(claimed[0].Payload))
+
+	// leased deliveries are not claimed again until the lease is over
+	claimed, err = repo.Claim(context.TODO(), now.Add(time.Second), leasedUntil, 10)
+	s.Require().NoError(err)
+	s.Require().Len(claimed, 1)
+	s.Require().Equal(second.Id, claimed[0].Id)
+
+	err = repo.MarkDelivered(context.TODO(), first.Id, now, 200, now)
+	s.Require().True(errors.Is(err, repository.ErrNotFound), "expected ErrNotFound for a lost lease, got %v", err)
+
+	s.Require().NoError(repo.MarkDelivered(context.TODO(), first.Id, leasedUntil, 200, now))
+	s.Require().NoError(repo.MarkFailed(context.TODO(), second.Id, leasedUntil, 500, "endpoint answered 500", now.Add(time.Hour), now))
+
+	claimed, err = repo.Claim(context.TODO(), now.Add(time.Minute), now.Add(2*time.Minute), 10)
+	s.Require().NoError(err)
+	s.Require().Empty(claimed)
+
+	claimed, err = repo.Claim(context.TODO(), now.Add(time.Hour), now.Add(time.Hour+time.Minute), 10)
+	s.Require().NoError(err)
+	s.Require().Len(claimed, 1)
+	s.Require().NoError(repo.MarkDead(context.TODO(), second.Id, claimed[0].NextAttemptAt, 0, "connection refused", now))
+
+	page, _, err = repo.ListDeliveries(context.TODO(), model.WebhookDeliveryFilter{MerchantId: testMerchantId, Status: model.WEBHOOK_DELIVERY_STATUS_DEAD, Limit: 10})
+	s.Require().NoError(err)
+	s.Require().Len(page, 1)
+	s.Require().Equal(second.Id, page[0].Id)
+	s.Require().Equal(2, page[0].Attempts)
+	s.Require().Equal(0, page[0].LastStatusCode)
+	s.Require().Equal("connection refused", page[0].LastError)
+
+	_, err = repo.Redeliver(context.TODO(), "other-merchant", second.Id, now)
+	s.Require().Equal(repository.ErrNotFound, err)
+
+	redeliveredAt := now.Add(2 * time.Hour)
+	redelivered, err := repo.Redeliver(context.TODO(), testMerchantId, second.Id, redeliveredAt)
+	s.Require().NoError(err)
+	s.Require().Equal(model.WEBHOOK_DELIVERY_STATUS_PENDING, redelivered.Status)
+	s.Require().Equal(0, redelivered.Attempts)
+
+	claimed, err = repo.Claim(context.TODO(), redeliveredAt, redeliveredAt.Add(time.Minute), 10)
+	s.Require().NoError(err)
+	s.Require().Len(claimed, 1)
+	s.Require().Equal(second.Id, claimed[0].Id)
+}
@@ -0,0 +1,223 @@
+// Package signature signs and verifies requests with HMAC-SHA256 so callers
+// on untrusted networks can prove a request was not altered on the way. The
+// signature covers the canonical string of the request:
+//
+//	METHOD\nREQUEST-URI\nTIMESTAMP\nNONCE\nHEX(SHA-256(BODY))
+//
+// where REQUEST-URI is the path and query as sent, TIMESTAMP is Unix seconds
+// and NONCE is a random string used once. The timestamp, the nonce and the
+// hex encoded signature travel in the headers below.
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HEADER_SIGNATURE = "X-Signature"
+	HEADER_TIMESTAMP = "X-Signature-Timestamp"
+	HEADER_NONCE     = "X-Signature-Nonce"
+
+	// MAX_NONCE_LENGTH bounds the nonces the verifier remembers.
+	MAX_NONCE_LENGTH = 128
+)
+
+var (
+	ErrMissing   = errors.New("signature headers are missing")
+	ErrMalformed = errors.New("signature headers are malformed")
+	ErrExpired   = errors.New("signature timestamp is outside the allowed window")
+	ErrMismatch  = errors.New("signature does not match the request")
+	ErrReplayed  = errors.New("signature nonce has already been used")
+)
+
+// CanonicalString returns the string signed for a request.
+func CanonicalString(method string, requestURI string, timestamp string, nonce string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		requestURI,
+		timestamp,
+		nonce,
+		hex.EncodeToString(sum[:]),
+	}, "\n")
+}
+
+// Compute returns the hex encoded HMAC-SHA256 of canonical under secret.
+func Compute(secret string, canonical string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewNonce returns a random nonce.
+func NewNonce() (nonce string, err error) {
+	b := make([]byte, 16)
+	_, err = rand.Read(b)
+	if err != nil {
+		return
+	}
+	nonce = hex.EncodeToString(b)
+	return
+}
+
+// Sign sets the signature headers of r. The body is read and replaced, so r
+// can still be sent.
+func Sign(r *http.Request, secret string, now time.Time, nonce string) (err error) {
+	body, err := readBody(r)
+	if err != nil {
+		return
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	canonical := CanonicalString(r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+
+	r.Header.Set(HEADER_TIMESTAMP, timestamp)
+	r.Header.Set(HEADER_NONCE, nonce)
+	r.Header.Set(HEADER_SIGNATURE, Compute(secret, canonical))
+
+	return
+}
+
+// Signed reports whether r carries any of the signature headers.
+func Signed(r *http.Request) bool {
+	return r.Header.Get(HEADER_SIGNATURE) != "" || r.Header.Get(HEADER_TIMESTAMP) != "" || r.Header.Get(HEADER_NONCE) != ""
+}
+
+// Verifier checks signed requests. A timestamp is accepted within Window of
+// the server time in either direction, and a nonce is accepted once per key
+// for as long as its timestamp is.
+type Verifier struct {
+	Window time.Duration
+	Nonces *NonceCache
+}
+
+// Verify checks the signature of r, whose body has already been read into
+// body, under secret. keyID scopes the nonce, so two keys may use the same
+// one.
+func (v Verifier) Verify(r *http.Request, body []byte, keyID string, secret string, now time.Time) (err error) {
+	sig := r.Header.Get(HEADER_SIGNATURE)
+	timestamp := r.Header.Get(HEADER_TIMESTAMP)
+	nonce := r.Header.Get(HEADER_NONCE)
+	if sig == "" || timestamp == "" || nonce == "" {
+		return ErrMissing
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(nonce) > MAX_NONCE_LENGTH {
+		return ErrMalformed
+	}
+
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-v.Window)) || signedAt.After(now.Add(v.Window)) {
+		return ErrExpired
+	}
+
+	expected := Compute(secret, CanonicalString(r.Method, r.URL.RequestURI(), timestamp, nonce, body))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(sig))) {
+		return ErrMismatch
+	}
+
+	// The nonce is only remembered once the signature is known to be good,
+	// so nobody can burn the nonces of a key without its secret.
+	if !v.Nonces.Add(keyID+":"+nonce, signedAt.Add(v.Window), now) {
+		return ErrReplayed
+	}
+
+	return
+}
+
+// NonceCache remembers nonces until they expire. It lives in memory, so each
+// instance of the service only rejects the replays it sees itself.
+type NonceCache struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	lastPrune time.Time
+}
+
+func NewNonceCache() *NonceCache {
+	return &NonceCache{expires: map[string]time.Time{}}
+}
+
+// Add remembers nonce until expiresAt and reports whether it was new. Expired
+// nonces are dropped at most once a minute.
+func (c *NonceCache) Add(nonce string, expiresAt time.Time, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastPrune) >= time.Minute {
+		for n, e := range c.expires {
+			if !e.After(now) {
+				delete(c.expires, n)
+			}
+		}
+		c.lastPrune = now
+	}
+
+	if e, ok := c.expires[nonce]; ok && e.After(now) {
+		return false
+	}
+	c.expires[nonce] = expiresAt
+	return true
+}
+
+// Transport is an http.RoundTripper for Go clients of the service. It sends
+// APIKey as a bearer token and signs every request with SigningSecret.
+type Transport struct {
+	APIKey        string
+	SigningSecret string
+	// Base sends the signed requests; http.DefaultTransport when nil.
+	Base http.RoundTripper
+}
+
+func (t Transport) RoundTrip(r *http.Request) (resp *http.Response, err error) {
+	nonce, err := NewNonce()
+	if err != nil {
+		return
+	}
+
+	// A RoundTripper must not modify the request it is given.
+	signed := r.Clone(r.Context())
+	signed.Header.Set("Authorization", "Bearer "+t.APIKey)
+	err = Sign(signed, t.SigningSecret, time.Now(), nonce)
+	if err != nil {
+		return
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
+
+// readBody reads the body of r and replaces it with a copy.
+func readBody(r *http.Request) (body []byte, err error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return
+	}
+
+	body, err = ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return
+}
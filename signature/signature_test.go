@@ -0,0 +1,221 @@
+package signature
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "ss_test"
+
+func newRequest(t *testing.T, method string, url string, body string) *http.Request {
+	r, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestCanonicalString(t *testing.T) {
+	got := CanonicalString("post", "/payment-codes?a=1", "1700000000", "test-nonce", []byte(""))
+	want := "POST\n/payment-codes?a=1\n1700000000\ntest-nonce\ne3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if got != want {
+		t.Errorf("CanonicalString() = %q, want %q", got, want)
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	window := 5 * time.Minute
+
+	signed := func(method string, url string, body string, signedAt time.Time, nonce string) *http.Request {
+		r := newRequest(t, method, url, body)
+		if err := Sign(r, testSecret, signedAt, nonce); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		r       func() *http.Request
+		body    string
+		secret  string
+		wantErr error
+	}{
+		{
+			name: "valid",
+			r: func() *http.Request {
+				return signed("POST", "http://localhost/payment-codes", `{"name":"test"}`, now, "test-nonce")
+			},
+			body: `{"name":"test"}`,
+		},
+		{
+			name: "within-window",
+			r: func() *http.Request {
+				return signed("GET", "http://localhost/payment-codes?limit=1", "", now.Add(-window), "test-nonce")
+			},
+		},
+		{
+			name: "missing",
+			r: func() *http.Request {
+				return newRequest(t, "GET", "http://localhost/payment-codes", "")
+			},
+			wantErr: ErrMissing,
+		},
+		{
+			name: "malformed-timestamp",
+			r: func() *http.Request {
+				r := signed("GET", "http://localhost/payment-codes", "", now, "test-nonce")
+				r.Header.Set(HEADER_TIMESTAMP, now.Format(time.RFC3339))
+				return r
+			},
+			wantErr: ErrMalformed,
+		},
+		{
+			name: "long-nonce",
+			r: func() *http.Request {
+				return signed("GET", "http://localhost/payment-codes", "", now, strings.Repeat("n", MAX_NONCE_LENGTH+1))
+			},
+			wantErr: ErrMalformed,
+		},
+		{
+			name: "too-old",
+			r: func() *http.Request {
+				return signed("GET", "http://localhost/payment-codes", "", now.Add(-window-time.Second), "test-nonce")
+			},
+			wantErr: ErrExpired,
+		},
+		{
+			name: "too-new",
+			r: func() *http.Request {
+				return signed("GET", "http://localhost/payment-codes", "", now.Add(window+time.Second), "test-nonce")
+			},
+			wantErr: ErrExpired,
+		},
+		{
+			name: "tampered-body",
+			r: func() *http.Request {
+				return signed("POST", "http://localhost/payment-codes", `{"name":"test"}`, now, "test-nonce")
+			},
+			body:    `{"name":"other"}`,
+			wantErr: ErrMismatch,
+		},
+		{
+			name: "tampered-query",
+			r: func() *http.Request {
+				r := signed("GET", "http://localhost/payment-codes?limit=1", "", now, "test-nonce")
+				r.URL.RawQuery = "limit=100"
+				return r
+			},
+			wantErr: ErrMismatch,
+		},
+		{
+			name: "tampered-method",
+			r: func() *http.Request {
+				r := signed("GET", "http://localhost/payment-codes/test-id", "", now, "test-nonce")
+				r.Method = "DELETE"
+				return r
+			},
+			wantErr: ErrMismatch,
+		},
+		{
+			name: "other-secret",
+			r: func() *http.Request {
+				return signed("GET", "http://localhost/payment-codes", "", now, "test-nonce")
+			},
+			secret:  "ss_other",
+			wantErr: ErrMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Verifier{Window: window, Nonces: NewNonceCache()}
+			secret := tt.secret
+			if secret == "" {
+				secret = testSecret
+			}
+			err := v.Verify(tt.r(), []byte(tt.body), "test-id", secret, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verifier.Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifier_Verify_replay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	v := Verifier{Window: 5 * time.Minute, Nonces: NewNonceCache()}
+
+	r := newRequest(t, "GET", "http://localhost/payment-codes", "")
+	if err := Sign(r, testSecret, now, "test-nonce"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Verify(r, nil, "test-id", testSecret, now); err != nil {
+		t.Fatalf("Verifier.Verify() error = %v", err)
+	}
+	if err := v.Verify(r, nil, "test-id", testSecret, now.Add(time.Minute)); !errors.Is(err, ErrReplayed) {
+		t.Errorf("Verifier.Verify() replay error = %v, want %v", err, ErrReplayed)
+	}
+	if err := v.Verify(r, nil, "other-id", testSecret, now); err != nil {
+		t.Errorf("Verifier.Verify() other key error = %v, want nil", err)
+	}
+}
+
+func TestNonceCache_Add(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := NewNonceCache()
+
+	if !c.Add("nonce", now.Add(time.Minute), now) {
+		t.Fatal("NonceCache.Add() = false for a new nonce")
+	}
+	if c.Add("nonce", now.Add(time.Minute), now.Add(time.Second)) {
+		t.Error("NonceCache.Add() = true for a remembered nonce")
+	}
+	if !c.Add("nonce", now.Add(3*time.Minute), now.Add(2*time.Minute)) {
+		t.Error("NonceCache.Add() = false for an expired nonce")
+	}
+
+	c.Add("stale", now, now)
+	c.Add("other", now.Add(10*time.Minute), now.Add(5*time.Minute))
+	if _, ok := c.expires["stale"]; ok {
+		t.Error("NonceCache.Add() kept an expired nonce after pruning")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got *http.Request
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: Transport{APIKey: "pk_test", SigningSecret: testSecret}}
+	req := newRequest(t, "POST", server.URL+"/payment-codes?a=1", `{"name":"test"}`)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if auth := got.Header.Get("Authorization"); auth != "Bearer pk_test" {
+		t.Errorf("Transport sent Authorization = %q, want Bearer pk_test", auth)
+	}
+	if req.Header.Get(HEADER_SIGNATURE) != "" {
+		t.Error("Transport modified the request it was given")
+	}
+
+	unix, _ := strconv.ParseInt(got.Header.Get(HEADER_TIMESTAMP), 10, 64)
+	v := Verifier{Window: time.Minute, Nonces: NewNonceCache()}
+	if err := v.Verify(got, gotBody, "test-id", testSecret, time.Unix(unix, 0)); err != nil {
+		t.Errorf("Transport signature does not verify: %v", err)
+	}
+}
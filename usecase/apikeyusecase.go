@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/google/uuid"
+)
+
+type IAPIKeyUseCase interface {
+	Create(ctx context.Context, k *model.APIKey) (err error)
+	List(ctx context.Context) (list model.APIKeyList, err error)
+	Rotate(ctx context.Context, id string) (k model.APIKey, err error)
+	Revoke(ctx context.Context, id string) (k model.APIKey, err error)
+	Authenticate(ctx context.Context, key string) (k model.APIKey, err error)
+}
+
+// An API key is API_KEY_PREFIX, the prefix the key is looked up by, an
+// underscore and the secret, e.g. pk_1f2e3d4c5b6a7988_<43 characters>. A
+// signing secret is SIGNING_SECRET_PREFIX and 43 random characters.
+const (
+	API_KEY_PREFIX        = "pk_"
+	SIGNING_SECRET_PREFIX = "ss_"
+
+	apiKeyPrefixBytes  = 8
+	apiKeySecretBytes  = 32
+	signingSecretBytes = 32
+)
+
+// LastUsedResolution is how stale the last use of a key may get before
+// Authenticate records a new one.
+const LastUsedResolution = time.Minute
+
+type APIKeyUseCase struct {
+	Repo repository.IAPIKeyRepository
+}
+
+// Create stores a new key for the merchant in ctx and sets k.Key and
+// k.SigningSecret, which cannot be read again.
+func (u APIKeyUseCase) Create(ctx context.Context, k *model.APIKey) (err error) {
+	merchantID := MerchantIDFromContext(ctx)
+	if merchantID == "" {
+		err = ErrMerchantRequired
+		return
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return
+	}
+
+	k.Id = id.String()
+	k.MerchantId = merchantID
+	k.Key, k.Prefix, k.KeyHash, err = newAPIKey()
+	if err != nil {
+		return
+	}
+	k.SigningSecret, err = newSigningSecret()
+	if err != nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	k.CreatedAt = now
+	k.UpdatedAt = now
+	k.LastUsedAt = nil
+	k.RevokedAt = nil
+
+	err = u.Repo.Create(ctx, k)
+
+	return
+}
+
+// List returns every key of the merchant in ctx, without their secrets.
+func (u APIKeyUseCase) List(ctx context.Context) (list model.APIKeyList, err error) {
+	apiKeys, err := u.Repo.List(ctx, MerchantIDFromContext(ctx))
+	if err != nil {
+		return
+	}
+
+	list.Data = make([]model.APIKey, 0, len(apiKeys))
+	for _, k := range apiKeys {
+		list.Data = append(list.Data, k.WithoutSecrets())
+	}
+
+	return
+}
+
+// Rotate gives a key of the merchant in ctx a new key and signing secret,
+// returned in k, and keeps its name and scopes. The old ones stop working at
+// once.
+func (u APIKeyUseCase) Rotate(ctx context.Context, id string) (k model.APIKey, err error) {
+	key, prefix, keyHash, err := newAPIKey()
+	if err != nil {
+		return
+	}
+	signingSecret, err := newSigningSecret()
+	if err != nil {
+		return
+	}
+
+	k, err = u.Repo.Rotate(ctx, MerchantIDFromContext(ctx), id, prefix, keyHash, signingSecret, time.Now().UTC())
+	if err != nil {
+		return
+	}
+	k.Key = key
+
+	return
+}
+
+// Revoke revokes a key of the merchant in ctx.
+func (u APIKeyUseCase) Revoke(ctx context.Context, id string) (k model.APIKey, err error) {
+	k, err = u.Repo.Revoke(ctx, MerchantIDFromContext(ctx), id, time.Now().UTC())
+	k = k.WithoutSecrets()
+	return
+}
+
+// Authenticate returns the unrevoked key matching key, with its signing
+// secret, and records its use. It returns ErrInvalidAPIKey for a malformed,
+// unknown or revoked key.
+func (u APIKeyUseCase) Authenticate(ctx context.Context, key string) (k model.APIKey, err error) {
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		err = ErrInvalidAPIKey
+		return
+	}
+
+	k, err = u.Repo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, repository.ErrNotFound) {
+		err = ErrInvalidAPIKey
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(k.KeyHash)) != 1 || k.Revoked() {
+		k = model.APIKey{}
+		err = ErrInvalidAPIKey
+		return
+	}
+
+	now := time.Now().UTC()
+	err = u.Repo.TouchLastUsed(ctx, k.Id, now, now.Add(-LastUsedResolution))
+
+	return
+}
+
+// newAPIKey returns a random key with its prefix and hash.
+func newAPIKey() (key string, prefix string, keyHash string, err error) {
+	b := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	_, err = rand.Read(b)
+	if err != nil {
+		return
+	}
+
+	prefix = hex.EncodeToString(b[:apiKeyPrefixBytes])
+	key = API_KEY_PREFIX + prefix + "_" + base64.RawURLEncoding.EncodeToString(b[apiKeyPrefixBytes:])
+	keyHash = hashAPIKey(key)
+
+	return
+}
+
+// newSigningSecret returns a random signing secret.
+func newSigningSecret() (secret string, err error) {
+	b := make([]byte, signingSecretBytes)
+	_, err = rand.Read(b)
+	if err != nil {
+		return
+	}
+
+	secret = SIGNING_SECRET_PREFIX + base64.RawURLEncoding.EncodeToString(b)
+
+	return
+}
+
+// parseAPIKey returns the prefix of key, or false when key is not shaped like
+// an API key.
+func parseAPIKey(key string) (prefix string, ok bool) {
+	if !strings.HasPrefix(key, API_KEY_PREFIX) {
+		return
+	}
+
+	rest := strings.TrimPrefix(key, API_KEY_PREFIX)
+	i := strings.Index(rest, "_")
+	if i != hex.EncodedLen(apiKeyPrefixBytes) || len(rest) == i+1 {
+		return
+	}
+
+	return rest[:i], true
+}
+
+// hashAPIKey hashes the whole key. Keys are random, so a plain SHA-256 is
+// enough to keep them from being read back out of the database.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
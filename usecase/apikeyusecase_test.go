@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	mock_repository "github.com/pevin/pevin-golang-training-beginner/mock/repository"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestAPIKeyUseCase_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		repo    func() repository.IAPIKeyRepository
+		wantErr error
+	}{
+		{
+			name: "created",
+			ctx:  merchantCtx,
+			repo: func() repository.IAPIKeyRepository {
+				repo := mock_repository.NewMockIAPIKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "without-merchant",
+			ctx:  context.TODO(),
+			repo: func() repository.IAPIKeyRepository {
+				return mock_repository.NewMockIAPIKeyRepository(ctrl)
+			},
+			wantErr: ErrMerchantRequired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := APIKeyUseCase{Repo: tt.repo()}
+			k := model.APIKey{Name: "test name", Scopes: []string{model.API_KEY_SCOPE_READ}}
+			err := u.Create(tt.ctx, &k)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("APIKeyUseCase.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if k.MerchantId != "test-merchant" {
+				t.Errorf("APIKeyUseCase.Create() merchant = %v, want test-merchant", k.MerchantId)
+			}
+			prefix, ok := parseAPIKey(k.Key)
+			if !ok || prefix != k.Prefix || !strings.HasPrefix(k.Key, API_KEY_PREFIX) {
+				t.Errorf("APIKeyUseCase.Create() key = %v, prefix = %v", k.Key, k.Prefix)
+			}
+			if k.KeyHash != hashAPIKey(k.Key) {
+				t.Errorf("APIKeyUseCase.Create() hash = %v, want the hash of the key", k.KeyHash)
+			}
+			if !strings.HasPrefix(k.SigningSecret, SIGNING_SECRET_PREFIX) {
+				t.Errorf("APIKeyUseCase.Create() signing secret = %v", k.SigningSecret)
+			}
+		})
+	}
+}
+
+func TestAPIKeyUseCase_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockErr := errors.New("Mock Error")
+	key, prefix, keyHash, err := newAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := model.APIKey{Id: "test-id", MerchantId: "test-merchant", Prefix: prefix, KeyHash: keyHash, Scopes: []string{model.API_KEY_SCOPE_READ}}
+	wrongSecret := key[:len(key)-1] + "x"
+	if wrongSecret == key {
+		wrongSecret = key[:len(key)-1] + "y"
+	}
+	revokedAt := time.Now()
+	revoked := stored
+	revoked.RevokedAt = &revokedAt
+
+	tests := []struct {
+		name    string
+		key     string
+		repo    func() repository.IAPIKeyRepository
+		wantErr error
+	}{
+		{
+			name: "valid",
+			key:  key,
+			repo: func() repository.IAPIKeyRepository {
+				repo := mock_repository.NewMockIAPIKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					GetByPrefix(gomock.Any(), prefix).
+					Return(stored, nil)
+				repo.
+					EXPECT().
+					TouchLastUsed(gomock.Any(), "test-id", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, id string, now time.Time, staleBefore time.Time) error {
+						if now.Sub(staleBefore) != LastUsedResolution {
+							t.Errorf("TouchLastUsed() stale before = %v, want %v before now", staleBefore, LastUsedResolution)
+						}
+						return nil
+					})
+				return repo
+			},
+		},
+		{
+			name: "malformed",
+			key:  "not-a-key",
+			repo: func() repository.IAPIKeyRepository {
+				return mock_repository.NewMockIAPIKeyRepository(ctrl)
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "unknown-prefix",
+			key:  key,
+			repo: func() repository.IAPIKeyRepository {
+				repo := mock_repository.NewMockIAPIKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					GetByPrefix(gomock.Any(), prefix).
+					Return(model.APIKey{}, fmt.Errorf("%w: mock", repository.ErrNotFound))
+				return repo
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "wrong-secret",
+			key:  wrongSecret,
+			repo: func() repository.IAPIKeyRepository {
+				repo := mock_repository.NewMockIAPIKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					GetByPrefix(gomock.Any(), prefix).
+					Return(stored, nil)
+				return repo
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "revoked",
+			key:  key,
+			repo: func() repository.IAPIKeyRepository {
+				repo := mock_repository.NewMockIAPIKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					GetByPrefix(gomock.Any(), prefix).
+					Return(revoked, nil)
+				return repo
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "error-from-repo",
+			key:  key,
+			repo: func() repository.IAPIKeyRepository {
+				repo := mock_repository.NewMockIAPIKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					GetByPrefix(gomock.Any(), prefix).
+					Return(model.APIKey{}, mockErr)
+				return repo
+			},
+			wantErr: mockErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := APIKeyUseCase{Repo: tt.repo()}
+			k, err := u.Authenticate(context.TODO(), tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("APIKeyUseCase.Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && k.Id != "test-id" {
+				t.Errorf("APIKeyUseCase.Authenticate() = %+v, want test-id", k)
+			}
+		})
+	}
+}
+
+func TestAPIKeyUseCase_Rotate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockIAPIKeyRepository(ctrl)
+	repo.
+		EXPECT().
+		Rotate(gomock.Any(), "test-merchant", "test-id", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, merchantID string, id string, prefix string, keyHash string, signingSecret string, now time.Time) (model.APIKey, error) {
+			return model.APIKey{Id: id, MerchantId: merchantID, Prefix: prefix, KeyHash: keyHash, SigningSecret: signingSecret}, nil
+		})
+
+	u := APIKeyUseCase{Repo: repo}
+	k, err := u.Rotate(merchantCtx, "test-id")
+	if err != nil {
+		t.Fatalf("APIKeyUseCase.Rotate() error = %v", err)
+	}
+	if prefix, ok := parseAPIKey(k.Key); !ok || prefix != k.Prefix || hashAPIKey(k.Key) != k.KeyHash {
+		t.Errorf("APIKeyUseCase.Rotate() = %+v, want the new key with its prefix and hash", k)
+	}
+	if !strings.HasPrefix(k.SigningSecret, SIGNING_SECRET_PREFIX) {
+		t.Errorf("APIKeyUseCase.Rotate() signing secret = %v, want a new one", k.SigningSecret)
+	}
+}
+
+func TestAPIKeyUseCase_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockIAPIKeyRepository(ctrl)
+	repo.
+		EXPECT().
+		List(gomock.Any(), "test-merchant").
+		Return([]model.APIKey{{Id: "test-id", SigningSecret: "ss_test"}}, nil)
+
+	u := APIKeyUseCase{Repo: repo}
+	list, err := u.List(merchantCtx)
+	if err != nil {
+		t.Fatalf("APIKeyUseCase.List() error = %v", err)
+	}
+	if len(list.Data) != 1 || list.Data[0].Id != "test-id" || list.Data[0].SigningSecret != "" {
+		t.Errorf("APIKeyUseCase.List() = %+v, want test-id without its signing secret", list)
+	}
+}
+
+func Test_parseAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		wantPrefix string
+		wantOk     bool
+	}{
+		{
+			name:       "valid",
+			key:        "pk_0123456789abcdef_secret",
+			wantPrefix: "0123456789abcdef",
+			wantOk:     true,
+		},
+		{
+			name: "missing-prefix",
+			key:  "0123456789abcdef_secret",
+		},
+		{
+			name: "short-prefix",
+			key:  "pk_0123_secret",
+		},
+		{
+			name: "missing-secret",
+			key:  "pk_0123456789abcdef_",
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, ok := parseAPIKey(tt.key)
+			if prefix != tt.wantPrefix || ok != tt.wantOk {
+				t.Errorf("parseAPIKey() = %v, %v, want %v, %v", prefix, ok, tt.wantPrefix, tt.wantOk)
+			}
+		})
+	}
+}
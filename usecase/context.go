@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"context"
+)
+
+type merchantIDKey struct{}
+
+// WithMerchantID returns a copy of ctx carrying the id of the merchant the
+// request is made for.
+func WithMerchantID(ctx context.Context, merchantID string) context.Context {
+	return context.WithValue(ctx, merchantIDKey{}, merchantID)
+}
+
+// MerchantIDFromContext returns the merchant id set by WithMerchantID, or an
+// empty string when there is none.
+func MerchantIDFromContext(ctx context.Context) string {
+	merchantID, _ := ctx.Value(merchantIDKey{}).(string)
+	return merchantID
+}
@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+)
+
+var (
+	ErrInvalidStatusTransition = errors.New("payment code status transition is not allowed")
+	ErrPaymentCodeNotPayable   = errors.New("payment code is not active or has expired")
+	ErrInvalidPayment          = errors.New("invalid payment")
+	ErrInvalidExpiration       = errors.New("invalid expiration")
+	ErrDuplicatePaymentCode    = errors.New("payment_code already exists")
+	ErrInvalidCheckDigit       = fmt.Errorf("%w: payment_code check digit is invalid, it may be mistyped", repository.ErrNotFound)
+	ErrPaymentCodeExhausted    = errors.New("no unused payment_code could be generated, try again")
+	ErrIdempotencyKeyInUse     = errors.New("a request with this Idempotency-Key is still in progress")
+	ErrIdempotencyKeyMismatch  = errors.New("Idempotency-Key was already used for a different request")
+	ErrBatchTooLarge           = errors.New("batch is too large")
+	ErrMerchantRequired        = errors.New("merchant is required")
+	ErrInvalidAPIKey           = errors.New("API key is invalid or revoked")
+
+	// errBatchRejected rolls back an atomic batch in which some payment codes
+	// could not be created. It never leaves the usecase.
+	errBatchRejected = errors.New("batch rejected")
+)
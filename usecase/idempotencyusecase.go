@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+)
+
+// IIdempotencyUseCase lets a handler run a request at most once per client
+// and Idempotency-Key, and replay the stored response to its retries.
+type IIdempotencyUseCase interface {
+	Begin(ctx context.Context, clientId string, key string, requestHash string) (stored model.IdempotencyKey, replay bool, err error)
+	Complete(ctx context.Context, clientId string, key string, lockedUntil time.Time, statusCode int, contentType string, responseBody []byte) (err error)
+	Release(ctx context.Context, clientId string, key string, lockedUntil time.Time) (err error)
+	DeleteExpired(ctx context.Context, batchSize int) (deleted int, err error)
+}
+
+// IdempotencyUseCase keeps a key for TTL after its first use. A first request
+// that has not completed within LockTimeout is assumed lost and its key can
+// be reused.
+type IdempotencyUseCase struct {
+	Repo        repository.IIdempotencyKeyRepository
+	TTL         time.Duration
+	LockTimeout time.Duration
+}
+
+// Begin reserves key for the request identified by requestHash and returns
+// the reservation in stored; its LockedUntil must be passed to Complete or
+// Release. When the key was already used for the same request and its
+// response is stored, replay is true and stored holds the response. A key
+// used for a different request fails with ErrIdempotencyKeyMismatch, and one
+// whose first request is still running with ErrIdempotencyKeyInUse.
+func (u IdempotencyUseCase) Begin(ctx context.Context, clientId string, key string, requestHash string) (stored model.IdempotencyKey, replay bool, err error) {
+	// LockedUntil identifies the reservation, so it is kept at the precision
+	// the database stores
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	reservation := model.IdempotencyKey{
+		ClientId:    clientId,
+		Key:         key,
+		RequestHash: requestHash,
+		LockedUntil: now.Add(u.LockTimeout).Truncate(time.Microsecond),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(u.TTL),
+	}
+	stored, reserved, err := u.Repo.Reserve(ctx, &reservation, now)
+	if err != nil {
+		return
+	}
+	if reserved {
+		stored = reservation
+		return
+	}
+
+	switch {
+	case stored.RequestHash != requestHash:
+		err = ErrIdempotencyKeyMismatch
+	case !stored.Completed():
+		err = ErrIdempotencyKeyInUse
+	default:
+		replay = true
+	}
+
+	return
+}
+
+// Complete stores the response to replay for key, unless the reservation
+// ending at lockedUntil was taken over by another request.
+func (u IdempotencyUseCase) Complete(ctx context.Context, clientId string, key string, lockedUntil time.Time, statusCode int, contentType string, responseBody []byte) (err error) {
+	return u.Repo.Complete(ctx, clientId, key, lockedUntil, statusCode, contentType, responseBody)
+}
+
+// Release frees key without storing a response, so the request can be retried
+// with the same key. A reservation that was taken over is left alone.
+func (u IdempotencyUseCase) Release(ctx context.Context, clientId string, key string, lockedUntil time.Time) (err error) {
+	return u.Repo.Release(ctx, clientId, key, lockedUntil)
+}
+
+// DeleteExpired deletes every expired key in batches of batchSize.
+func (u IdempotencyUseCase) DeleteExpired(ctx context.Context, batchSize int) (deleted int, err error) {
+	for {
+		var n int
+		n, err = u.Repo.DeleteExpired(ctx, time.Now().UTC(), batchSize)
+		deleted += n
+		if err != nil || n < batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	mock_repository "github.com/pevin/pevin-golang-training-beginner/mock/repository"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestIdempotencyUseCase_Begin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockErr := errors.New("Mock Error")
+	completed := model.IdempotencyKey{
+		ClientId:     "test-client",
+		Key:          "test-key",
+		RequestHash:  "test-hash",
+		StatusCode:   http.StatusCreated,
+		ResponseBody: []byte(`{"id":"test-id"}`),
+	}
+
+	tests := []struct {
+		name            string
+		repo            func() repository.IIdempotencyKeyRepository
+		wantReplay      bool
+		wantReservation bool
+		wantErr         error
+	}{
+		{
+			name: "reserved",
+			repo: func() repository.IIdempotencyKeyRepository {
+				repo := mock_repository.NewMockIIdempotencyKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, k *model.IdempotencyKey, now time.Time) (model.IdempotencyKey, bool, error) {
+						if k.ExpiresAt.Sub(k.CreatedAt) != time.Hour || k.LockedUntil.Sub(k.CreatedAt) != time.Minute {
+							t.Errorf("Reserve() key = %+v, want expiry after TTL and lock after LockTimeout", k)
+						}
+						if !k.LockedUntil.Equal(k.LockedUntil.Truncate(time.Microsecond)) {
+							t.Errorf("Reserve() lock = %v, want microsecond precision", k.LockedUntil)
+						}
+						return model.IdempotencyKey{}, true, nil
+					})
+				return repo
+			},
+			wantReservation: true,
+		},
+		{
+			name: "replayed",
+			repo: func() repository.IIdempotencyKeyRepository {
+				repo := mock_repository.NewMockIIdempotencyKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(completed, false, nil)
+				return repo
+			},
+			wantReplay: true,
+		},
+		{
+			name: "in-progress",
+			repo: func() repository.IIdempotencyKeyRepository {
+				inProgress := completed
+				inProgress.StatusCode = 0
+				inProgress.ResponseBody = nil
+				repo := mock_repository.NewMockIIdempotencyKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(inProgress, false, nil)
+				return repo
+			},
+			wantErr: ErrIdempotencyKeyInUse,
+		},
+		{
+			name: "different-request",
+			repo: func() repository.IIdempotencyKeyRepository {
+				other := completed
+				other.RequestHash = "other-hash"
+				repo := mock_repository.NewMockIIdempotencyKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(other, false, nil)
+				return repo
+			},
+			wantErr: ErrIdempotencyKeyMismatch,
+		},
+		{
+			name: "with-error-in-repo",
+			repo: func() repository.IIdempotencyKeyRepository {
+				repo := mock_repository.NewMockIIdempotencyKeyRepository(ctrl)
+				repo.
+					EXPECT().
+					Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(model.IdempotencyKey{}, false, mockErr)
+				return repo
+			},
+			wantErr: mockErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := IdempotencyUseCase{
+				Repo:        tt.repo(),
+				TTL:         time.Hour,
+				LockTimeout: time.Minute,
+			}
+			stored, replay, err := u.Begin(context.TODO(), "test-client", "test-key", "test-hash")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("IdempotencyUseCase.Begin() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if replay != tt.wantReplay {
+				t.Errorf("IdempotencyUseCase.Begin() replay = %v, want %v", replay, tt.wantReplay)
+			}
+			if replay && stored.StatusCode != completed.StatusCode {
+				t.Errorf("IdempotencyUseCase.Begin() stored = %+v, want %+v", stored, completed)
+			}
+			if tt.wantReservation && (stored.Key != "test-key" || stored.LockedUntil.IsZero()) {
+				t.Errorf("IdempotencyUseCase.Begin() stored = %+v, want the reservation", stored)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/google/uuid"
+)
+
+type IMerchantUseCase interface {
+	Create(ctx context.Context, m *model.Merchant) (err error)
+	Get(ctx context.Context, id string) (merchant model.Merchant, err error)
+}
+
+type MerchantUseCase struct {
+	Repo repository.IMerchantRepository
+}
+
+// Create stores a new merchant. A random id is generated unless m has one.
+func (u MerchantUseCase) Create(ctx context.Context, m *model.Merchant) (err error) {
+	if m.Id == "" {
+		var id uuid.UUID
+		id, err = uuid.NewRandom()
+		if err != nil {
+			return
+		}
+		m.Id = id.String()
+	}
+
+	now := time.Now().UTC()
+	m.CreatedAt = now
+	m.UpdatedAt = now
+
+	err = u.Repo.Create(ctx, m)
+
+	return
+}
+
+func (u MerchantUseCase) Get(ctx context.Context, id string) (merchant model.Merchant, err error) {
+	return u.Repo.Get(ctx, id)
+}
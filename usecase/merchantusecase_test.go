@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock_repository "github.com/pevin/pevin-golang-training-beginner/mock/repository"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestMerchantUseCase_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockErr := errors.New("Mock Error")
+
+	tests := []struct {
+		name     string
+		repo     func() repository.IMerchantRepository
+		merchant model.Merchant
+		wantId   string
+		wantErr  error
+	}{
+		{
+			name: "generated-id",
+			repo: func() repository.IMerchantRepository {
+				repo := mock_repository.NewMockIMerchantRepository(ctrl)
+				repo.
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(nil)
+				return repo
+			},
+			merchant: model.Merchant{Name: "test name"},
+		},
+		{
+			name: "given-id",
+			repo: func() repository.IMerchantRepository {
+				repo := mock_repository.NewMockIMerchantRepository(ctrl)
+				repo.
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(nil)
+				return repo
+			},
+			merchant: model.Merchant{Id: "test-merchant", Name: "test name"},
+			wantId:   "test-merchant",
+		},
+		{
+			name: "error-from-repo",
+			repo: func() repository.IMerchantRepository {
+				repo := mock_repository.NewMockIMerchantRepository(ctrl)
+				repo.
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(mockErr)
+				return repo
+			},
+			merchant: model.Merchant{Id: "test-merchant", Name: "test name"},
+			wantErr:  mockErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := MerchantUseCase{Repo: tt.repo()}
+			merchant := tt.merchant
+			err := u.Create(context.TODO(), &merchant)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MerchantUseCase.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if merchant.Id == "" || (tt.wantId != "" && merchant.Id != tt.wantId) {
+				t.Errorf("MerchantUseCase.Create() id = %q, want %q", merchant.Id, tt.wantId)
+			}
+			if merchant.CreatedAt.IsZero() || !merchant.UpdatedAt.Equal(merchant.CreatedAt) {
+				t.Errorf("MerchantUseCase.Create() timestamps = %v, %v", merchant.CreatedAt, merchant.UpdatedAt)
+			}
+		})
+	}
+}
@@ -2,11 +2,12 @@ package usecase
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/generator"
 	"github.com/pevin/pevin-golang-training-beginner/model"
 	"github.com/pevin/pevin-golang-training-beginner/producer"
 	"github.com/pevin/pevin-golang-training-beginner/repository"
@@ -15,60 +16,366 @@ import (
 )
 
 type IPaymentCodeUseCase interface {
-	InitFromRequest(r *http.Request) (paymentCode model.PaymentCode, err error)
 	Create(ctx context.Context, p *model.PaymentCode) (err error)
+	CreateBatch(ctx context.Context, paymentCodes []*model.PaymentCode, atomic bool) (errs []error, err error)
 	Get(ctx context.Context, id string) (paymentCode model.PaymentCode, err error)
+	GetByPaymentCode(ctx context.Context, paymentCode string) (p model.PaymentCode, err error)
+	ExpireDue(ctx context.Context, batchSize int) (expired int, err error)
+	Deactivate(ctx context.Context, id string) (paymentCode model.PaymentCode, err error)
+	Reactivate(ctx context.Context, id string) (paymentCode model.PaymentCode, err error)
+	List(ctx context.Context, filter model.PaymentCodeFilter) (list model.PaymentCodeList, err error)
 }
+
+const (
+	DefaultListLimit   = 20
+	MaxListLimit       = 100
+	MaxBatchCreateSize = 1000
+)
+
 type PaymentCodeUseCase struct {
-	Repo     repository.IPaymentCodeRepository
-	Producer producer.IPaymentCodeMessageProducer
+	Repo             repository.IPaymentCodeRepository
+	Producer         producer.IPaymentCodeMessageProducer
+	Transactor       repository.ITransactor
+	ExpirationPolicy config.ExpirationPolicy
+	Generator        generator.Generator
+	GenerateAttempts int
 }
 
-func (u PaymentCodeUseCase) InitFromRequest(r *http.Request) (paymentCode model.PaymentCode, err error) {
-	body, err := ioutil.ReadAll(r.Body)
+// Create stores a new ACTIVE payment code. When paymentCode has no
+// payment_code one is generated, and generated codes that collide with an
+// existing one are regenerated up to GenerateAttempts times.
+func (u PaymentCodeUseCase) Create(ctx context.Context, paymentCode *model.PaymentCode) (err error) {
+	generate := paymentCode.PaymentCode == ""
+
+	err = u.prepare(ctx, paymentCode, time.Now().UTC())
 	if err != nil {
 		return
 	}
 
-	json.Unmarshal([]byte(body), &paymentCode)
+	attempts := u.generateAttempts(generate)
+	for attempt := 0; attempt < attempts; attempt++ {
+		if generate {
+			paymentCode.PaymentCode, err = u.Generator.Generate()
+			if err != nil {
+				return
+			}
+		}
+
+		err = u.Transactor.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+			err = u.Repo.Create(ctx, paymentCode)
+
+			// the generated id is a UUID, so a conflict is on payment_code
+			if errors.Is(err, repository.ErrConflict) {
+				err = fmt.Errorf("%w: %v", ErrDuplicatePaymentCode, err)
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			err = u.Producer.ProduceCreated(ctx, paymentCode)
+
+			return
+		})
+		if !generate || !errors.Is(err, ErrDuplicatePaymentCode) {
+			return
+		}
+	}
+
+	err = fmt.Errorf("%w: every one of %d attempts collided: %v", ErrPaymentCodeExhausted, attempts, err)
 
 	return
 }
 
-func (u PaymentCodeUseCase) Create(ctx context.Context, paymentCode *model.PaymentCode) (err error) {
+// CreateBatch stores paymentCodes like Create, in a single transaction. errs
+// holds the error of every payment code that was not created. When atomic is
+// true nothing is created unless every payment code can be; otherwise the
+// valid ones are created and only the others are reported in errs. err is set
+// when the batch as a whole failed and nothing was created.
+func (u PaymentCodeUseCase) CreateBatch(ctx context.Context, paymentCodes []*model.PaymentCode, atomic bool) (errs []error, err error) {
+	if len(paymentCodes) > MaxBatchCreateSize {
+		err = fmt.Errorf("%w: at most %d payment codes can be created at once", ErrBatchTooLarge, MaxBatchCreateSize)
+		return
+	}
+
+	errs = make([]error, len(paymentCodes))
+	generate := make([]bool, len(paymentCodes))
+	failed := false
+
+	now := time.Now().UTC()
+	var pending []int
+	for i, paymentCode := range paymentCodes {
+		generate[i] = paymentCode.PaymentCode == ""
+		errs[i] = u.prepare(ctx, paymentCode, now)
+		if errs[i] != nil {
+			failed = true
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	if atomic && failed {
+		return
+	}
+
+	attempts := u.generateAttempts(true)
+	err = u.Transactor.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		for attempt := 1; len(pending) > 0; attempt++ {
+			batch := make([]*model.PaymentCode, len(pending))
+			for j, i := range pending {
+				if generate[i] {
+					paymentCodes[i].PaymentCode, err = u.Generator.Generate()
+					if err != nil {
+						return
+					}
+				}
+				batch[j] = paymentCodes[i]
+			}
+
+			var inserted []bool
+			inserted, err = u.Repo.CreateBatch(ctx, batch)
+			if err != nil {
+				return
+			}
+
+			// conflicts do not abort the transaction, so collided generated
+			// codes are regenerated and inserted again within it
+			var retry []int
+			for j, i := range pending {
+				switch {
+				case inserted[j]:
+					err = u.Producer.ProduceCreated(ctx, paymentCodes[i])
+					if err != nil {
+						return
+					}
+				case !generate[i]:
+					errs[i] = ErrDuplicatePaymentCode
+					failed = true
+				case attempt < attempts:
+					retry = append(retry, i)
+				default:
+					err = fmt.Errorf("%w: every one of %d attempts collided", ErrPaymentCodeExhausted, attempts)
+					return
+				}
+			}
+			pending = retry
+		}
+
+		if atomic && failed {
+			err = errBatchRejected
+		}
+
+		return
+	})
+	if errors.Is(err, errBatchRejected) {
+		err = nil
+	}
+
+	return
+}
+
+// prepare validates a new payment code and fills in what Create sets: id,
+// merchant, timestamps, expiration date and the initial status and usage.
+func (u PaymentCodeUseCase) prepare(ctx context.Context, paymentCode *model.PaymentCode, now time.Time) (err error) {
+	merchantID := MerchantIDFromContext(ctx)
+	if merchantID == "" {
+		err = ErrMerchantRequired
+		return
+	}
+
 	id, err := uuid.NewRandom()
 	if err != nil {
 		return
 	}
 	paymentCode.Id = id.String()
+	paymentCode.MerchantId = merchantID
 
-	now := time.Now().UTC()
 	paymentCode.CreatedAt = now
 	paymentCode.UpdatedAt = now
 
-	expDate := now.AddDate(50, 0, 0)
-	paymentCode.ExpirationDate = expDate
+	paymentCode.ExpirationDate, err = expirationDate(*paymentCode, now, u.ExpirationPolicy.For(merchantID))
+	if err != nil {
+		return
+	}
+	paymentCode.TTLSeconds = 0
 
 	paymentCode.Status = model.PAYMENT_CODE_STATUS_ACTIVE
+	paymentCode.UsageCount = 0
+	paymentCode.TotalPaid = 0
 
-	err = u.Repo.Create(ctx, paymentCode)
+	return
+}
 
-	if err != nil {
+// generateAttempts is how many codes are tried before giving up on
+// collisions. Client-provided codes are tried once.
+func (u PaymentCodeUseCase) generateAttempts(generate bool) int {
+	if generate && u.GenerateAttempts > 1 {
+		return u.GenerateAttempts
+	}
+	return 1
+}
+
+// hasInvalidCheckDigit reports whether code looks like a code generated in
+// format but its check digits do not match, which usually means it was
+// mistyped.
+func hasInvalidCheckDigit(format generator.Format, code string) bool {
+	if format.CheckDigit == "" || format.CheckDigit == generator.CHECK_DIGIT_NONE {
+		return false
+	}
+	return format.Matches(code) && !format.ValidateCheckDigit(code)
+}
+
+// expirationDate returns the expiration date requested in p, either as a date
+// or a TTL, or the default of rule when neither is set. The date must be in
+// the future and within the max TTL of rule.
+func expirationDate(p model.PaymentCode, now time.Time, rule config.ExpirationRule) (expiration time.Time, err error) {
+	switch {
+	case !p.ExpirationDate.IsZero() && p.TTLSeconds != 0:
+		err = fmt.Errorf("%w: set either 'expiration_date' or 'ttl_seconds', not both", ErrInvalidExpiration)
+		return
+	case !p.ExpirationDate.IsZero():
+		expiration = p.ExpirationDate.UTC()
+	case p.TTLSeconds < 0:
+		err = fmt.Errorf("%w: field 'ttl_seconds' must be positive", ErrInvalidExpiration)
+		return
+	case p.TTLSeconds > 0:
+		expiration = now.Add(time.Duration(p.TTLSeconds) * time.Second)
+	default:
+		expiration = now.Add(rule.DefaultTTL.Duration())
+	}
+
+	if !expiration.After(now) {
+		err = fmt.Errorf("%w: expiration must be in the future", ErrInvalidExpiration)
 		return
 	}
 
-	err = u.Producer.Produce(paymentCode)
+	if expiration.After(now.Add(rule.MaxTTL.Duration())) {
+		err = fmt.Errorf("%w: expiration must be within %s of creation", ErrInvalidExpiration, rule.MaxTTL)
+		return
+	}
 
 	return
 }
 
+// Get returns a payment code of the merchant in ctx. The payment codes of other
+// merchants are not found.
 func (u PaymentCodeUseCase) Get(ctx context.Context, id string) (p model.PaymentCode, err error) {
-	p, err = u.Repo.Get(ctx, id)
+	return u.Repo.Get(ctx, MerchantIDFromContext(ctx), id)
+}
+
+// GetByPaymentCode looks a payment code of the merchant in ctx up by its
+// payment_code value.
+func (u PaymentCodeUseCase) GetByPaymentCode(ctx context.Context, paymentCode string) (p model.PaymentCode, err error) {
+	return u.Repo.GetByPaymentCode(ctx, MerchantIDFromContext(ctx), paymentCode)
+}
+
+// ExpireDue expires every ACTIVE or INACTIVE payment code past its expiration
+// date, in batches of batchSize, and produces an expired event for each of
+// them. Each batch is committed together with its events.
+func (u PaymentCodeUseCase) ExpireDue(ctx context.Context, batchSize int) (expired int, err error) {
+	for {
+		var paymentCodes []model.PaymentCode
+		err = u.Transactor.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+			paymentCodes, err = u.Repo.ExpireBatch(ctx, time.Now().UTC(), batchSize)
+			if err != nil {
+				return
+			}
+
+			for i := range paymentCodes {
+				err = u.Producer.ProduceExpired(ctx, &paymentCodes[i])
+				if err != nil {
+					return
+				}
+			}
+
+			return
+		})
+		if err != nil {
+			return
+		}
+
+		expired += len(paymentCodes)
+
+		if len(paymentCodes) < batchSize {
+			return
+		}
+	}
+}
+
+// Deactivate moves an ACTIVE payment code to INACTIVE.
+func (u PaymentCodeUseCase) Deactivate(ctx context.Context, id string) (p model.PaymentCode, err error) {
+	return u.transition(ctx, id, model.PAYMENT_CODE_STATUS_INACTIVE)
+}
+
+// Reactivate moves an INACTIVE payment code back to ACTIVE. Codes that are
+// already past their expiration date cannot be reactivated.
+func (u PaymentCodeUseCase) Reactivate(ctx context.Context, id string) (p model.PaymentCode, err error) {
+	return u.transition(ctx, id, model.PAYMENT_CODE_STATUS_ACTIVE)
+}
+
+func (u PaymentCodeUseCase) transition(ctx context.Context, id string, status string) (p model.PaymentCode, err error) {
+	p, err = u.Repo.Get(ctx, MerchantIDFromContext(ctx), id)
 	if err != nil {
 		return
 	}
 
-	err = u.Producer.Produce(&p)
+	if !p.CanTransitionTo(status) {
+		err = ErrInvalidStatusTransition
+		return
+	}
+
+	now := time.Now().UTC()
+	if status == model.PAYMENT_CODE_STATUS_ACTIVE && !p.ExpirationDate.IsZero() && !p.ExpirationDate.After(now) {
+		err = ErrInvalidStatusTransition
+		return
+	}
+
+	previousStatus := p.Status
+	err = u.Transactor.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		updated, err := u.Repo.UpdateStatus(ctx, p.MerchantId, p.Id, previousStatus, status, now)
+		if err != nil {
+			return
+		}
+
+		if !updated {
+			err = ErrInvalidStatusTransition
+			return
+		}
+
+		p.Status = status
+		p.UpdatedAt = now
+
+		err = u.Producer.ProduceStatusChanged(ctx, &p, previousStatus)
+
+		return
+	})
+
+	return
+}
+
+// List returns a page of the payment codes of the merchant in ctx matching
+// filter. The page size defaults to DefaultListLimit and is capped at
+// MaxListLimit.
+func (u PaymentCodeUseCase) List(ctx context.Context, filter model.PaymentCodeFilter) (list model.PaymentCodeList, err error) {
+	filter.MerchantId = MerchantIDFromContext(ctx)
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultListLimit
+	}
+	if filter.Limit > MaxListLimit {
+		filter.Limit = MaxListLimit
+	}
+	if filter.SortOrder == "" {
+		filter.SortOrder = model.SORT_ORDER_ASC
+	}
+
+	list.Data, list.NextCursor, err = u.Repo.List(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	if list.Data == nil {
+		list.Data = []model.PaymentCode{}
+	}
 
 	return
 }
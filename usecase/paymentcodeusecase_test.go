@@ -3,9 +3,13 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/pevin/pevin-golang-training-beginner/config"
+	"github.com/pevin/pevin-golang-training-beginner/generator"
 	mock_producer "github.com/pevin/pevin-golang-training-beginner/mock/producer"
 	mock_repository "github.com/pevin/pevin-golang-training-beginner/mock/repository"
 	"github.com/pevin/pevin-golang-training-beginner/model"
@@ -15,6 +19,23 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
+// newMockTransactor returns a transactor that runs the function it is given
+// without a real transaction.
+func newMockTransactor(ctrl *gomock.Controller) repository.ITransactor {
+	transactor := mock_repository.NewMockITransactor(ctrl)
+	transactor.
+		EXPECT().
+		WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).
+		AnyTimes()
+	return transactor
+}
+
+// merchantCtx is the context of a request made by test-merchant.
+var merchantCtx = WithMerchantID(context.TODO(), "test-merchant")
+
 func TestPaymentCodeUseCase_Create(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
@@ -44,20 +65,25 @@ func TestPaymentCodeUseCase_Create(t *testing.T) {
 					repo.
 						EXPECT().
 						Create(gomock.Any(), gomock.Any()).
-						Return(nil)
+						DoAndReturn(func(ctx context.Context, p *model.PaymentCode) error {
+							if p.MerchantId != "test-merchant" {
+								t.Errorf("PaymentCodeUseCase.Create() merchant = %v, want test-merchant", p.MerchantId)
+							}
+							return nil
+						})
 					return repo
 				}(),
 				Producer: func() producer.IPaymentCodeMessageProducer {
 					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
 					producer.
 						EXPECT().
-						Produce(gomock.Any()).
+						ProduceCreated(gomock.Any(), gomock.Any()).
 						Return(nil)
 					return producer
 				}(),
 			},
 			args: args{
-				ctx: context.TODO(),
+				ctx: merchantCtx,
 				paymentCode: &model.PaymentCode{
 					PaymentCode: "test-payment-code",
 					Name:        "test name",
@@ -65,6 +91,21 @@ func TestPaymentCodeUseCase_Create(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "without-merchant",
+			fields: fields{
+				Repo:     mock_repository.NewMockIPaymentCodeRepository(ctrl),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			args: args{
+				ctx: context.TODO(),
+				paymentCode: &model.PaymentCode{
+					PaymentCode: "test-payment-code",
+					Name:        "test name",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "with-error-in-repo",
 			fields: fields{
@@ -82,7 +123,7 @@ func TestPaymentCodeUseCase_Create(t *testing.T) {
 				}(),
 			},
 			args: args{
-				ctx: context.TODO(),
+				ctx: merchantCtx,
 				paymentCode: &model.PaymentCode{
 					PaymentCode: "test-payment-code",
 					Name:        "test name",
@@ -91,6 +132,28 @@ func TestPaymentCodeUseCase_Create(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "duplicate-payment-code",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						Create(gomock.Any(), gomock.Any()).
+						Return(fmt.Errorf("%w: mock", repository.ErrConflict))
+					return repo
+				}(),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			args: args{
+				ctx: merchantCtx,
+				paymentCode: &model.PaymentCode{
+					PaymentCode: "test-payment-code",
+					Name:        "test name",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "with-error-in-producer",
 			fields: fields{
@@ -106,13 +169,13 @@ func TestPaymentCodeUseCase_Create(t *testing.T) {
 					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
 					producer.
 						EXPECT().
-						Produce(gomock.Any()).
+						ProduceCreated(gomock.Any(), gomock.Any()).
 						Return(err)
 					return producer
 				}(),
 			},
 			args: args{
-				ctx: context.TODO(),
+				ctx: merchantCtx,
 				paymentCode: &model.PaymentCode{
 					PaymentCode: "test-payment-code",
 					Name:        "test name",
@@ -125,8 +188,10 @@ func TestPaymentCodeUseCase_Create(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			u := PaymentCodeUseCase{
-				Repo:     tt.fields.Repo,
-				Producer: tt.fields.Producer,
+				Repo:             tt.fields.Repo,
+				Producer:         tt.fields.Producer,
+				Transactor:       newMockTransactor(ctrl),
+				ExpirationPolicy: config.Default().Expiration,
 			}
 			if err := u.Create(tt.args.ctx, tt.args.paymentCode); (err != nil) != tt.wantErr {
 				t.Errorf("PaymentCodeUseCase.Create() error = %v, wantErr %v", err, tt.wantErr)
@@ -134,6 +199,384 @@ func TestPaymentCodeUseCase_Create(t *testing.T) {
 		})
 	}
 }
+
+func TestPaymentCodeUseCase_CreateGenerated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	format := generator.Format{Prefix: "PC", Length: 12, Alphabet: generator.ALPHABET_NUMERIC, CheckDigit: generator.CHECK_DIGIT_LUHN}
+	conflict := fmt.Errorf("%w: mock", repository.ErrConflict)
+
+	tests := []struct {
+		name        string
+		repo        func() repository.IPaymentCodeRepository
+		paymentCode string
+		wantErr     error
+	}{
+		{
+			name: "generated",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				repo.
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "regenerated-after-collision",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				gomock.InOrder(
+					repo.
+						EXPECT().
+						Create(gomock.Any(), gomock.Any()).
+						Return(conflict),
+					repo.
+						EXPECT().
+						Create(gomock.Any(), gomock.Any()).
+						Return(nil),
+				)
+				return repo
+			},
+		},
+		{
+			name: "every-attempt-collided",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				repo.
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(conflict).
+					Times(3)
+				return repo
+			},
+			wantErr: ErrPaymentCodeExhausted,
+		},
+		{
+			name: "client-code-in-generator-format-is-kept",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				repo.
+					EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(nil)
+				return repo
+			},
+			paymentCode: "PC7992739872",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+			producer.
+				EXPECT().
+				ProduceCreated(gomock.Any(), gomock.Any()).
+				Return(nil).
+				AnyTimes()
+
+			u := PaymentCodeUseCase{
+				Repo:             tt.repo(),
+				Producer:         producer,
+				Transactor:       newMockTransactor(ctrl),
+				ExpirationPolicy: config.Default().Expiration,
+				Generator:        generator.Generator{Format: format},
+				GenerateAttempts: 3,
+			}
+			paymentCode := model.PaymentCode{PaymentCode: tt.paymentCode, Name: "test name"}
+			err := u.Create(merchantCtx, &paymentCode)
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("PaymentCodeUseCase.Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("PaymentCodeUseCase.Create() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if errors.Is(err, ErrDuplicatePaymentCode) {
+					t.Errorf("PaymentCodeUseCase.Create() error = %v, must not report a duplicate the client did not send", err)
+				}
+				return
+			}
+			if tt.paymentCode != "" {
+				if paymentCode.PaymentCode != tt.paymentCode {
+					t.Errorf("PaymentCodeUseCase.Create() payment code = %v, want %v", paymentCode.PaymentCode, tt.paymentCode)
+				}
+				return
+			}
+			if !format.ValidateCheckDigit(paymentCode.PaymentCode) {
+				t.Errorf("PaymentCodeUseCase.Create() payment code = %v, want a code in the generator format", paymentCode.PaymentCode)
+			}
+		})
+	}
+}
+
+func TestPaymentCodeUseCase_CreateBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockErr := errors.New("Mock Error")
+	format := generator.Format{Length: 12, Alphabet: generator.ALPHABET_NUMERIC, CheckDigit: generator.CHECK_DIGIT_LUHN}
+
+	newBatch := func(paymentCodes ...string) []*model.PaymentCode {
+		batch := make([]*model.PaymentCode, len(paymentCodes))
+		for i, paymentCode := range paymentCodes {
+			batch[i] = &model.PaymentCode{PaymentCode: paymentCode, Name: "test name"}
+		}
+		return batch
+	}
+
+	tests := []struct {
+		name         string
+		repo         func() repository.IPaymentCodeRepository
+		producer     func() producer.IPaymentCodeMessageProducer
+		paymentCodes []*model.PaymentCode
+		atomic       bool
+		wantErrs     []error
+		wantErr      error
+	}{
+		{
+			name: "all-created",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				repo.
+					EXPECT().
+					CreateBatch(gomock.Any(), gomock.Len(2)).
+					Return([]bool{true, true}, nil)
+				return repo
+			},
+			producer: func() producer.IPaymentCodeMessageProducer {
+				producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+				producer.
+					EXPECT().
+					ProduceCreated(gomock.Any(), gomock.Any()).
+					Return(nil).
+					Times(2)
+				return producer
+			},
+			paymentCodes: newBatch("test-payment-code-1", "test-payment-code-2"),
+			atomic:       true,
+			wantErrs:     []error{nil, nil},
+		},
+		{
+			name: "atomic-with-duplicate",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				repo.
+					EXPECT().
+					CreateBatch(gomock.Any(), gomock.Len(2)).
+					Return([]bool{true, false}, nil)
+				return repo
+			},
+			producer: func() producer.IPaymentCodeMessageProducer {
+				producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+				producer.
+					EXPECT().
+					ProduceCreated(gomock.Any(), gomock.Any()).
+					Return(nil)
+				return producer
+			},
+			paymentCodes: newBatch("test-payment-code-1", "test-payment-code-2"),
+			atomic:       true,
+			wantErrs:     []error{nil, ErrDuplicatePaymentCode},
+		},
+		{
+			name: "atomic-with-invalid-item",
+			repo: func() repository.IPaymentCodeRepository {
+				return mock_repository.NewMockIPaymentCodeRepository(ctrl)
+			},
+			producer: func() producer.IPaymentCodeMessageProducer {
+				return mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+			},
+			paymentCodes: func() []*model.PaymentCode {
+				batch := newBatch("test-payment-code-1", "test-payment-code-2")
+				batch[1].TTLSeconds = -1
+				return batch
+			}(),
+			atomic:   true,
+			wantErrs: []error{nil, ErrInvalidExpiration},
+		},
+		{
+			name: "partial-with-invalid-item",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				repo.
+					EXPECT().
+					CreateBatch(gomock.Any(), gomock.Len(1)).
+					Return([]bool{true}, nil)
+				return repo
+			},
+			producer: func() producer.IPaymentCodeMessageProducer {
+				producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+				producer.
+					EXPECT().
+					ProduceCreated(gomock.Any(), gomock.Any()).
+					Return(nil)
+				return producer
+			},
+			paymentCodes: func() []*model.PaymentCode {
+				batch := newBatch("test-payment-code-1", "test-payment-code-2")
+				batch[1].TTLSeconds = -1
+				return batch
+			}(),
+			wantErrs: []error{nil, ErrInvalidExpiration},
+		},
+		{
+			name: "generated-collision-regenerated",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				gomock.InOrder(
+					repo.
+						EXPECT().
+						CreateBatch(gomock.Any(), gomock.Len(2)).
+						Return([]bool{true, false}, nil),
+					repo.
+						EXPECT().
+						CreateBatch(gomock.Any(), gomock.Len(1)).
+						Return([]bool{true}, nil),
+				)
+				return repo
+			},
+			producer: func() producer.IPaymentCodeMessageProducer {
+				producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+				producer.
+					EXPECT().
+					ProduceCreated(gomock.Any(), gomock.Any()).
+					Return(nil).
+					Times(2)
+				return producer
+			},
+			paymentCodes: newBatch("", ""),
+			atomic:       true,
+			wantErrs:     []error{nil, nil},
+		},
+		{
+			name: "with-error-in-repo",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				repo.
+					EXPECT().
+					CreateBatch(gomock.Any(), gomock.Any()).
+					Return(nil, mockErr)
+				return repo
+			},
+			producer: func() producer.IPaymentCodeMessageProducer {
+				return mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+			},
+			paymentCodes: newBatch("test-payment-code-1"),
+			wantErr:      mockErr,
+		},
+		{
+			name: "too-large",
+			repo: func() repository.IPaymentCodeRepository {
+				return mock_repository.NewMockIPaymentCodeRepository(ctrl)
+			},
+			producer: func() producer.IPaymentCodeMessageProducer {
+				return mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+			},
+			paymentCodes: make([]*model.PaymentCode, MaxBatchCreateSize+1),
+			wantErr:      ErrBatchTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := PaymentCodeUseCase{
+				Repo:             tt.repo(),
+				Producer:         tt.producer(),
+				Transactor:       newMockTransactor(ctrl),
+				ExpirationPolicy: config.Default().Expiration,
+				Generator:        generator.Generator{Format: format},
+				GenerateAttempts: 3,
+			}
+			errs, err := u.CreateBatch(merchantCtx, tt.paymentCodes, tt.atomic)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PaymentCodeUseCase.CreateBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("PaymentCodeUseCase.CreateBatch() errs = %v, want %v", errs, tt.wantErrs)
+			}
+			for i := range errs {
+				if !errors.Is(errs[i], tt.wantErrs[i]) {
+					t.Errorf("PaymentCodeUseCase.CreateBatch() errs[%d] = %v, want %v", i, errs[i], tt.wantErrs[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_expirationDate(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	rule := config.ExpirationRule{
+		DefaultTTL: config.Duration(24 * time.Hour),
+		MaxTTL:     config.Duration(7 * 24 * time.Hour),
+	}
+
+	tests := []struct {
+		name        string
+		paymentCode model.PaymentCode
+		want        time.Time
+		wantErr     bool
+	}{
+		{
+			name: "default-ttl",
+			want: now.Add(24 * time.Hour),
+		},
+		{
+			name:        "requested-ttl",
+			paymentCode: model.PaymentCode{TTLSeconds: 3600},
+			want:        now.Add(time.Hour),
+		},
+		{
+			name:        "requested-expiration-date",
+			paymentCode: model.PaymentCode{ExpirationDate: now.Add(48 * time.Hour)},
+			want:        now.Add(48 * time.Hour),
+		},
+		{
+			name:        "both-set",
+			paymentCode: model.PaymentCode{ExpirationDate: now.Add(48 * time.Hour), TTLSeconds: 3600},
+			wantErr:     true,
+		},
+		{
+			name:        "negative-ttl",
+			paymentCode: model.PaymentCode{TTLSeconds: -1},
+			wantErr:     true,
+		},
+		{
+			name:        "in-the-past",
+			paymentCode: model.PaymentCode{ExpirationDate: now.Add(-time.Hour)},
+			wantErr:     true,
+		},
+		{
+			name:        "beyond-max-ttl",
+			paymentCode: model.PaymentCode{TTLSeconds: int64(8 * 24 * time.Hour / time.Second)},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expirationDate(tt.paymentCode, now, rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expirationDate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidExpiration) {
+					t.Errorf("expirationDate() error = %v, want ErrInvalidExpiration", err)
+				}
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("expirationDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPaymentCodeUseCase_Get(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
@@ -141,6 +584,7 @@ func TestPaymentCodeUseCase_Get(t *testing.T) {
 
 	paymentCode := model.PaymentCode{
 		Id:          "test-id",
+		MerchantId:  "test-merchant",
 		PaymentCode: "test-payment-code",
 		Name:        "test name",
 		Status:      "test-status",
@@ -170,21 +614,14 @@ func TestPaymentCodeUseCase_Get(t *testing.T) {
 					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
 					repo.
 						EXPECT().
-						Get(gomock.Any(), "test-id").
+						Get(gomock.Any(), "test-merchant", "test-id").
 						Return(paymentCode, nil)
 					return repo
 				}(),
-				Producer: func() producer.IPaymentCodeMessageProducer {
-					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
-					producer.
-						EXPECT().
-						Produce(gomock.Any()).
-						Return(nil)
-					return producer
-				}(),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
 			},
 			args: args{
-				ctx: context.TODO(),
+				ctx: merchantCtx,
 				id:  "test-id",
 			},
 			wantP: paymentCode,
@@ -196,7 +633,7 @@ func TestPaymentCodeUseCase_Get(t *testing.T) {
 					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
 					repo.
 						EXPECT().
-						Get(gomock.Any(), "test-id").
+						Get(gomock.Any(), "test-merchant", "test-id").
 						Return(emptyPaymentCode, err)
 					return repo
 				}(),
@@ -206,53 +643,533 @@ func TestPaymentCodeUseCase_Get(t *testing.T) {
 				}(),
 			},
 			args: args{
-				ctx: context.TODO(),
+				ctx: merchantCtx,
 				id:  "test-id",
 			},
 			wantP:   emptyPaymentCode,
 			wantErr: true,
 		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := PaymentCodeUseCase{
+				Repo:       tt.fields.Repo,
+				Producer:   tt.fields.Producer,
+				Transactor: newMockTransactor(ctrl),
+			}
+			gotP, err := u.Get(tt.args.ctx, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PaymentCodeUseCase.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotP, tt.wantP) {
+				t.Errorf("PaymentCodeUseCase.Get() = %v, want %v", gotP, tt.wantP)
+			}
+		})
+	}
+}
+
+func TestPaymentCodeUseCase_ExpireDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	err := errors.New("Mock Error")
+
+	expiredPaymentCodes := []model.PaymentCode{
+		{Id: "test-id-1", Status: model.PAYMENT_CODE_STATUS_EXPIRED},
+		{Id: "test-id-2", Status: model.PAYMENT_CODE_STATUS_EXPIRED},
+	}
+
+	type fields struct {
+		Repo     repository.IPaymentCodeRepository
+		Producer producer.IPaymentCodeMessageProducer
+	}
+	type args struct {
+		ctx       context.Context
+		batchSize int
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		args        args
+		wantExpired int
+		wantErr     bool
+	}{
 		{
-			name: "get-error-from-producer",
+			name: "expire-in-batches",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					gomock.InOrder(
+						repo.
+							EXPECT().
+							ExpireBatch(gomock.Any(), gomock.Any(), 2).
+							Return(expiredPaymentCodes, nil),
+						repo.
+							EXPECT().
+							ExpireBatch(gomock.Any(), gomock.Any(), 2).
+							Return(expiredPaymentCodes[:1], nil),
+					)
+					return repo
+				}(),
+				Producer: func() producer.IPaymentCodeMessageProducer {
+					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+					producer.
+						EXPECT().
+						ProduceExpired(gomock.Any(), gomock.Any()).
+						Return(nil).
+						Times(3)
+					return producer
+				}(),
+			},
+			args: args{
+				ctx:       merchantCtx,
+				batchSize: 2,
+			},
+			wantExpired: 3,
+		},
+		{
+			name: "nothing-to-expire",
 			fields: fields{
 				Repo: func() repository.IPaymentCodeRepository {
 					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
 					repo.
 						EXPECT().
-						Get(gomock.Any(), "test-id").
-						Return(paymentCode, nil)
+						ExpireBatch(gomock.Any(), gomock.Any(), 2).
+						Return(nil, nil)
+					return repo
+				}(),
+				Producer: func() producer.IPaymentCodeMessageProducer {
+					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+					return producer
+				}(),
+			},
+			args: args{
+				ctx:       merchantCtx,
+				batchSize: 2,
+			},
+			wantExpired: 0,
+		},
+		{
+			name: "with-error-in-repo",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						ExpireBatch(gomock.Any(), gomock.Any(), 2).
+						Return(nil, err)
+					return repo
+				}(),
+				Producer: func() producer.IPaymentCodeMessageProducer {
+					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+					return producer
+				}(),
+			},
+			args: args{
+				ctx:       merchantCtx,
+				batchSize: 2,
+			},
+			wantExpired: 0,
+			wantErr:     true,
+		},
+		{
+			name: "with-error-in-producer",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						ExpireBatch(gomock.Any(), gomock.Any(), 2).
+						Return(expiredPaymentCodes, nil)
+					return repo
+				}(),
+				Producer: func() producer.IPaymentCodeMessageProducer {
+					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+					gomock.InOrder(
+						producer.
+							EXPECT().
+							ProduceExpired(gomock.Any(), gomock.Any()).
+							Return(nil),
+						producer.
+							EXPECT().
+							ProduceExpired(gomock.Any(), gomock.Any()).
+							Return(err),
+					)
+					return producer
+				}(),
+			},
+			args: args{
+				ctx:       merchantCtx,
+				batchSize: 2,
+			},
+			wantExpired: 0,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := PaymentCodeUseCase{
+				Repo:       tt.fields.Repo,
+				Producer:   tt.fields.Producer,
+				Transactor: newMockTransactor(ctrl),
+			}
+			gotExpired, err := u.ExpireDue(tt.args.ctx, tt.args.batchSize)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PaymentCodeUseCase.ExpireDue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotExpired != tt.wantExpired {
+				t.Errorf("PaymentCodeUseCase.ExpireDue() = %v, want %v", gotExpired, tt.wantExpired)
+			}
+		})
+	}
+}
+
+func TestPaymentCodeUseCase_Deactivate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	activePaymentCode := model.PaymentCode{
+		Id:          "test-id",
+		MerchantId:  "test-merchant",
+		PaymentCode: "test-payment-code",
+		Name:        "test name",
+		Status:      model.PAYMENT_CODE_STATUS_ACTIVE,
+	}
+	expiredPaymentCode := activePaymentCode
+	expiredPaymentCode.Status = model.PAYMENT_CODE_STATUS_EXPIRED
+	inactivePaymentCode := activePaymentCode
+	inactivePaymentCode.Status = model.PAYMENT_CODE_STATUS_INACTIVE
+	err := errors.New("Mock Error")
+
+	type fields struct {
+		Repo     repository.IPaymentCodeRepository
+		Producer producer.IPaymentCodeMessageProducer
+	}
+	type args struct {
+		ctx context.Context
+		id  string
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantStatus string
+		wantErr    error
+	}{
+		{
+			name: "deactivate-success",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						Get(gomock.Any(), "test-merchant", "test-id").
+						Return(activePaymentCode, nil)
+					repo.
+						EXPECT().
+						UpdateStatus(gomock.Any(), "test-merchant", "test-id", model.PAYMENT_CODE_STATUS_ACTIVE, model.PAYMENT_CODE_STATUS_INACTIVE, gomock.Any()).
+						Return(true, nil)
 					return repo
 				}(),
 				Producer: func() producer.IPaymentCodeMessageProducer {
 					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
 					producer.
 						EXPECT().
-						Produce(gomock.Any()).
-						Return(err)
+						ProduceStatusChanged(gomock.Any(), gomock.Any(), model.PAYMENT_CODE_STATUS_ACTIVE).
+						Return(nil)
 					return producer
 				}(),
 			},
 			args: args{
-				ctx: context.TODO(),
+				ctx: merchantCtx,
+				id:  "test-id",
+			},
+			wantStatus: model.PAYMENT_CODE_STATUS_INACTIVE,
+		},
+		{
+			name: "not-found",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						Get(gomock.Any(), "test-merchant", "test-id").
+						Return(model.PaymentCode{}, repository.ErrNotFound)
+					return repo
+				}(),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			args: args{
+				ctx: merchantCtx,
+				id:  "test-id",
+			},
+			wantErr: repository.ErrNotFound,
+		},
+		{
+			name: "already-inactive",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						Get(gomock.Any(), "test-merchant", "test-id").
+						Return(inactivePaymentCode, nil)
+					return repo
+				}(),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			args: args{
+				ctx: merchantCtx,
+				id:  "test-id",
+			},
+			wantErr: ErrInvalidStatusTransition,
+		},
+		{
+			name: "expired-is-terminal",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						Get(gomock.Any(), "test-merchant", "test-id").
+						Return(expiredPaymentCode, nil)
+					return repo
+				}(),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			args: args{
+				ctx: merchantCtx,
+				id:  "test-id",
+			},
+			wantErr: ErrInvalidStatusTransition,
+		},
+		{
+			name: "changed-concurrently",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						Get(gomock.Any(), "test-merchant", "test-id").
+						Return(activePaymentCode, nil)
+					repo.
+						EXPECT().
+						UpdateStatus(gomock.Any(), "test-merchant", "test-id", model.PAYMENT_CODE_STATUS_ACTIVE, model.PAYMENT_CODE_STATUS_INACTIVE, gomock.Any()).
+						Return(false, nil)
+					return repo
+				}(),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			args: args{
+				ctx: merchantCtx,
 				id:  "test-id",
 			},
-			wantP:   paymentCode,
+			wantErr: ErrInvalidStatusTransition,
+		},
+		{
+			name: "with-error-in-repo",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						Get(gomock.Any(), "test-merchant", "test-id").
+						Return(activePaymentCode, nil)
+					repo.
+						EXPECT().
+						UpdateStatus(gomock.Any(), "test-merchant", "test-id", model.PAYMENT_CODE_STATUS_ACTIVE, model.PAYMENT_CODE_STATUS_INACTIVE, gomock.Any()).
+						Return(false, err)
+					return repo
+				}(),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			args: args{
+				ctx: merchantCtx,
+				id:  "test-id",
+			},
+			wantErr: err,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := PaymentCodeUseCase{
+				Repo:       tt.fields.Repo,
+				Producer:   tt.fields.Producer,
+				Transactor: newMockTransactor(ctrl),
+			}
+			gotP, err := u.Deactivate(tt.args.ctx, tt.args.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PaymentCodeUseCase.Deactivate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && gotP.Status != tt.wantStatus {
+				t.Errorf("PaymentCodeUseCase.Deactivate() status = %v, want %v", gotP.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPaymentCodeUseCase_Reactivate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	inactivePaymentCode := model.PaymentCode{
+		Id:             "test-id",
+		MerchantId:     "test-merchant",
+		PaymentCode:    "test-payment-code",
+		Name:           "test name",
+		Status:         model.PAYMENT_CODE_STATUS_INACTIVE,
+		ExpirationDate: time.Now().Add(time.Hour),
+	}
+	pastDuePaymentCode := inactivePaymentCode
+	pastDuePaymentCode.ExpirationDate = time.Now().Add(-time.Hour)
+
+	type fields struct {
+		Repo     repository.IPaymentCodeRepository
+		Producer producer.IPaymentCodeMessageProducer
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		wantStatus string
+		wantErr    error
+	}{
+		{
+			name: "reactivate-success",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						Get(gomock.Any(), "test-merchant", "test-id").
+						Return(inactivePaymentCode, nil)
+					repo.
+						EXPECT().
+						UpdateStatus(gomock.Any(), "test-merchant", "test-id", model.PAYMENT_CODE_STATUS_INACTIVE, model.PAYMENT_CODE_STATUS_ACTIVE, gomock.Any()).
+						Return(true, nil)
+					return repo
+				}(),
+				Producer: func() producer.IPaymentCodeMessageProducer {
+					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+					producer.
+						EXPECT().
+						ProduceStatusChanged(gomock.Any(), gomock.Any(), model.PAYMENT_CODE_STATUS_INACTIVE).
+						Return(nil)
+					return producer
+				}(),
+			},
+			wantStatus: model.PAYMENT_CODE_STATUS_ACTIVE,
+		},
+		{
+			name: "past-expiration-date",
+			fields: fields{
+				Repo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						Get(gomock.Any(), "test-merchant", "test-id").
+						Return(pastDuePaymentCode, nil)
+					return repo
+				}(),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			wantErr: ErrInvalidStatusTransition,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := PaymentCodeUseCase{
+				Repo:       tt.fields.Repo,
+				Producer:   tt.fields.Producer,
+				Transactor: newMockTransactor(ctrl),
+			}
+			gotP, err := u.Reactivate(merchantCtx, "test-id")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PaymentCodeUseCase.Reactivate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && gotP.Status != tt.wantStatus {
+				t.Errorf("PaymentCodeUseCase.Reactivate() status = %v, want %v", gotP.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPaymentCodeUseCase_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	paymentCodes := []model.PaymentCode{
+		{Id: "test-id-1", PaymentCode: "test-payment-code-1", Name: "test name"},
+		{Id: "test-id-2", PaymentCode: "test-payment-code-2", Name: "test name"},
+	}
+	err := errors.New("Mock Error")
+
+	tests := []struct {
+		name     string
+		repo     repository.IPaymentCodeRepository
+		filter   model.PaymentCodeFilter
+		wantList model.PaymentCodeList
+		wantErr  bool
+	}{
+		{
+			name: "list-with-defaults",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				repo.
+					EXPECT().
+					List(gomock.Any(), model.PaymentCodeFilter{MerchantId: "test-merchant", Limit: DefaultListLimit, SortOrder: model.SORT_ORDER_ASC}).
+					Return(paymentCodes, "next-cursor", nil)
+				return repo
+			}(),
+			filter:   model.PaymentCodeFilter{},
+			wantList: model.PaymentCodeList{Data: paymentCodes, NextCursor: "next-cursor"},
+		},
+		{
+			name: "limit-is-capped",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				repo.
+					EXPECT().
+					List(gomock.Any(), model.PaymentCodeFilter{MerchantId: "test-merchant", Limit: MaxListLimit, SortOrder: model.SORT_ORDER_DESC}).
+					Return(nil, "", nil)
+				return repo
+			}(),
+			filter:   model.PaymentCodeFilter{Limit: MaxListLimit + 1, SortOrder: model.SORT_ORDER_DESC},
+			wantList: model.PaymentCodeList{Data: []model.PaymentCode{}},
+		},
+		{
+			name: "with-error-in-repo",
+			repo: func() repository.IPaymentCodeRepository {
+				repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+				repo.
+					EXPECT().
+					List(gomock.Any(), gomock.Any()).
+					Return(nil, "", err)
+				return repo
+			}(),
+			filter:  model.PaymentCodeFilter{},
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			u := PaymentCodeUseCase{
-				Repo:     tt.fields.Repo,
-				Producer: tt.fields.Producer,
+				Repo: tt.repo,
 			}
-			gotP, err := u.Get(tt.args.ctx, tt.args.id)
+			gotList, err := u.List(merchantCtx, tt.filter)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("PaymentCodeUseCase.Get() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("PaymentCodeUseCase.List() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(gotP, tt.wantP) {
-				t.Errorf("PaymentCodeUseCase.Get() = %v, want %v", gotP, tt.wantP)
+			if !tt.wantErr && !reflect.DeepEqual(gotList, tt.wantList) {
+				t.Errorf("PaymentCodeUseCase.List() = %v, want %v", gotList, tt.wantList)
 			}
 		})
 	}
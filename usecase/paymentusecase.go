@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/generator"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/producer"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/google/uuid"
+)
+
+type IPaymentUseCase interface {
+	Pay(ctx context.Context, paymentCode string, p *model.Payment) (err error)
+}
+
+type PaymentUseCase struct {
+	PaymentCodeRepo repository.IPaymentCodeRepository
+	Repo            repository.IPaymentRepository
+	Producer        producer.IPaymentCodeMessageProducer
+	Transactor      repository.ITransactor
+	// CodeFormat is the format of generated payment codes. A code that is not
+	// found but looks like one with a wrong check digit is reported as
+	// ErrInvalidCheckDigit, since it was most likely mistyped.
+	CodeFormat generator.Format
+}
+
+// Pay records p against the payment code of the merchant in ctx with the
+// given payment_code value and produces a payment received event, followed by
+// a status changed event when the payment completes the code. The currency
+// defaults to the one of the payment code.
+func (u PaymentUseCase) Pay(ctx context.Context, paymentCode string, p *model.Payment) (err error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return
+	}
+
+	err = u.Transactor.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		pc, err := u.PaymentCodeRepo.GetByPaymentCode(ctx, MerchantIDFromContext(ctx), paymentCode)
+		if errors.Is(err, repository.ErrNotFound) && hasInvalidCheckDigit(u.CodeFormat, paymentCode) {
+			err = ErrInvalidCheckDigit
+			return
+		}
+		if err != nil {
+			return
+		}
+
+		now := time.Now().UTC()
+		if !pc.IsPayable(now) {
+			err = ErrPaymentCodeNotPayable
+			return
+		}
+
+		if p.Currency == "" {
+			p.Currency = pc.Currency
+		}
+
+		err = pc.ValidatePayment(p.Amount, p.Currency)
+		if err != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidPayment, err)
+			return
+		}
+
+		pc, recorded, err := u.PaymentCodeRepo.RecordPayment(ctx, pc.MerchantId, pc.Id, p.Amount, now)
+		if err != nil {
+			return
+		}
+
+		// the code changed since it was read, e.g. a concurrent payment used it up
+		if !recorded {
+			err = ErrPaymentCodeNotPayable
+			return
+		}
+
+		p.Id = id.String()
+		p.MerchantId = pc.MerchantId
+		p.PaymentCodeId = pc.Id
+		p.PaymentCode = pc.PaymentCode
+		p.CreatedAt = now
+
+		err = u.Repo.Create(ctx, p)
+		if err != nil {
+			return
+		}
+
+		err = u.Producer.ProducePaymentReceived(ctx, p)
+		if err != nil {
+			return
+		}
+
+		if pc.Status == model.PAYMENT_CODE_STATUS_PAID {
+			err = u.Producer.ProduceStatusChanged(ctx, &pc, model.PAYMENT_CODE_STATUS_ACTIVE)
+		}
+
+		return
+	})
+
+	return
+}
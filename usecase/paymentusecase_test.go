@@ -0,0 +1,343 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/generator"
+	mock_producer "github.com/pevin/pevin-golang-training-beginner/mock/producer"
+	mock_repository "github.com/pevin/pevin-golang-training-beginner/mock/repository"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/producer"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestPaymentUseCase_Pay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	mockErr := errors.New("Mock Error")
+
+	paymentCode := model.PaymentCode{
+		Id:             "test-id",
+		MerchantId:     "test-merchant",
+		PaymentCode:    "test-payment-code",
+		Status:         model.PAYMENT_CODE_STATUS_ACTIVE,
+		AmountType:     model.AMOUNT_TYPE_FIXED,
+		Amount:         150000,
+		Currency:       "IDR",
+		UsageType:      model.USAGE_TYPE_MULTI,
+		ExpirationDate: time.Now().UTC().Add(time.Hour),
+	}
+	inactivePaymentCode := paymentCode
+	inactivePaymentCode.Status = model.PAYMENT_CODE_STATUS_INACTIVE
+	paidPaymentCode := paymentCode
+	paidPaymentCode.Status = model.PAYMENT_CODE_STATUS_PAID
+	paidPaymentCode.UsageCount = 1
+	paidPaymentCode.TotalPaid = 150000
+	expiredPaymentCode := paymentCode
+	expiredPaymentCode.ExpirationDate = time.Now().UTC().Add(-time.Hour)
+
+	type fields struct {
+		PaymentCodeRepo repository.IPaymentCodeRepository
+		Repo            repository.IPaymentRepository
+		Producer        producer.IPaymentCodeMessageProducer
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		amount  int64
+		wantErr error
+	}{
+		{
+			name: "pay-success",
+			fields: fields{
+				PaymentCodeRepo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						GetByPaymentCode(gomock.Any(), "test-merchant", "test-payment-code").
+						Return(paymentCode, nil)
+					repo.
+						EXPECT().
+						RecordPayment(gomock.Any(), "test-merchant", "test-id", int64(150000), gomock.Any()).
+						Return(paymentCode, true, nil)
+					return repo
+				}(),
+				Repo: func() repository.IPaymentRepository {
+					repo := mock_repository.NewMockIPaymentRepository(ctrl)
+					repo.
+						EXPECT().
+						Create(gomock.Any(), gomock.Any()).
+						Return(nil)
+					return repo
+				}(),
+				Producer: func() producer.IPaymentCodeMessageProducer {
+					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+					producer.
+						EXPECT().
+						ProducePaymentReceived(gomock.Any(), gomock.Any()).
+						Return(nil)
+					return producer
+				}(),
+			},
+			amount: 150000,
+		},
+		{
+			name: "payment-code-not-found",
+			fields: fields{
+				PaymentCodeRepo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						GetByPaymentCode(gomock.Any(), "test-merchant", "test-payment-code").
+						Return(model.PaymentCode{}, repository.ErrNotFound)
+					return repo
+				}(),
+				Repo:     mock_repository.NewMockIPaymentRepository(ctrl),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			amount:  150000,
+			wantErr: repository.ErrNotFound,
+		},
+		{
+			name: "payment-code-inactive",
+			fields: fields{
+				PaymentCodeRepo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						GetByPaymentCode(gomock.Any(), "test-merchant", "test-payment-code").
+						Return(inactivePaymentCode, nil)
+					return repo
+				}(),
+				Repo:     mock_repository.NewMockIPaymentRepository(ctrl),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			amount:  150000,
+			wantErr: ErrPaymentCodeNotPayable,
+		},
+		{
+			name: "payment-code-past-expiration-date",
+			fields: fields{
+				PaymentCodeRepo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						GetByPaymentCode(gomock.Any(), "test-merchant", "test-payment-code").
+						Return(expiredPaymentCode, nil)
+					return repo
+				}(),
+				Repo:     mock_repository.NewMockIPaymentRepository(ctrl),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			amount:  150000,
+			wantErr: ErrPaymentCodeNotPayable,
+		},
+		{
+			name: "amount-does-not-match",
+			fields: fields{
+				PaymentCodeRepo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						GetByPaymentCode(gomock.Any(), "test-merchant", "test-payment-code").
+						Return(paymentCode, nil)
+					return repo
+				}(),
+				Repo:     mock_repository.NewMockIPaymentRepository(ctrl),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			amount:  100000,
+			wantErr: ErrInvalidPayment,
+		},
+		{
+			name: "single-use-becomes-paid",
+			fields: fields{
+				PaymentCodeRepo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						GetByPaymentCode(gomock.Any(), "test-merchant", "test-payment-code").
+						Return(paymentCode, nil)
+					repo.
+						EXPECT().
+						RecordPayment(gomock.Any(), "test-merchant", "test-id", int64(150000), gomock.Any()).
+						Return(paidPaymentCode, true, nil)
+					return repo
+				}(),
+				Repo: func() repository.IPaymentRepository {
+					repo := mock_repository.NewMockIPaymentRepository(ctrl)
+					repo.
+						EXPECT().
+						Create(gomock.Any(), gomock.Any()).
+						Return(nil)
+					return repo
+				}(),
+				Producer: func() producer.IPaymentCodeMessageProducer {
+					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+					producer.
+						EXPECT().
+						ProducePaymentReceived(gomock.Any(), gomock.Any()).
+						Return(nil)
+					producer.
+						EXPECT().
+						ProduceStatusChanged(gomock.Any(), &paidPaymentCode, model.PAYMENT_CODE_STATUS_ACTIVE).
+						Return(nil)
+					return producer
+				}(),
+			},
+			amount: 150000,
+		},
+		{
+			name: "used-up-concurrently",
+			fields: fields{
+				PaymentCodeRepo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						GetByPaymentCode(gomock.Any(), "test-merchant", "test-payment-code").
+						Return(paymentCode, nil)
+					repo.
+						EXPECT().
+						RecordPayment(gomock.Any(), "test-merchant", "test-id", int64(150000), gomock.Any()).
+						Return(model.PaymentCode{}, false, nil)
+					return repo
+				}(),
+				Repo:     mock_repository.NewMockIPaymentRepository(ctrl),
+				Producer: mock_producer.NewMockIPaymentCodeMessageProducer(ctrl),
+			},
+			amount:  150000,
+			wantErr: ErrPaymentCodeNotPayable,
+		},
+		{
+			name: "with-error-in-producer",
+			fields: fields{
+				PaymentCodeRepo: func() repository.IPaymentCodeRepository {
+					repo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+					repo.
+						EXPECT().
+						GetByPaymentCode(gomock.Any(), "test-merchant", "test-payment-code").
+						Return(paymentCode, nil)
+					repo.
+						EXPECT().
+						RecordPayment(gomock.Any(), "test-merchant", "test-id", int64(150000), gomock.Any()).
+						Return(paymentCode, true, nil)
+					return repo
+				}(),
+				Repo: func() repository.IPaymentRepository {
+					repo := mock_repository.NewMockIPaymentRepository(ctrl)
+					repo.
+						EXPECT().
+						Create(gomock.Any(), gomock.Any()).
+						Return(nil)
+					return repo
+				}(),
+				Producer: func() producer.IPaymentCodeMessageProducer {
+					producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+					producer.
+						EXPECT().
+						ProducePaymentReceived(gomock.Any(), gomock.Any()).
+						Return(mockErr)
+					return producer
+				}(),
+			},
+			amount:  150000,
+			wantErr: mockErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := PaymentUseCase{
+				PaymentCodeRepo: tt.fields.PaymentCodeRepo,
+				Repo:            tt.fields.Repo,
+				Producer:        tt.fields.Producer,
+				Transactor:      newMockTransactor(ctrl),
+			}
+			payment := model.Payment{Amount: tt.amount}
+			err := u.Pay(merchantCtx, "test-payment-code", &payment)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("PaymentUseCase.Pay() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && (payment.Id == "" || payment.PaymentCodeId != paymentCode.Id || payment.Currency != paymentCode.Currency) {
+				t.Errorf("PaymentUseCase.Pay() payment = %+v", payment)
+			}
+		})
+	}
+}
+
+func TestPaymentUseCase_PayMistypedPaymentCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	format := generator.Format{Length: 11, Alphabet: generator.ALPHABET_NUMERIC, CheckDigit: generator.CHECK_DIGIT_LUHN}
+
+	tests := []struct {
+		name        string
+		paymentCode string
+		found       bool
+		wantErr     error
+	}{
+		{
+			name:        "existing-code-with-wrong-check-digit-is-paid",
+			paymentCode: "79927398723",
+			found:       true,
+		},
+		{
+			name:        "missing-code-with-wrong-check-digit",
+			paymentCode: "79927398723",
+			wantErr:     ErrInvalidCheckDigit,
+		},
+		{
+			name:        "missing-code-with-valid-check-digit",
+			paymentCode: "79927398713",
+			wantErr:     repository.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paymentCode := model.PaymentCode{
+				Id:             "test-id",
+				MerchantId:     "test-merchant",
+				PaymentCode:    tt.paymentCode,
+				Status:         model.PAYMENT_CODE_STATUS_ACTIVE,
+				AmountType:     model.AMOUNT_TYPE_FIXED,
+				Amount:         150000,
+				Currency:       "IDR",
+				UsageType:      model.USAGE_TYPE_MULTI,
+				ExpirationDate: time.Now().UTC().Add(time.Hour),
+			}
+
+			paymentCodeRepo := mock_repository.NewMockIPaymentCodeRepository(ctrl)
+			repo := mock_repository.NewMockIPaymentRepository(ctrl)
+			producer := mock_producer.NewMockIPaymentCodeMessageProducer(ctrl)
+			if tt.found {
+				paymentCodeRepo.EXPECT().GetByPaymentCode(gomock.Any(), "test-merchant", tt.paymentCode).Return(paymentCode, nil)
+				paymentCodeRepo.EXPECT().RecordPayment(gomock.Any(), "test-merchant", "test-id", int64(150000), gomock.Any()).Return(paymentCode, true, nil)
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				producer.EXPECT().ProducePaymentReceived(gomock.Any(), gomock.Any()).Return(nil)
+			} else {
+				paymentCodeRepo.EXPECT().GetByPaymentCode(gomock.Any(), "test-merchant", tt.paymentCode).Return(model.PaymentCode{}, repository.ErrNotFound)
+			}
+
+			u := PaymentUseCase{
+				PaymentCodeRepo: paymentCodeRepo,
+				Repo:            repo,
+				Producer:        producer,
+				Transactor:      newMockTransactor(ctrl),
+				CodeFormat:      format,
+			}
+
+			err := u.Pay(merchantCtx, tt.paymentCode, &model.Payment{Amount: 150000})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == repository.ErrNotFound && errors.Is(err, ErrInvalidCheckDigit)) {
+				t.Errorf("PaymentUseCase.Pay() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/google/uuid"
+)
+
+type IWebhookUseCase interface {
+	CreateEndpoint(ctx context.Context, e *model.WebhookEndpoint) (err error)
+	ListEndpoints(ctx context.Context) (list model.WebhookEndpointList, err error)
+	DeleteEndpoint(ctx context.Context, id string) (err error)
+	ListDeliveries(ctx context.Context, filter model.WebhookDeliveryFilter) (list model.WebhookDeliveryList, err error)
+	Redeliver(ctx context.Context, id string) (d model.WebhookDelivery, err error)
+}
+
+type WebhookUseCase struct {
+	Repo repository.IWebhookRepository
+}
+
+// CreateEndpoint stores a new endpoint for the merchant in ctx and sets
+// e.SigningSecret, which cannot be read again.
+func (u WebhookUseCase) CreateEndpoint(ctx context.Context, e *model.WebhookEndpoint) (err error) {
+	merchantID := MerchantIDFromContext(ctx)
+	if merchantID == "" {
+		err = ErrMerchantRequired
+		return
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return
+	}
+
+	e.Id = id.String()
+	e.MerchantId = merchantID
+	e.SigningSecret, err = newSigningSecret()
+	if err != nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+
+	err = u.Repo.CreateEndpoint(ctx, e)
+
+	return
+}
+
+// ListEndpoints returns every endpoint of the merchant in ctx, without their
+// signing secrets.
+func (u WebhookUseCase) ListEndpoints(ctx context.Context) (list model.WebhookEndpointList, err error) {
+	endpoints, err := u.Repo.ListEndpoints(ctx, MerchantIDFromContext(ctx))
+	if err != nil {
+		return
+	}
+
+	list.Data = make([]model.WebhookEndpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		e.SigningSecret = ""
+		list.Data = append(list.Data, e)
+	}
+
+	return
+}
+
+// DeleteEndpoint deletes an endpoint of the merchant in ctx. Its pending
+// deliveries are dropped.
+func (u WebhookUseCase) DeleteEndpoint(ctx context.Context, id string) (err error) {
+	return u.Repo.DeleteEndpoint(ctx, MerchantIDFromContext(ctx), id)
+}
+
+// ListDeliveries returns a page of the deliveries of the merchant in ctx
+// matching filter, newest first. The page size defaults to DefaultListLimit
+// and is capped at MaxListLimit.
+func (u WebhookUseCase) ListDeliveries(ctx context.Context, filter model.WebhookDeliveryFilter) (list model.WebhookDeliveryList, err error) {
+	filter.MerchantId = MerchantIDFromContext(ctx)
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultListLimit
+	}
+	if filter.Limit > MaxListLimit {
+		filter.Limit = MaxListLimit
+	}
+
+	list.Data, list.NextCursor, err = u.Repo.ListDeliveries(ctx, filter)
+	if err != nil {
+		return
+	}
+
+	if list.Data == nil {
+		list.Data = []model.WebhookDelivery{}
+	}
+
+	return
+}
+
+// Redeliver queues a delivery of the merchant in ctx again, including a
+// delivered or dead one, and gives it a fresh set of attempts.
+func (u WebhookUseCase) Redeliver(ctx context.Context, id string) (d model.WebhookDelivery, err error) {
+	return u.Repo.Redeliver(ctx, MerchantIDFromContext(ctx), id, time.Now().UTC())
+}
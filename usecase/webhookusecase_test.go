@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	mock_repository "github.com/pevin/pevin-golang-training-beginner/mock/repository"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestWebhookUseCase_CreateEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		repo    func() repository.IWebhookRepository
+		wantErr error
+	}{
+		{
+			name: "created",
+			ctx:  merchantCtx,
+			repo: func() repository.IWebhookRepository {
+				repo := mock_repository.NewMockIWebhookRepository(ctrl)
+				repo.
+					EXPECT().
+					CreateEndpoint(gomock.Any(), gomock.Any()).
+					Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "without-merchant",
+			ctx:  context.TODO(),
+			repo: func() repository.IWebhookRepository {
+				return mock_repository.NewMockIWebhookRepository(ctrl)
+			},
+			wantErr: ErrMerchantRequired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := WebhookUseCase{Repo: tt.repo()}
+			e := model.WebhookEndpoint{URL: "https://example.com/webhooks", EventTypes: []string{model.EVENT_TYPE_PAYMENT_RECEIVED}}
+			err := u.CreateEndpoint(tt.ctx, &e)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WebhookUseCase.CreateEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if e.Id == "" || e.MerchantId != "test-merchant" || e.CreatedAt.IsZero() {
+				t.Errorf("WebhookUseCase.CreateEndpoint() = %+v", e)
+			}
+			if !strings.HasPrefix(e.SigningSecret, SIGNING_SECRET_PREFIX) {
+				t.Errorf("WebhookUseCase.CreateEndpoint() signing secret = %v", e.SigningSecret)
+			}
+		})
+	}
+}
+
+func TestWebhookUseCase_ListEndpoints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	repo := mock_repository.NewMockIWebhookRepository(ctrl)
+	repo.
+		EXPECT().
+		ListEndpoints(gomock.Any(), "test-merchant").
+		Return([]model.WebhookEndpoint{{Id: "test-id", SigningSecret: "ss_test"}}, nil)
+
+	u := WebhookUseCase{Repo: repo}
+	list, err := u.ListEndpoints(merchantCtx)
+	if err != nil {
+		t.Fatalf("WebhookUseCase.ListEndpoints() error = %v", err)
+	}
+	if len(list.Data) != 1 || list.Data[0].Id != "test-id" || list.Data[0].SigningSecret != "" {
+		t.Errorf("WebhookUseCase.ListEndpoints() = %+v, want test-id without its signing secret", list)
+	}
+}
+
+func TestWebhookUseCase_ListDeliveries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name      string
+		limit     int
+		wantLimit int
+	}{
+		{
+			name:      "default-limit",
+			wantLimit: DefaultListLimit,
+		},
+		{
+			name:      "capped-limit",
+			limit:     MaxListLimit + 1,
+			wantLimit: MaxListLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mock_repository.NewMockIWebhookRepository(ctrl)
+			repo.
+				EXPECT().
+				ListDeliveries(gomock.Any(), model.WebhookDeliveryFilter{MerchantId: "test-merchant", Status: model.WEBHOOK_DELIVERY_STATUS_DEAD, Limit: tt.wantLimit}).
+				Return(nil, "", nil)
+
+			u := WebhookUseCase{Repo: repo}
+			list, err := u.ListDeliveries(merchantCtx, model.WebhookDeliveryFilter{Status: model.WEBHOOK_DELIVERY_STATUS_DEAD, Limit: tt.limit})
+			if err != nil {
+				t.Fatalf("WebhookUseCase.ListDeliveries() error = %v", err)
+			}
+			if list.Data == nil {
+				t.Errorf("WebhookUseCase.ListDeliveries() data = nil, want an empty list")
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/worker"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// payloadValidator validates request payloads. A validator caches what it
+// learns about each struct, so a single one is shared by every request.
+var payloadValidator = newValidator()
+
+var paymentCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// newValidator returns a validator that reports fields by their JSON name and
+// knows the payment_code, future and webhook_url tags.
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	v.RegisterValidation("payment_code", func(fl validator.FieldLevel) bool {
+		return paymentCodePattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("future", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		return ok && t.After(time.Now())
+	})
+	v.RegisterValidation("webhook_url", func(fl validator.FieldLevel) bool {
+		u, err := url.Parse(fl.Field().String())
+		return err == nil && u.Scheme == "https" && u.Hostname() != "" && u.User == nil && worker.IsPublicHost(u.Hostname())
+	})
+
+	return v
+}
+
+// validatePayload returns a detail for every field of payload that breaks one
+// of its validate tags.
+func validatePayload(payload interface{}) (details []model.ErrorDetail, err error) {
+	err = payloadValidator.Struct(payload)
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return
+	}
+	err = nil
+
+	for _, fieldError := range fieldErrors {
+		details = append(details, model.ErrorDetail{
+			Field:   fieldError.Field(),
+			Rule:    fieldError.Tag(),
+			Param:   fieldError.Param(),
+			Message: validationMessage(fieldError),
+		})
+	}
+
+	return
+}
+
+func validationMessage(fieldError validator.FieldError) string {
+	field := fieldError.Field()
+	param := fieldError.Param()
+
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("field '%s' is required", field)
+	case "max":
+		if fieldError.Kind() == reflect.String {
+			return fmt.Sprintf("field '%s' must be at most %s characters", field, param)
+		}
+		return fmt.Sprintf("field '%s' must be at most %s", field, param)
+	case "gt":
+		return fmt.Sprintf("field '%s' must be greater than %s", field, param)
+	case "len":
+		return fmt.Sprintf("field '%s' must be %s characters long", field, param)
+	case "alpha":
+		return fmt.Sprintf("field '%s' must contain only letters", field)
+	case "oneof":
+		return fmt.Sprintf("field '%s' must be one of %s", field, strings.ReplaceAll(param, " ", ", "))
+	case "payment_code":
+		return fmt.Sprintf("field '%s' must contain only letters, digits, '-' and '_'", field)
+	case "future":
+		return fmt.Sprintf("field '%s' must be in the future", field)
+	case "webhook_url":
+		return fmt.Sprintf("field '%s' must be an https URL of a public host", field)
+	default:
+		return fmt.Sprintf("field '%s' is invalid", field)
+	}
+}
+
+// validationError is the response to a request with the given problems, or
+// the zero Error when there are none. Message lists every problem, so
+// clients that only read it still see all of them.
+func validationError(details []model.ErrorDetail) (valError model.Error) {
+	if len(details) == 0 {
+		return
+	}
+
+	messages := make([]string, len(details))
+	for i, detail := range details {
+		messages[i] = detail.Message
+	}
+
+	return model.Error{Message: strings.Join(messages, "; "), Details: details}
+}
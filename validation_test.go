@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+)
+
+func TestPaymentCodeHandler_validate(t *testing.T) {
+	valid := model.PaymentCode{
+		PaymentCode: "test-payment-code",
+		Name:        "test-name",
+		AmountType:  model.AMOUNT_TYPE_FIXED,
+		Amount:      150000,
+		Currency:    "IDR",
+		UsageType:   model.USAGE_TYPE_SINGLE,
+	}
+
+	tests := []struct {
+		name        string
+		paymentCode func() model.PaymentCode
+		want        []model.ErrorDetail
+	}{
+		{
+			name:        "valid",
+			paymentCode: func() model.PaymentCode { return valid },
+		},
+		{
+			name: "every-problem-reported",
+			paymentCode: func() model.PaymentCode {
+				p := valid
+				p.Name = ""
+				p.PaymentCode = "test payment code"
+				p.Currency = "ID"
+				p.UsageType = "ONCE"
+				p.Amount = 0
+				return p
+			},
+			want: []model.ErrorDetail{
+				{Field: "payment_code", Rule: "payment_code", Message: "field 'payment_code' must contain only letters, digits, '-' and '_'"},
+				{Field: "name", Rule: "required", Message: "field 'name' is required"},
+				{Field: "currency", Rule: "len", Param: "3", Message: "field 'currency' must be 3 characters long"},
+				{Field: "usage_type", Rule: "oneof", Param: "SINGLE MULTI", Message: "field 'usage_type' must be one of SINGLE, MULTI"},
+				{Rule: "amount", Message: "field 'amount' is required when 'amount_type' is FIXED"},
+			},
+		},
+		{
+			name: "too-long",
+			paymentCode: func() model.PaymentCode {
+				p := valid
+				p.Name = strings.Repeat("a", 256)
+				return p
+			},
+			want: []model.ErrorDetail{
+				{Field: "name", Rule: "max", Param: "255", Message: "field 'name' must be at most 255 characters"},
+			},
+		},
+		{
+			name: "expiration-in-the-past",
+			paymentCode: func() model.PaymentCode {
+				p := valid
+				p.ExpirationDate = time.Now().Add(-time.Hour)
+				p.TTLSeconds = -1
+				return p
+			},
+			want: []model.ErrorDetail{
+				{Field: "expiration_date", Rule: "future", Message: "field 'expiration_date' must be in the future"},
+				{Field: "ttl_seconds", Rule: "gt", Param: "0", Message: "field 'ttl_seconds' must be greater than 0"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &PaymentCodeHandler{}
+			got, err := p.validate(tt.paymentCode())
+			if err != nil {
+				t.Fatalf("PaymentCodeHandler.validate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Details, tt.want) {
+				t.Errorf("PaymentCodeHandler.validate() details = %+v, want %+v", got.Details, tt.want)
+			}
+			if (got.Message != "") != (len(tt.want) > 0) {
+				t.Errorf("PaymentCodeHandler.validate() message = %q", got.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+)
+
+type WebhookHandler struct {
+	Usecase usecase.IWebhookUseCase
+}
+
+// endpointRouteHandler routes /webhook-endpoints and /webhook-endpoints/{id}.
+func (h *WebhookHandler) endpointRouteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/webhook-endpoints" {
+		switch r.Method {
+		case "POST":
+			h.createEndpointHandler(w, r)
+			return
+		case "GET":
+			h.listEndpointsHandler(w, r)
+			return
+		}
+		notFoundHandler(w, r)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/webhook-endpoints/")
+	if r.Method == "DELETE" && id != "" && !strings.Contains(id, "/") {
+		h.deleteEndpointHandler(w, r, id)
+		return
+	}
+	notFoundHandler(w, r)
+}
+
+// deliveryRouteHandler routes /webhook-deliveries and
+// /webhook-deliveries/{id}:redeliver.
+func (h *WebhookHandler) deliveryRouteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/webhook-deliveries" && r.Method == "GET" {
+		h.listDeliveriesHandler(w, r)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/webhook-deliveries/")
+	if r.Method == "POST" && strings.HasSuffix(id, ":redeliver") {
+		h.redeliverHandler(w, r, strings.TrimSuffix(id, ":redeliver"))
+		return
+	}
+	notFoundHandler(w, r)
+}
+
+func (h *WebhookHandler) createEndpointHandler(w http.ResponseWriter, r *http.Request) {
+	var endpoint model.WebhookEndpoint
+	err := decodeJSON(r, &endpoint)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	details, err := validatePayload(endpoint)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+	if len(details) > 0 {
+		validationErrorHandler(w, r, validationError(details))
+		return
+	}
+
+	err = h.Usecase.CreateEndpoint(r.Context(), &endpoint)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(endpoint)
+
+	// the response holds the signing secret
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resp)
+}
+
+func (h *WebhookHandler) listEndpointsHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := h.Usecase.ListEndpoints(r.Context())
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(list)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+func (h *WebhookHandler) deleteEndpointHandler(w http.ResponseWriter, r *http.Request, id string) {
+	err := h.Usecase.DeleteEndpoint(r.Context(), id)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) listDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseWebhookDeliveryFilter(r.URL.Query())
+	if err != nil {
+		badRequestHandler(w, r, err.Error())
+		return
+	}
+
+	list, err := h.Usecase.ListDeliveries(r.Context(), filter)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(list)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// redeliverHandler answers 202 since the delivery is only queued again; the
+// dispatcher sends it shortly after.
+func (h *WebhookHandler) redeliverHandler(w http.ResponseWriter, r *http.Request, id string) {
+	delivery, err := h.Usecase.Redeliver(r.Context(), id)
+	if err != nil {
+		errorHandler(w, r, err)
+		return
+	}
+
+	resp, _ := json.Marshal(delivery)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(resp)
+}
+
+func parseWebhookDeliveryFilter(query url.Values) (filter model.WebhookDeliveryFilter, err error) {
+	filter.EndpointId = query.Get("endpoint_id")
+	filter.Cursor = query.Get("cursor")
+
+	switch status := query.Get("status"); status {
+	case "", model.WEBHOOK_DELIVERY_STATUS_PENDING, model.WEBHOOK_DELIVERY_STATUS_DELIVERED, model.WEBHOOK_DELIVERY_STATUS_DEAD:
+		filter.Status = status
+	default:
+		err = fmt.Errorf("query 'status' must be one of %s, %s, %s", model.WEBHOOK_DELIVERY_STATUS_PENDING, model.WEBHOOK_DELIVERY_STATUS_DELIVERED, model.WEBHOOK_DELIVERY_STATUS_DEAD)
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit <= 0 {
+			err = fmt.Errorf("query 'limit' must be a positive integer")
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mock_usecase "github.com/pevin/pevin-golang-training-beginner/mock/usecase"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	endpoint := model.WebhookEndpoint{Id: "test-id", MerchantId: "test-merchant", URL: "https://example.com/webhooks", EventTypes: []string{model.EVENT_TYPE_PAYMENT_RECEIVED}}
+	withSecret := endpoint
+	withSecret.SigningSecret = "ss_test"
+	delivery := model.WebhookDelivery{Id: "test-delivery", EndpointId: "test-id", Payload: json.RawMessage(`{}`), Status: model.WEBHOOK_DELIVERY_STATUS_PENDING}
+
+	newRequest := func(method string, path string, body string) *http.Request {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+	noUsecase := func() usecase.IWebhookUseCase {
+		return mock_usecase.NewMockIWebhookUseCase(ctrl)
+	}
+
+	tests := []struct {
+		name        string
+		usecase     func() usecase.IWebhookUseCase
+		r           *http.Request
+		wantStatus  int
+		wantBody    interface{}
+		wantMessage string
+	}{
+		{
+			name: "create-endpoint",
+			usecase: func() usecase.IWebhookUseCase {
+				uc := mock_usecase.NewMockIWebhookUseCase(ctrl)
+				uc.
+					EXPECT().
+					CreateEndpoint(gomock.Any(), &model.WebhookEndpoint{URL: endpoint.URL, EventTypes: endpoint.EventTypes}).
+					DoAndReturn(func(_ interface{}, e *model.WebhookEndpoint) error {
+						*e = withSecret
+						return nil
+					})
+				return uc
+			},
+			r:          newRequest("POST", "/webhook-endpoints", `{"url":"https://example.com/webhooks","event_types":["payment.received"]}`),
+			wantStatus: http.StatusCreated,
+			wantBody:   withSecret,
+		},
+		{
+			name:        "create-endpoint-with-invalid-url",
+			usecase:     noUsecase,
+			r:           newRequest("POST", "/webhook-endpoints", `{"url":"ftp://example.com","event_types":["payment.received"]}`),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "field 'url' must be an https URL of a public host",
+		},
+		{
+			name:        "create-endpoint-with-http-url",
+			usecase:     noUsecase,
+			r:           newRequest("POST", "/webhook-endpoints", `{"url":"http://example.com/webhooks","event_types":["payment.received"]}`),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "field 'url' must be an https URL of a public host",
+		},
+		{
+			name:        "create-endpoint-with-link-local-url",
+			usecase:     noUsecase,
+			r:           newRequest("POST", "/webhook-endpoints", `{"url":"https://169.254.169.254/latest/meta-data","event_types":["payment.received"]}`),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "field 'url' must be an https URL of a public host",
+		},
+		{
+			name:        "create-endpoint-with-unknown-event-type",
+			usecase:     noUsecase,
+			r:           newRequest("POST", "/webhook-endpoints", `{"url":"https://example.com/webhooks","event_types":["payment.refunded"]}`),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "field 'event_types[0]' must be one of payment_code.created, payment_code.status_changed, payment_code.expired, payment.received",
+		},
+		{
+			name: "list-endpoints",
+			usecase: func() usecase.IWebhookUseCase {
+				uc := mock_usecase.NewMockIWebhookUseCase(ctrl)
+				uc.
+					EXPECT().
+					ListEndpoints(gomock.Any()).
+					Return(model.WebhookEndpointList{Data: []model.WebhookEndpoint{endpoint}}, nil)
+				return uc
+			},
+			r:          newRequest("GET", "/webhook-endpoints", ""),
+			wantStatus: http.StatusOK,
+			wantBody:   model.WebhookEndpointList{Data: []model.WebhookEndpoint{endpoint}},
+		},
+		{
+			name: "delete-endpoint",
+			usecase: func() usecase.IWebhookUseCase {
+				uc := mock_usecase.NewMockIWebhookUseCase(ctrl)
+				uc.
+					EXPECT().
+					DeleteEndpoint(gomock.Any(), "test-id").
+					Return(nil)
+				return uc
+			},
+			r:          newRequest("DELETE", "/webhook-endpoints/test-id", ""),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "list-deliveries",
+			usecase: func() usecase.IWebhookUseCase {
+				uc := mock_usecase.NewMockIWebhookUseCase(ctrl)
+				uc.
+					EXPECT().
+					ListDeliveries(gomock.Any(), model.WebhookDeliveryFilter{EndpointId: "test-id", Status: model.WEBHOOK_DELIVERY_STATUS_DEAD, Limit: 5}).
+					Return(model.WebhookDeliveryList{Data: []model.WebhookDelivery{delivery}}, nil)
+				return uc
+			},
+			r:          newRequest("GET", "/webhook-deliveries?endpoint_id=test-id&status=DEAD&limit=5", ""),
+			wantStatus: http.StatusOK,
+			wantBody:   model.WebhookDeliveryList{Data: []model.WebhookDelivery{delivery}},
+		},
+		{
+			name:        "list-deliveries-with-unknown-status",
+			usecase:     noUsecase,
+			r:           newRequest("GET", "/webhook-deliveries?status=LOST", ""),
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "query 'status' must be one of PENDING, DELIVERED, DEAD",
+		},
+		{
+			name: "redeliver",
+			usecase: func() usecase.IWebhookUseCase {
+				uc := mock_usecase.NewMockIWebhookUseCase(ctrl)
+				uc.
+					EXPECT().
+					Redeliver(gomock.Any(), "test-delivery").
+					Return(delivery, nil)
+				return uc
+			},
+			r:          newRequest("POST", "/webhook-deliveries/test-delivery:redeliver", ""),
+			wantStatus: http.StatusAccepted,
+			wantBody:   delivery,
+		},
+		{
+			name: "redeliver-unknown",
+			usecase: func() usecase.IWebhookUseCase {
+				uc := mock_usecase.NewMockIWebhookUseCase(ctrl)
+				uc.
+					EXPECT().
+					Redeliver(gomock.Any(), "unknown-delivery").
+					Return(model.WebhookDelivery{}, fmt.Errorf("%w: mock", repository.ErrNotFound))
+				return uc
+			},
+			r:          newRequest("POST", "/webhook-deliveries/unknown-delivery:redeliver", ""),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unknown-route",
+			usecase:    noUsecase,
+			r:          newRequest("DELETE", "/webhook-deliveries/test-delivery", ""),
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &WebhookHandler{Usecase: tt.usecase()}
+			handler := h.deliveryRouteHandler
+			if strings.HasPrefix(tt.r.URL.Path, "/webhook-endpoints") {
+				handler = h.endpointRouteHandler
+			}
+
+			rec := httptest.NewRecorder()
+			handler(rec, tt.r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("WebhookHandler status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantBody != nil {
+				want, _ := json.Marshal(tt.wantBody)
+				if got := rec.Body.String(); got != string(want) {
+					t.Errorf("WebhookHandler body = %s, want %s", got, want)
+				}
+			}
+			if tt.wantMessage != "" {
+				var got model.Error
+				json.Unmarshal(rec.Body.Bytes(), &got)
+				if got.Message != tt.wantMessage {
+					t.Errorf("WebhookHandler message = %q, want %q", got.Message, tt.wantMessage)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+)
+
+const (
+	DefaultExpirationInterval  = time.Minute
+	DefaultExpirationBatchSize = 100
+)
+
+// ExpirationWorker periodically moves ACTIVE and INACTIVE payment codes that
+// are past their expiration date to EXPIRED. It is safe to run one worker per
+// service instance: the repository skips rows another instance is already
+// expiring.
+type ExpirationWorker struct {
+	Usecase   usecase.IPaymentCodeUseCase
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Run expires due payment codes once immediately and then on every interval
+// until ctx is cancelled.
+func (w ExpirationWorker) Run(ctx context.Context) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultExpirationInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce expires all payment codes that are currently due.
+func (w ExpirationWorker) RunOnce(ctx context.Context) (expired int, err error) {
+	batchSize := w.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultExpirationBatchSize
+	}
+
+	expired, err = w.Usecase.ExpireDue(ctx, batchSize)
+	if err != nil {
+		log.Printf("expiration worker: %v (expired %d payment codes before failing)", err, expired)
+		return
+	}
+
+	if expired > 0 {
+		log.Printf("expiration worker: expired %d payment codes", expired)
+	}
+
+	return
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock_usecase "github.com/pevin/pevin-golang-training-beginner/mock/usecase"
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestExpirationWorker_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	err := errors.New("Mock Error")
+
+	type fields struct {
+		Usecase   usecase.IPaymentCodeUseCase
+		BatchSize int
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		wantExpired int
+		wantErr     bool
+	}{
+		{
+			name: "expire-success",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						ExpireDue(gomock.Any(), 10).
+						Return(3, nil)
+					return uc
+				}(),
+				BatchSize: 10,
+			},
+			wantExpired: 3,
+		},
+		{
+			name: "default-batch-size",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						ExpireDue(gomock.Any(), DefaultExpirationBatchSize).
+						Return(0, nil)
+					return uc
+				}(),
+			},
+			wantExpired: 0,
+		},
+		{
+			name: "with-error-in-usecase",
+			fields: fields{
+				Usecase: func() usecase.IPaymentCodeUseCase {
+					uc := mock_usecase.NewMockIPaymentCodeUseCase(ctrl)
+					uc.
+						EXPECT().
+						ExpireDue(gomock.Any(), 10).
+						Return(1, err)
+					return uc
+				}(),
+				BatchSize: 10,
+			},
+			wantExpired: 1,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := ExpirationWorker{
+				Usecase:   tt.fields.Usecase,
+				BatchSize: tt.fields.BatchSize,
+			}
+			gotExpired, err := w.RunOnce(context.TODO())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExpirationWorker.RunOnce() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotExpired != tt.wantExpired {
+				t.Errorf("ExpirationWorker.RunOnce() = %v, want %v", gotExpired, tt.wantExpired)
+			}
+		})
+	}
+}
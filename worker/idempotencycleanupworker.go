@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+)
+
+const (
+	DefaultIdempotencyCleanupInterval  = time.Hour
+	DefaultIdempotencyCleanupBatchSize = 1000
+)
+
+// IdempotencyCleanupWorker periodically deletes expired idempotency keys.
+type IdempotencyCleanupWorker struct {
+	Usecase   usecase.IIdempotencyUseCase
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Run deletes expired keys once immediately and then on every interval until
+// ctx is cancelled.
+func (w IdempotencyCleanupWorker) Run(ctx context.Context) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultIdempotencyCleanupInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce deletes all keys that are currently expired.
+func (w IdempotencyCleanupWorker) RunOnce(ctx context.Context) (deleted int, err error) {
+	batchSize := w.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultIdempotencyCleanupBatchSize
+	}
+
+	deleted, err = w.Usecase.DeleteExpired(ctx, batchSize)
+	if err != nil {
+		log.Printf("idempotency cleanup worker: %v (deleted %d keys before failing)", err, deleted)
+		return
+	}
+
+	if deleted > 0 {
+		log.Printf("idempotency cleanup worker: deleted %d expired keys", deleted)
+	}
+
+	return
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mock_usecase "github.com/pevin/pevin-golang-training-beginner/mock/usecase"
+	"github.com/pevin/pevin-golang-training-beginner/usecase"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestIdempotencyCleanupWorker_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	err := errors.New("Mock Error")
+
+	type fields struct {
+		Usecase   usecase.IIdempotencyUseCase
+		BatchSize int
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		wantDeleted int
+		wantErr     bool
+	}{
+		{
+			name: "delete-success",
+			fields: fields{
+				Usecase: func() usecase.IIdempotencyUseCase {
+					uc := mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+					uc.
+						EXPECT().
+						DeleteExpired(gomock.Any(), 10).
+						Return(3, nil)
+					return uc
+				}(),
+				BatchSize: 10,
+			},
+			wantDeleted: 3,
+		},
+		{
+			name: "default-batch-size",
+			fields: fields{
+				Usecase: func() usecase.IIdempotencyUseCase {
+					uc := mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+					uc.
+						EXPECT().
+						DeleteExpired(gomock.Any(), DefaultIdempotencyCleanupBatchSize).
+						Return(0, nil)
+					return uc
+				}(),
+			},
+			wantDeleted: 0,
+		},
+		{
+			name: "with-error-in-usecase",
+			fields: fields{
+				Usecase: func() usecase.IIdempotencyUseCase {
+					uc := mock_usecase.NewMockIIdempotencyUseCase(ctrl)
+					uc.
+						EXPECT().
+						DeleteExpired(gomock.Any(), 10).
+						Return(1, err)
+					return uc
+				}(),
+				BatchSize: 10,
+			},
+			wantDeleted: 1,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := IdempotencyCleanupWorker{
+				Usecase:   tt.fields.Usecase,
+				BatchSize: tt.fields.BatchSize,
+			}
+			gotDeleted, err := w.RunOnce(context.TODO())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IdempotencyCleanupWorker.RunOnce() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotDeleted != tt.wantDeleted {
+				t.Errorf("IdempotencyCleanupWorker.RunOnce() = %v, want %v", gotDeleted, tt.wantDeleted)
+			}
+		})
+	}
+}
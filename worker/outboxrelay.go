@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/producer"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+)
+
+const (
+	DefaultRelayInterval   = time.Second
+	DefaultRelayBatchSize  = 100
+	DefaultRelayMinBackoff = time.Second
+	DefaultRelayMaxBackoff = 10 * time.Minute
+	DefaultRelayLease      = 10 * time.Minute
+)
+
+// OutboxRelay publishes the messages written to the outbox. A message is
+// marked as published only after the publisher accepted it, so it is
+// delivered at least once; failed deliveries are retried with exponential
+// backoff.
+//
+// Each batch is claimed for Lease before it is published, so no database lock
+// or connection is held while the publisher is called, and several relays can
+// run at once. Lease must cover the publisher timeout for every message of a
+// batch, or a message may be published twice.
+type OutboxRelay struct {
+	Outbox     repository.IOutboxRepository
+	Publisher  producer.Publisher
+	Interval   time.Duration
+	BatchSize  int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	Lease      time.Duration
+}
+
+// Run publishes pending messages once immediately and then on every interval
+// until ctx is cancelled.
+func (w OutboxRelay) Run(ctx context.Context) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultRelayInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce publishes every message that is currently due.
+func (w OutboxRelay) RunOnce(ctx context.Context) (published int, err error) {
+	batchSize := w.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultRelayBatchSize
+	}
+
+	for {
+		var fetched, batchPublished int
+		fetched, batchPublished, err = w.relayBatch(ctx, batchSize)
+		published += batchPublished
+		if err != nil {
+			log.Printf("outbox relay: %v (published %d messages before failing)", err, published)
+			return
+		}
+
+		if fetched < batchSize {
+			return
+		}
+	}
+}
+
+func (w OutboxRelay) relayBatch(ctx context.Context, batchSize int) (fetched int, published int, err error) {
+	lease := w.Lease
+	if lease <= 0 {
+		lease = DefaultRelayLease
+	}
+
+	now := time.Now().UTC()
+	messages, err := w.Outbox.Claim(ctx, now, now.Add(lease), batchSize)
+	if err != nil {
+		return
+	}
+	fetched = len(messages)
+
+	for _, m := range messages {
+		publishErr := w.Publisher.Publish(ctx, m)
+		if publishErr != nil {
+			log.Printf("outbox relay: publish message %s (attempt %d): %v", m.Id, m.Attempts+1, publishErr)
+			err = w.Outbox.MarkFailed(ctx, m.Id, m.NextAttemptAt, time.Now().UTC().Add(w.backoff(m.Attempts+1)), publishErr.Error())
+		} else {
+			err = w.Outbox.MarkPublished(ctx, m.Id, m.NextAttemptAt, time.Now().UTC())
+			if err == nil {
+				published++
+			}
+		}
+		// the message is handled by the relay that took the lease over
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Printf("outbox relay: message %s: lease lost before the outcome was recorded", m.Id)
+			err = nil
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// backoff returns how long to wait before the given delivery attempt.
+func (w OutboxRelay) backoff(attempt int) time.Duration {
+	minBackoff, maxBackoff := w.MinBackoff, w.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultRelayMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRelayMaxBackoff
+	}
+	return exponentialBackoff(attempt, minBackoff, maxBackoff)
+}
+
+// exponentialBackoff returns minBackoff doubled for every failed attempt
+// before attempt, capped at maxBackoff.
+func exponentialBackoff(attempt int, minBackoff time.Duration, maxBackoff time.Duration) time.Duration {
+	backoff := minBackoff
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,194 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	mock_producer "github.com/pevin/pevin-golang-training-beginner/mock/producer"
+	mock_repository "github.com/pevin/pevin-golang-training-beginner/mock/repository"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/producer"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestOutboxRelay_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	err := errors.New("Mock Error")
+
+	leasedUntil := time.Now().UTC().Add(time.Minute)
+	messages := []model.OutboxMessage{
+		{Id: "test-id-1", Topic: "payment-codes", Key: "test-key", Payload: []byte(`{}`), NextAttemptAt: leasedUntil},
+		{Id: "test-id-2", Topic: "payment-codes", Key: "test-key", Payload: []byte(`{}`), Attempts: 2, NextAttemptAt: leasedUntil},
+	}
+
+	type fields struct {
+		Outbox    repository.IOutboxRepository
+		Publisher producer.Publisher
+	}
+	tests := []struct {
+		name          string
+		fields        fields
+		wantPublished int
+		wantErr       bool
+	}{
+		{
+			name: "publish-success",
+			fields: fields{
+				Outbox: func() repository.IOutboxRepository {
+					outbox := mock_repository.NewMockIOutboxRepository(ctrl)
+					outbox.
+						EXPECT().
+						Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+						Return(messages, nil)
+					outbox.
+						EXPECT().
+						MarkPublished(gomock.Any(), "test-id-1", leasedUntil, gomock.Any()).
+						Return(nil)
+					outbox.
+						EXPECT().
+						MarkPublished(gomock.Any(), "test-id-2", leasedUntil, gomock.Any()).
+						Return(nil)
+					return outbox
+				}(),
+				Publisher: func() producer.Publisher {
+					publisher := mock_producer.NewMockPublisher(ctrl)
+					publisher.
+						EXPECT().
+						Publish(gomock.Any(), gomock.Any()).
+						Return(nil).
+						Times(2)
+					return publisher
+				}(),
+			},
+			wantPublished: 2,
+		},
+		{
+			name: "failed-delivery-is-rescheduled",
+			fields: fields{
+				Outbox: func() repository.IOutboxRepository {
+					outbox := mock_repository.NewMockIOutboxRepository(ctrl)
+					outbox.
+						EXPECT().
+						Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+						Return(messages, nil)
+					outbox.
+						EXPECT().
+						MarkPublished(gomock.Any(), "test-id-1", leasedUntil, gomock.Any()).
+						Return(nil)
+					outbox.
+						EXPECT().
+						MarkFailed(gomock.Any(), "test-id-2", leasedUntil, gomock.Any(), err.Error()).
+						Return(nil)
+					return outbox
+				}(),
+				Publisher: func() producer.Publisher {
+					publisher := mock_producer.NewMockPublisher(ctrl)
+					gomock.InOrder(
+						publisher.
+							EXPECT().
+							Publish(gomock.Any(), messages[0]).
+							Return(nil),
+						publisher.
+							EXPECT().
+							Publish(gomock.Any(), messages[1]).
+							Return(err),
+					)
+					return publisher
+				}(),
+			},
+			wantPublished: 1,
+		},
+		{
+			name: "lost-lease",
+			fields: fields{
+				Outbox: func() repository.IOutboxRepository {
+					outbox := mock_repository.NewMockIOutboxRepository(ctrl)
+					outbox.
+						EXPECT().
+						Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+						Return(messages, nil)
+					outbox.
+						EXPECT().
+						MarkPublished(gomock.Any(), "test-id-1", leasedUntil, gomock.Any()).
+						Return(fmt.Errorf("%w: mock", repository.ErrNotFound))
+					outbox.
+						EXPECT().
+						MarkPublished(gomock.Any(), "test-id-2", leasedUntil, gomock.Any()).
+						Return(nil)
+					return outbox
+				}(),
+				Publisher: func() producer.Publisher {
+					publisher := mock_producer.NewMockPublisher(ctrl)
+					publisher.
+						EXPECT().
+						Publish(gomock.Any(), gomock.Any()).
+						Return(nil).
+						Times(2)
+					return publisher
+				}(),
+			},
+			wantPublished: 1,
+		},
+		{
+			name: "with-error-in-outbox",
+			fields: fields{
+				Outbox: func() repository.IOutboxRepository {
+					outbox := mock_repository.NewMockIOutboxRepository(ctrl)
+					outbox.
+						EXPECT().
+						Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+						Return(nil, err)
+					return outbox
+				}(),
+				Publisher: mock_producer.NewMockPublisher(ctrl),
+			},
+			wantPublished: 0,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := OutboxRelay{
+				Outbox:    tt.fields.Outbox,
+				Publisher: tt.fields.Publisher,
+				BatchSize: 10,
+			}
+			gotPublished, err := w.RunOnce(context.TODO())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("OutboxRelay.RunOnce() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotPublished != tt.wantPublished {
+				t.Errorf("OutboxRelay.RunOnce() = %v, want %v", gotPublished, tt.wantPublished)
+			}
+		})
+	}
+}
+
+func TestOutboxRelay_backoff(t *testing.T) {
+	w := OutboxRelay{MinBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		{attempt: 5, want: 10 * time.Second},
+		{attempt: 100, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := w.backoff(tt.attempt); got != tt.want {
+			t.Errorf("OutboxRelay.backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
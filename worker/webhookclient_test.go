@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublicHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{host: "example.com", want: true},
+		{host: "93.184.216.34", want: true},
+		{host: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{host: "localhost", want: false},
+		{host: "api.localhost.", want: false},
+		{host: "127.0.0.1", want: false},
+		{host: "0.0.0.0", want: false},
+		{host: "10.1.2.3", want: false},
+		{host: "172.16.0.1", want: false},
+		{host: "192.168.1.1", want: false},
+		{host: "100.64.0.1", want: false},
+		{host: "169.254.169.254", want: false},
+		{host: "::1", want: false},
+		{host: "fd00::1", want: false},
+		{host: "fe80::1", want: false},
+		{host: "::ffff:127.0.0.1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := IsPublicHost(tt.host); got != tt.want {
+				t.Errorf("IsPublicHost(%q) = %v, want %v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWebhookClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("webhook client reached %s", r.Host)
+	}))
+	defer server.Close()
+
+	// the server listens on loopback, which the client must refuse to dial
+	_, err := NewWebhookClient(time.Second).Get(server.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("NewWebhookClient().Get() error = %v, want %v", err, ErrBlockedAddress)
+	}
+	if got := deliveryError(err); got != "endpoint address is not public" {
+		t.Errorf("deliveryError() = %q", got)
+	}
+}
@@ -0,0 +1,265 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+	"github.com/pevin/pevin-golang-training-beginner/signature"
+)
+
+const (
+	DefaultWebhookInterval    = time.Second
+	DefaultWebhookBatchSize   = 20
+	DefaultWebhookMaxAttempts = 10
+	DefaultWebhookMinBackoff  = 30 * time.Second
+	DefaultWebhookMaxBackoff  = 2 * time.Hour
+	DefaultWebhookLease       = 5 * time.Minute
+)
+
+var (
+	errInsecureEndpoint = errors.New("endpoint URL must use https")
+	errEndpointStatus   = errors.New("endpoint answered with a non-2xx status")
+)
+
+// WebhookDispatcher POSTs queued webhook deliveries to the endpoints of the
+// merchants. Every request is signed with the secret of its endpoint, so
+// merchants can check it with package signature. A delivery succeeds on any
+// 2xx response; failed ones are retried with exponential backoff and moved to
+// the dead letter state after MaxAttempts attempts.
+//
+// Each batch is claimed for Lease before it is sent, so no database lock or
+// connection is held while endpoints answer, and several dispatchers can run
+// at once. The deliveries of different endpoints are sent concurrently; those
+// of one endpoint are sent in order, so a slow endpoint only delays its own.
+// Lease must cover the client timeout for every delivery of a batch, or a
+// delivery may be sent twice.
+type WebhookDispatcher struct {
+	Repo        repository.IWebhookRepository
+	Client      *http.Client
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	Lease       time.Duration
+}
+
+// Run sends due deliveries once immediately and then on every interval until
+// ctx is cancelled.
+func (w WebhookDispatcher) Run(ctx context.Context) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWebhookInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends every delivery that is currently due.
+func (w WebhookDispatcher) RunOnce(ctx context.Context) (delivered int, err error) {
+	batchSize := w.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultWebhookBatchSize
+	}
+
+	for {
+		var fetched, batchDelivered int
+		fetched, batchDelivered, err = w.dispatchBatch(ctx, batchSize)
+		delivered += batchDelivered
+		if err != nil {
+			log.Printf("webhook dispatcher: %v (delivered %d webhooks before failing)", err, delivered)
+			return
+		}
+
+		if fetched < batchSize {
+			return
+		}
+	}
+}
+
+func (w WebhookDispatcher) dispatchBatch(ctx context.Context, batchSize int) (fetched int, delivered int, err error) {
+	now := time.Now().UTC()
+	deliveries, err := w.Repo.Claim(ctx, now, now.Add(w.lease()), batchSize)
+	if err != nil {
+		return
+	}
+	fetched = len(deliveries)
+
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+	})
+	var endpointIDs []string
+	byEndpoint := make(map[string][]model.WebhookDelivery)
+	for _, d := range deliveries {
+		if _, ok := byEndpoint[d.EndpointId]; !ok {
+			endpointIDs = append(endpointIDs, d.EndpointId)
+		}
+		byEndpoint[d.EndpointId] = append(byEndpoint[d.EndpointId], d)
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, endpointID := range endpointIDs {
+		wg.Add(1)
+		go func(deliveries []model.WebhookDelivery) {
+			defer wg.Done()
+
+			for _, d := range deliveries {
+				ok, deliverErr := w.deliver(ctx, d)
+
+				mu.Lock()
+				if ok {
+					delivered++
+				}
+				if deliverErr != nil && err == nil {
+					err = deliverErr
+				}
+				mu.Unlock()
+			}
+		}(byEndpoint[endpointID])
+	}
+	wg.Wait()
+
+	return
+}
+
+// deliver sends a claimed delivery and records the outcome. A lost lease is
+// logged rather than returned: the delivery was redelivered or picked up by
+// another dispatcher, which records its own outcome.
+func (w WebhookDispatcher) deliver(ctx context.Context, d model.WebhookDelivery) (ok bool, err error) {
+	statusCode, sendErr := w.send(ctx, d)
+	attempt := d.Attempts + 1
+	now := time.Now().UTC()
+	switch {
+	case sendErr == nil:
+		err = w.Repo.MarkDelivered(ctx, d.Id, d.NextAttemptAt, statusCode, now)
+		ok = err == nil
+	case attempt >= w.maxAttempts():
+		log.Printf("webhook dispatcher: deliver %s to %s (attempt %d, giving up): %v", d.Id, d.URL, attempt, sendErr)
+		err = w.Repo.MarkDead(ctx, d.Id, d.NextAttemptAt, statusCode, deliveryError(sendErr), now)
+	default:
+		log.Printf("webhook dispatcher: deliver %s to %s (attempt %d): %v", d.Id, d.URL, attempt, sendErr)
+		err = w.Repo.MarkFailed(ctx, d.Id, d.NextAttemptAt, statusCode, deliveryError(sendErr), now.Add(w.backoff(attempt)), now)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		log.Printf("webhook dispatcher: delivery %s: lease lost before the outcome was recorded", d.Id)
+		err = nil
+	}
+
+	return
+}
+
+// deliveryError returns the error recorded for a failed attempt. Merchants can
+// read it, so it names the kind of failure without the details of the network
+// error or response, which are only logged.
+func deliveryError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errInsecureEndpoint):
+		return errInsecureEndpoint.Error()
+	case errors.Is(err, errEndpointStatus):
+		return errEndpointStatus.Error()
+	case errors.Is(err, ErrBlockedAddress):
+		return "endpoint address is not public"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "endpoint timed out"
+	default:
+		return "endpoint could not be reached"
+	}
+}
+
+// send POSTs the delivery to its endpoint. statusCode is zero when the
+// endpoint could not be reached.
+func (w WebhookDispatcher) send(ctx context.Context, d model.WebhookDelivery) (statusCode int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return
+	}
+	if req.URL.Scheme != "https" {
+		err = errInsecureEndpoint
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Delivery-Id", d.Id)
+	req.Header.Set("X-Event-Id", d.EventId)
+	req.Header.Set("X-Event-Type", d.EventType)
+
+	nonce, err := signature.NewNonce()
+	if err != nil {
+		return
+	}
+	err = signature.Sign(req, d.SigningSecret, time.Now(), nonce)
+	if err != nil {
+		return
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	statusCode = resp.StatusCode
+	if statusCode < 200 || statusCode > 299 {
+		err = fmt.Errorf("%w: %s", errEndpointStatus, resp.Status)
+	}
+
+	return
+}
+
+func (w WebhookDispatcher) lease() time.Duration {
+	if w.Lease <= 0 {
+		return DefaultWebhookLease
+	}
+	return w.Lease
+}
+
+func (w WebhookDispatcher) maxAttempts() int {
+	if w.MaxAttempts <= 0 {
+		return DefaultWebhookMaxAttempts
+	}
+	return w.MaxAttempts
+}
+
+// backoff returns how long to wait before the given delivery attempt.
+func (w WebhookDispatcher) backoff(attempt int) time.Duration {
+	minBackoff, maxBackoff := w.MinBackoff, w.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultWebhookMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultWebhookMaxBackoff
+	}
+	return exponentialBackoff(attempt, minBackoff, maxBackoff)
+}
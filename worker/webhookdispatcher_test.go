@@ -0,0 +1,245 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mock_repository "github.com/pevin/pevin-golang-training-beginner/mock/repository"
+	"github.com/pevin/pevin-golang-training-beginner/model"
+	"github.com/pevin/pevin-golang-training-beginner/repository"
+	"github.com/pevin/pevin-golang-training-beginner/signature"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestWebhookDispatcher_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	defer ctrl.Finish()
+
+	err := errors.New("Mock Error")
+
+	// a delivery to /wait is only answered once a delivery to /release of
+	// another endpoint arrived, which needs both to be sent concurrently
+	released := make(chan struct{})
+
+	verifier := signature.Verifier{Window: time.Minute, Nonces: signature.NewNonceCache()}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := verifier.Verify(r, body, "test-endpoint", "ss_test", time.Now()); err != nil {
+			t.Errorf("webhook signature: %v", err)
+		}
+		if r.Header.Get("X-Event-Type") != model.EVENT_TYPE_PAYMENT_RECEIVED || string(body) != `{"id":"test-event"}` {
+			t.Errorf("unexpected webhook %v: %s", r.Header, body)
+		}
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/release":
+			close(released)
+		case "/wait":
+			select {
+			case <-released:
+			case <-time.After(5 * time.Second):
+				w.WriteHeader(http.StatusGatewayTimeout)
+			}
+		}
+	}))
+	defer server.Close()
+
+	leasedUntil := time.Now().UTC().Add(time.Minute)
+	delivery := func(id string, path string, attempts int) model.WebhookDelivery {
+		return model.WebhookDelivery{
+			Id:            id,
+			EndpointId:    "test-endpoint" + path,
+			URL:           server.URL + path,
+			SigningSecret: "ss_test",
+			EventId:       "test-event",
+			EventType:     model.EVENT_TYPE_PAYMENT_RECEIVED,
+			Payload:       []byte(`{"id":"test-event"}`),
+			Status:        model.WEBHOOK_DELIVERY_STATUS_PENDING,
+			Attempts:      attempts,
+			NextAttemptAt: leasedUntil,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		repo          func() repository.IWebhookRepository
+		wantDelivered int
+		wantErr       bool
+	}{
+		{
+			name: "delivered",
+			repo: func() repository.IWebhookRepository {
+				repo := mock_repository.NewMockIWebhookRepository(ctrl)
+				repo.
+					EXPECT().
+					Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+					DoAndReturn(func(ctx context.Context, now time.Time, leasedUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+						if got := leasedUntil.Sub(now); got != time.Minute {
+							t.Errorf("leased for %v, want 1m", got)
+						}
+						return []model.WebhookDelivery{delivery("test-id-1", "/ok", 0), delivery("test-id-2", "/ok", 1)}, nil
+					})
+				repo.
+					EXPECT().
+					MarkDelivered(gomock.Any(), "test-id-1", leasedUntil, http.StatusOK, gomock.Any()).
+					Return(nil)
+				repo.
+					EXPECT().
+					MarkDelivered(gomock.Any(), "test-id-2", leasedUntil, http.StatusOK, gomock.Any()).
+					Return(nil)
+				return repo
+			},
+			wantDelivered: 2,
+		},
+		{
+			name: "failed-delivery-is-rescheduled",
+			repo: func() repository.IWebhookRepository {
+				repo := mock_repository.NewMockIWebhookRepository(ctrl)
+				repo.
+					EXPECT().
+					Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+					Return([]model.WebhookDelivery{delivery("test-id", "/fail", 1)}, nil)
+				repo.
+					EXPECT().
+					MarkFailed(gomock.Any(), "test-id", leasedUntil, http.StatusInternalServerError, "endpoint answered with a non-2xx status", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, id string, leasedUntil time.Time, statusCode int, lastError string, nextAttemptAt time.Time, now time.Time) error {
+						if got := nextAttemptAt.Sub(now); got != 2*time.Second {
+							t.Errorf("next attempt in %v, want 2s", got)
+						}
+						return nil
+					})
+				return repo
+			},
+		},
+		{
+			name: "last-attempt-is-dead-lettered",
+			repo: func() repository.IWebhookRepository {
+				repo := mock_repository.NewMockIWebhookRepository(ctrl)
+				repo.
+					EXPECT().
+					Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+					Return([]model.WebhookDelivery{delivery("test-id", "/fail", 2)}, nil)
+				repo.
+					EXPECT().
+					MarkDead(gomock.Any(), "test-id", leasedUntil, http.StatusInternalServerError, "endpoint answered with a non-2xx status", gomock.Any()).
+					Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "unreachable-endpoint",
+			repo: func() repository.IWebhookRepository {
+				unreachable := delivery("test-id", "", 0)
+				unreachable.URL = "https://127.0.0.1:1/webhooks"
+				repo := mock_repository.NewMockIWebhookRepository(ctrl)
+				repo.
+					EXPECT().
+					Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+					Return([]model.WebhookDelivery{unreachable}, nil)
+				repo.
+					EXPECT().
+					MarkFailed(gomock.Any(), "test-id", leasedUntil, 0, "endpoint could not be reached", gomock.Any(), gomock.Any()).
+					Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "insecure-endpoint",
+			repo: func() repository.IWebhookRepository {
+				insecure := delivery("test-id", "/ok", 0)
+				insecure.URL = strings.Replace(insecure.URL, "https:", "http:", 1)
+				repo := mock_repository.NewMockIWebhookRepository(ctrl)
+				repo.
+					EXPECT().
+					Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+					Return([]model.WebhookDelivery{insecure}, nil)
+				repo.
+					EXPECT().
+					MarkFailed(gomock.Any(), "test-id", leasedUntil, 0, "endpoint URL must use https", gomock.Any(), gomock.Any()).
+					Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "endpoints-are-sent-concurrently",
+			repo: func() repository.IWebhookRepository {
+				waiting := delivery("test-id-1", "/wait", 0)
+				releasing := delivery("test-id-2", "/release", 0)
+				releasing.CreatedAt = waiting.CreatedAt.Add(time.Second)
+				repo := mock_repository.NewMockIWebhookRepository(ctrl)
+				repo.
+					EXPECT().
+					Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+					Return([]model.WebhookDelivery{releasing, waiting}, nil)
+				repo.
+					EXPECT().
+					MarkDelivered(gomock.Any(), "test-id-1", leasedUntil, http.StatusOK, gomock.Any()).
+					Return(nil)
+				repo.
+					EXPECT().
+					MarkDelivered(gomock.Any(), "test-id-2", leasedUntil, http.StatusOK, gomock.Any()).
+					Return(nil)
+				return repo
+			},
+			wantDelivered: 2,
+		},
+		{
+			name: "lost-lease",
+			repo: func() repository.IWebhookRepository {
+				repo := mock_repository.NewMockIWebhookRepository(ctrl)
+				repo.
+					EXPECT().
+					Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+					Return([]model.WebhookDelivery{delivery("test-id", "/ok", 0)}, nil)
+				repo.
+					EXPECT().
+					MarkDelivered(gomock.Any(), "test-id", leasedUntil, http.StatusOK, gomock.Any()).
+					Return(fmt.Errorf("%w: mock", repository.ErrNotFound))
+				return repo
+			},
+		},
+		{
+			name: "with-error-in-repo",
+			repo: func() repository.IWebhookRepository {
+				repo := mock_repository.NewMockIWebhookRepository(ctrl)
+				repo.
+					EXPECT().
+					Claim(gomock.Any(), gomock.Any(), gomock.Any(), 10).
+					Return(nil, err)
+				return repo
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := WebhookDispatcher{
+				Repo:        tt.repo(),
+				Client:      server.Client(),
+				BatchSize:   10,
+				MaxAttempts: 3,
+				MinBackoff:  time.Second,
+				MaxBackoff:  time.Minute,
+				Lease:       time.Minute,
+			}
+			gotDelivered, err := w.RunOnce(context.TODO())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WebhookDispatcher.RunOnce() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotDelivered != tt.wantDelivered {
+				t.Errorf("WebhookDispatcher.RunOnce() = %v, want %v", gotDelivered, tt.wantDelivered)
+			}
+		})
+	}
+}